@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+)
+
+func catalogueCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("catalogue", flag.ContinueOnError)
+	nf := &namingFlags{}
+	nf.register(fs)
+	format := fs.String("format", "markdown", "Output format (markdown or json)")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	naming, err := nf.naming()
+	if err != nil {
+		return err
+	}
+	descs := naming.Describe()
+	switch *format {
+	case "markdown":
+		return writeMarkdown(stdout, descs)
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(descs)
+	default:
+		return fmt.Errorf("unknown format `%s`", *format)
+	}
+}
+
+func writeMarkdown(w io.Writer, descs []gen.MetricDesc) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("# Metrics\n\n")
+	bw.WriteString("Generated by `go run ./cmd/kafka_stats_exporter catalogue`. Do not edit.\n\n")
+	bw.WriteString("| Name | Type | Unit | Labels | Help | Source |\n")
+	bw.WriteString("|------|------|------|--------|------|--------|\n")
+	for _, d := range descs {
+		labels := make([]string, len(d.LabelNames))
+		for i, ln := range d.LabelNames {
+			labels[i] = "`" + ln + "`"
+		}
+		fmt.Fprintf(bw, "| `%s` | %s | %s | %s | %s | `%s` |\n",
+			d.Name, d.Type, d.Unit, strings.Join(labels, ", "), markdownEscape(d.Help), d.Path)
+	}
+	return bw.Flush()
+}
+
+// markdownEscape escapes characters breaking a table cell
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/dashboard"
+)
+
+func dashboardCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("dashboard", flag.ContinueOnError)
+	nf := &namingFlags{}
+	nf.register(fs)
+	title := fs.String("title", "Kafka client statistics", "Title of the dashboard")
+	uid := fs.String("uid", "kafka-stats-exporter", "UID of the dashboard")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	naming, err := nf.naming()
+	if err != nil {
+		return err
+	}
+	d, err := dashboard.Build(naming, dashboard.WithTitle(*title), dashboard.WithUID(*uid))
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
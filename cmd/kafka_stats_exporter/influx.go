@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/influx"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+)
+
+// influxCommand converts a stream of JSON statistics of librdkafka to
+// InfluxDB line protocol
+func influxCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("influx", flag.ContinueOnError)
+	// Windows are exported by default as measurement kafka_window
+	nf := &namingFlags{windows: true}
+	nf.register(fs)
+	output := fs.String("o", "", "Append to this file instead of writing to stdout")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	naming, err := nf.naming()
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		return encode(influx.NewEncoder(stdout, naming), stdin)
+	}
+	f, err := os.OpenFile(*output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	err = encode(influx.NewEncoder(f, naming), stdin)
+	closeErr := f.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// encode encodes every JSON value of stdin with enc
+func encode(enc *influx.Encoder, stdin io.Reader) error {
+	dec := json.NewDecoder(stdin)
+	for {
+		stats := &typed.Stats{}
+		err := dec.Decode(stats)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = enc.Encode(stats)
+		if err != nil {
+			return err
+		}
+	}
+}
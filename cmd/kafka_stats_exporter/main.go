@@ -0,0 +1,64 @@
+// Command kafka_stats_exporter generates artifacts matching the metrics
+// exported by package v0.
+//
+//	kafka_stats_exporter rules [flags] > kafka.rules.yaml
+//	kafka_stats_exporter dashboard [flags] > kafka.dashboard.json
+//	kafka_stats_exporter catalogue [-format markdown|json] [flags] > METRICS.md
+//	kafka_stats_exporter influx [-o file] [flags] < stats.json
+//	kafka_stats_exporter remote-write -url url [flags] < stats.json
+//	kafka_stats_exporter serve [-listen addr] [flags] < stats.json
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// ErrUsage is returned for unknown subcommands
+var ErrUsage = errors.New("usage: kafka_stats_exporter <subcommand> [flags]")
+
+type subcommand func(args []string, stdin io.Reader, stdout io.Writer) error
+
+var subcommands = map[string]subcommand{
+	"catalogue":    catalogueCommand,
+	"dashboard":    dashboardCommand,
+	"influx":       influxCommand,
+	"remote-write": remoteWriteCommand,
+	"rules":        rulesCommand,
+	"serve":        serveCommand,
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return usage()
+	}
+	cmd, ok := subcommands[args[0]]
+	if !ok {
+		return usage()
+	}
+	return cmd(args[1:], stdin, stdout)
+}
+
+func usage() error {
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("%w\nsubcommands: %v", ErrUsage, names)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/dashboard"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+	"github.com/golang/snappy"
+)
+
+func TestRunUnknownSubcommand(t *testing.T) {
+	err := run([]string{"unknown"}, nil, &bytes.Buffer{})
+	if !errors.Is(err, ErrUsage) {
+		t.Fatal("Expected ErrUsage but got", err)
+	}
+}
+
+func TestRules(t *testing.T) {
+	b := &bytes.Buffer{}
+	err := run([]string{"rules"}, nil, b)
+	if err != nil {
+		t.Fatal("rules failed:", err)
+	}
+	expected, err := os.ReadFile("../../v0/pkg/prometheus/rules/testdata/rules.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != string(expected) {
+		t.Error("Unexpected rules", b.String())
+	}
+
+	b.Reset()
+	err = run([]string{"rules", "-namespace", "kafka", "-consumer-lag", "10"}, nil, b)
+	if err != nil {
+		t.Fatal("rules failed:", err)
+	}
+	if !strings.Contains(b.String(), `"topic:kafka_topics_partitions_consumer_lag:sum > 10"`) {
+		t.Error("Flags not applied", b.String())
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	b := &bytes.Buffer{}
+	err := run([]string{"dashboard", "-namespace", "kafka", "-uid", "kafka"}, nil, b)
+	if err != nil {
+		t.Fatal("dashboard failed:", err)
+	}
+	d := &dashboard.Dashboard{}
+	err = json.Unmarshal(b.Bytes(), d)
+	if err != nil {
+		t.Fatal("Invalid dashboard JSON:", err)
+	}
+	if d.UID != "kafka" {
+		t.Error("Unexpected UID", d.UID)
+	}
+	if !strings.HasPrefix(d.Panels[0].Panels[0].Title, "kafka_") {
+		t.Error("Namespace not applied", d.Panels[0].Panels[0].Title)
+	}
+}
+
+func TestCatalogueUpToDate(t *testing.T) {
+	b := &bytes.Buffer{}
+	err := run([]string{"catalogue"}, nil, b)
+	if err != nil {
+		t.Fatal("catalogue failed:", err)
+	}
+	expected, err := os.ReadFile("../../METRICS.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != string(expected) {
+		t.Error("METRICS.md is outdated. Run `go run ./cmd/kafka_stats_exporter catalogue > METRICS.md`")
+	}
+}
+
+func TestCatalogueJSON(t *testing.T) {
+	b := &bytes.Buffer{}
+	err := run([]string{"catalogue", "-format", "json"}, nil, b)
+	if err != nil {
+		t.Fatal("catalogue failed:", err)
+	}
+	var descs []gen.MetricDesc
+	err = json.Unmarshal(b.Bytes(), &descs)
+	if err != nil {
+		t.Fatal("Invalid catalogue JSON:", err)
+	}
+	if descs[0].Name != "msg_cnt_fill_ratio" || descs[0].Path != "Stats.msg_cnt_fill_ratio()" {
+		t.Errorf("Unexpected first metric %#v", descs[0])
+	}
+
+	err = run([]string{"catalogue", "-format", "xml"}, nil, b)
+	if err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestInflux(t *testing.T) {
+	stats := `{"name": "rdkafka#consumer-1", "client_id": "rdkafka", "type": "consumer", "time": 1700000000, "rx": 5}
+{"name": "rdkafka#consumer-1", "client_id": "rdkafka", "type": "consumer", "time": 1700000060, "rx": 7}`
+	output := t.TempDir() + "/stats.influx"
+	err := run([]string{"influx", "-o", output}, strings.NewReader(stats), nil)
+	if err != nil {
+		t.Fatal("influx failed:", err)
+	}
+	b, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines but got %d: %s", len(lines), b)
+	}
+	if !strings.HasPrefix(lines[1], "kafka_client,client_id=rdkafka,name=rdkafka#consumer-1,type=consumer ") ||
+		!strings.Contains(lines[1], ",rx=7i,") || !strings.HasSuffix(lines[1], " 1700000060000000000") {
+		t.Error("Unexpected line", lines[1])
+	}
+
+	err = run([]string{"influx"}, strings.NewReader("{"), &bytes.Buffer{})
+	if err == nil {
+		t.Error("Expected error for malformed stats")
+	}
+}
+
+func TestRemoteWrite(t *testing.T) {
+	var bodies [][]byte
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		compressed, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		b, err := snappy.Decode(nil, compressed)
+		if err != nil {
+			t.Error("Expected snappy compressed body:", err)
+		}
+		bodies = append(bodies, b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer s.Close()
+
+	stats := `{"name": "rdkafka#consumer-1", "client_id": "rdkafka", "type": "consumer", "rx": 5}
+{"name": "rdkafka#consumer-1", "client_id": "rdkafka", "type": "consumer", "rx": 7}`
+	err := run([]string{"remote-write", "-url", s.URL, "-interval", "1h", "-namespace", "kafka"}, strings.NewReader(stats), &bytes.Buffer{})
+	if err != nil {
+		t.Fatal("remote-write failed:", err)
+	}
+	if len(bodies) != 1 {
+		t.Fatalf("Expected a single send at the end of the stream. Got: %d", len(bodies))
+	}
+	for _, expected := range []string{"kafka_rx_total", "rdkafka#consumer-1"} {
+		if !bytes.Contains(bodies[0], []byte(expected)) {
+			t.Errorf("Expected %s to be sent", expected)
+		}
+	}
+
+	err = run([]string{"remote-write"}, strings.NewReader(stats), &bytes.Buffer{})
+	if err == nil {
+		t.Error("Expected error without -url")
+	}
+}
+
+func TestServe(t *testing.T) {
+	stats := `{"name": "rdkafka#consumer-1", "client_id": "rdkafka", "type": "consumer", "rx": 5}`
+	err := run([]string{"serve", "-listen", "127.0.0.1:0"}, strings.NewReader(stats), &bytes.Buffer{})
+	if err != nil {
+		t.Fatal("serve failed:", err)
+	}
+
+	err = run([]string{"serve", "-listen", "127.0.0.1:0"}, strings.NewReader("{"), &bytes.Buffer{})
+	if err == nil {
+		t.Error("Expected error for malformed stats")
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+
+	v0 "github.com/abergmeier/kafka_stats_exporter/v0"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+)
+
+// namingFlags are the Exporter options influencing metric names
+type namingFlags struct {
+	namespace string
+	subsystem string
+	windows   bool
+}
+
+func (nf *namingFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&nf.namespace, "namespace", "", "Namespace of the exported metrics")
+	fs.StringVar(&nf.subsystem, "subsystem", "", "Subsystem of the exported metrics")
+	fs.BoolVar(&nf.windows, "windows", nf.windows, "Export the windows (e.g. rtt) of brokers and topics")
+}
+
+func (nf *namingFlags) options() []v0.ExporterOption {
+	opts := []v0.ExporterOption{v0.WithNamespace(nf.namespace), v0.WithSubsystem(nf.subsystem)}
+	if nf.windows {
+		opts = append(opts, v0.WithNestedInMapEntries())
+	}
+	return opts
+}
+
+func (nf *namingFlags) naming() (*gen.GeneratedOptions, error) {
+	return v0.MetricNaming(nf.options()...)
+}
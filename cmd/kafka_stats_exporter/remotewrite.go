@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	v0 "github.com/abergmeier/kafka_stats_exporter/v0"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/remotewrite"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// remoteWriteCommand exports a stream of JSON statistics of librdkafka and
+// sends the metrics to a remote-write endpoint every interval and once the
+// stream ends
+func remoteWriteCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("remote-write", flag.ContinueOnError)
+	nf := &namingFlags{}
+	nf.register(fs)
+	url := fs.String("url", "", "Remote-write endpoint (e.g. http://localhost:9090/api/v1/write)")
+	interval := fs.Duration("interval", 15*time.Second, "Interval between sends")
+	queueSize := fs.Int("queue", 10, "Sends waiting to be retried before dropping the oldest")
+	retries := fs.Int("retries", 3, "Retries of a failed send")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *url == "" {
+		return fmt.Errorf("remote-write: -url is required")
+	}
+
+	r := prometheus.NewRegistry()
+	e := v0.NewExporter(r, nf.options()...)
+	c := remotewrite.NewClient(*url, remotewrite.WithQueueSize(*queueSize), remotewrite.WithRetries(*retries))
+	send := func() error {
+		mfs, gatherErr := r.Gather()
+		// Partial results are still worth sending
+		err := c.Send(mfs, time.Now())
+		if gatherErr != nil {
+			return gatherErr
+		}
+		return err
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	var tickErr error // First failed send of the ticker
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t := time.NewTicker(*interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				err := send()
+				if tickErr == nil {
+					tickErr = err
+				}
+			}
+		}
+	}()
+
+	errs := []error{update(e, stdin)}
+	close(stop)
+	wg.Wait()
+	errs = append(errs, tickErr, send(), c.Close(), e.Close())
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// update updates e with every JSON value of stdin
+func update(e v0.Exporter, stdin io.Reader) error {
+	dec := json.NewDecoder(stdin)
+	for {
+		var stats json.RawMessage
+		err := dec.Decode(&stats)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = e.UpdateWithStatString(string(stats))
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"time"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/rules"
+)
+
+func rulesCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("rules", flag.ContinueOnError)
+	nf := &namingFlags{}
+	nf.register(fs)
+	lag := fs.Int("consumer-lag", 1000, "Alert when the consumer lag of a topic exceeds this many messages")
+	brokerDown := fs.Duration("broker-down", time.Minute, "Alert when a broker is DOWN for longer")
+	disconnects := fs.Int("broker-disconnects", 5, "Alert when a broker disconnects more often within the rate interval")
+	queue := fs.Float64("producer-queue", 0.8, "Alert when the producer queue is filled by more than this ratio")
+	rateInterval := fs.Duration("rate-interval", 5*time.Minute, "Range of rates")
+	forDuration := fs.Duration("for", 5*time.Minute, "How long alert conditions have to hold before firing")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	naming, err := nf.naming()
+	if err != nil {
+		return err
+	}
+	groups, err := rules.Build(naming,
+		rules.WithConsumerLagThreshold(*lag),
+		rules.WithBrokerDownThreshold(*brokerDown),
+		rules.WithBrokerDisconnectThreshold(*disconnects),
+		rules.WithProducerQueueThreshold(*queue),
+		rules.WithRateInterval(*rateInterval),
+		rules.WithFor(*forDuration),
+	)
+	if err != nil {
+		return err
+	}
+	return rules.WriteYAML(stdout, groups)
+}
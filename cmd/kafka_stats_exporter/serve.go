@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"net"
+	"net/http"
+
+	v0 "github.com/abergmeier/kafka_stats_exporter/v0"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/openmetrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// serveCommand exports a stream of JSON statistics of librdkafka via HTTP
+// until the stream ends. OpenMetrics is served when negotiated.
+func serveCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	nf := &namingFlags{}
+	nf.register(fs)
+	listen := fs.String("listen", ":9308", "Address to serve metrics on")
+	path := fs.String("path", "/metrics", "Path to serve metrics on")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	naming, err := nf.naming()
+	if err != nil {
+		return err
+	}
+	l, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+	r := prometheus.NewRegistry()
+	e := v0.NewExporter(r, nf.options()...)
+	defer e.Close()
+	mux := http.NewServeMux()
+	mux.Handle(*path, openmetrics.NewHandler(r, naming, e))
+	s := &http.Server{
+		Handler: mux,
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(l)
+	}()
+
+	err = update(e, stdin)
+	shutdownErr := s.Shutdown(context.Background())
+	serveErr := <-served
+	if err != nil {
+		return err
+	}
+	if shutdownErr != nil {
+		return shutdownErr
+	}
+	if !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	return nil
+}
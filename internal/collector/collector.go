@@ -2,97 +2,61 @@ package collector
 
 import (
 	"fmt"
-	"net/url"
 	"reflect"
-	"strings"
-
-	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/types"
-	"github.com/iancoleman/strcase"
-	"github.com/prometheus/client_golang/prometheus"
 )
 
-type DynamicMap struct {
-	IndexInStruct int
-	StructParent  string
-	FieldName     string // Field names get saved in snake cased prometheus format
-
-	Mapped map[reflect.Value]*Collectors
+// AggregatedEntry holds the last values of the aggregated counters of a
+// map entry
+type AggregatedEntry struct {
+	Epoch uint64 // Of the RecordState at the last walk
+	Last  []int64
 }
 
-type GeneratedUpdator struct {
-	Collector prometheus.Collector
-	Index     int
-	Update    func(last, current int64, ls prometheus.Labels) int64
-	Last      int64
+// MapKey identifies an entry of a tagged map. Keys of tagged maps are
+// either integers or strings.
+type MapKey struct {
+	Int    int64
+	String string
 }
 
-func makeGenerated(i int, tag string, f reflect.StructField, parent string, labelNames types.LabelNames, metricNameTransform types.MetricNameTransformer) *GeneratedUpdator {
-	prom := strings.SplitN(tag, ",", 2)
-	help, err := url.QueryUnescape(prom[1])
-	if err != nil {
-		panic(err)
-	}
-
-	switch prom[0] {
-	case "CounterVec":
-		// FIXME: This could result in overlapping prefixes
-		namePrefix := metricNameTransform(parent)
-		if namePrefix != "" && !strings.HasSuffix(namePrefix, "_") {
-			namePrefix = namePrefix + "_"
-		}
-		counterVec := prometheus.NewCounterVec(prometheus.CounterOpts{
-			Name: namePrefix + strcase.ToSnake(f.Name) + "_total",
-			Help: help,
-		}, labelNames.Strings())
-		return &GeneratedUpdator{
-			Collector: counterVec,
-			Index:     i,
-			Update: func(last, current int64, ls prometheus.Labels) int64 {
-				return updateCounterVec(last, current, counterVec, ls)
-			},
-		}
-	case "GaugeVec":
-		// FIXME: This could result in overlapping prefixes
-		namePrefix := metricNameTransform(parent)
-		if namePrefix != "" && !strings.HasSuffix(namePrefix, "_") {
-			namePrefix = namePrefix + "_"
-		}
-		gaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Name: namePrefix + strcase.ToSnake(f.Name),
-			Help: help,
-		}, labelNames.Strings())
-		return &GeneratedUpdator{
-			Collector: gaugeVec,
-			Index:     i,
-			Update: func(last, current int64, ls prometheus.Labels) int64 {
-				return updateGaugeVec(current, gaugeVec, ls)
-			},
-		}
-	case "":
-		return nil
-	default:
-		panic(fmt.Sprintf("Unsupported prometheus Metric: %s", prom[0]))
+// MakeMapKey creates the MapKey of map key k
+func MakeMapKey(k reflect.Value) MapKey {
+	if k.CanInt() {
+		return MapKey{Int: k.Int()}
 	}
+	return MapKey{String: k.String()}
 }
 
-func updateCounterVec(last, current int64, counter *prometheus.CounterVec, ls prometheus.Labels) int64 {
-	return updateCounter(last, current, counter.With(ls))
-}
+// Aggregation defines how values of map entries are combined
+type Aggregation int
 
-func updateCounter(last, current int64, counter prometheus.Counter) int64 {
-	diff := current - last
-	if diff < 0 {
-		return last
-	}
-	counter.Add(float64(diff))
-	return current
-}
+const (
+	AggregateSum Aggregation = iota
+	AggregateMax
+	AggregateNone
+)
 
-func updateGaugeVec(current int64, gauge *prometheus.GaugeVec, ls prometheus.Labels) int64 {
-	return updateGauge(current, gauge.With(ls))
+// AggregatedMetricType returns the type of the metric for a field tagged
+// with `kpromcol:"<metricType>,..."` when aggregated via a. The maximum of
+// counters (e.g. the idle time of brokers) is not monotonic and thus
+// exported as gauge.
+func AggregatedMetricType(metricType string, a Aggregation) string {
+	if a == AggregateMax && metricType == "CounterVec" {
+		return "GaugeVec"
+	}
+	return metricType
 }
 
-func updateGauge(current int64, gauge prometheus.Gauge) int64 {
-	gauge.Set(float64(current))
-	return current
+// ParseAggregation parses the `kpromagg` tag of a field
+func ParseAggregation(tag string) Aggregation {
+	switch tag {
+	case "", "sum":
+		return AggregateSum
+	case "max":
+		return AggregateMax
+	case "-":
+		return AggregateNone
+	default:
+		panic(fmt.Sprintf("Unsupported aggregation: %s", tag))
+	}
 }
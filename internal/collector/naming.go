@@ -0,0 +1,236 @@
+package collector
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/abergmeier/kafka_stats_exporter/internal/label"
+	"github.com/iancoleman/strcase"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Desc describes a metric generated for a tagged type
+type Desc struct {
+	Name       string // Fully qualified metric name
+	Type       string // Either `counter` or `gauge`
+	Help       string
+	Unit       string // Optional OpenMetrics base unit suffixing Name (e.g. `bytes`)
+	LabelNames []string
+	Path       string // Go path of the source field (e.g. `Stats.Brokers[].Rtt.P99`)
+}
+
+// MetricName builds the metric name (without namespace and subsystem) for
+// `name` inside of the struct with prefix `parent`.
+// All generated metrics get their name from here.
+func (opts *Options) MetricName(parent, name string) string {
+	namePrefix := opts.MetricNameTransform(parent)
+	if namePrefix != "" && !strings.HasSuffix(namePrefix, "_") {
+		namePrefix = namePrefix + "_"
+	}
+	return namePrefix + name
+}
+
+// FieldMetricName builds the metric name for the Go field `fieldName`
+// tagged with `kpromcol:"<metricType>,..."`.
+func (opts *Options) FieldMetricName(parent, fieldName, metricType string) string {
+	name := opts.MetricName(parent, strcase.ToSnake(fieldName))
+	if metricType == "CounterVec" {
+		name += "_total"
+	}
+	return name
+}
+
+func (opts *Options) fqName(name string) string {
+	return prometheus.BuildFQName(opts.Namespace, opts.Subsystem, name)
+}
+
+// ParseColTag splits a `kpromcol:"<metricType>,<help>[,<unit>]"` tag.
+// Commas in help have to be escaped as `%2C`. The unit is the one of the
+// values as reported by librdkafka (e.g. `bytes` or `microseconds`).
+func ParseColTag(tag string) (metricType, help, unit string) {
+	prom := strings.SplitN(tag, ",", 3)
+	if len(prom) < 2 {
+		return prom[0], "", ""
+	}
+	help, err := url.QueryUnescape(prom[1])
+	if err != nil {
+		panic(err)
+	}
+	if len(prom) == 3 {
+		unit = prom[2]
+	}
+	return prom[0], help, unit
+}
+
+// DescribeType walks the tagged type `t` and describes all metrics, which
+// will be generated for it. Fails if two fields result in the same metric name.
+func DescribeType(t reflect.Type, rlr *label.RecursiveReflector, opts *Options) ([]Desc, error) {
+	d := describer{
+		opts:   opts,
+		byName: map[string]int{},
+	}
+	_, err := d.describe(t, rlr, "", t.Name(), false)
+	if err != nil {
+		return nil, err
+	}
+	return d.descs, nil
+}
+
+type describer struct {
+	opts   *Options
+	descs  []Desc
+	byName map[string]int
+}
+
+// add returns the index of desc
+func (d *describer) add(desc Desc) (int, error) {
+	i, ok := d.byName[desc.Name]
+	if ok {
+		return 0, fmt.Errorf("metric name `%s` generated for both `%s` and `%s`", desc.Name, d.descs[i].Path, desc.Path)
+	}
+	d.byName[desc.Name] = len(d.descs)
+	d.descs = append(d.descs, desc)
+	return len(d.descs) - 1, nil
+}
+
+// baseUnits are the OpenMetrics base units of tags. Values are exported as
+// reported by librdkafka, so other units (e.g. `microseconds`) are dropped
+// instead of renaming and scaling the metrics, which would break existing
+// queries and dashboards.
+var baseUnits = map[string]bool{
+	"bytes":   true,
+	"seconds": true,
+}
+
+// addField returns the index of the Desc or -1 if f is not exported
+func (d *describer) addField(f reflect.StructField, metricType, help, unit, parent string, rlr *label.RecursiveReflector, path string) (int, error) {
+	var promType string
+	switch metricType {
+	case "CounterVec":
+		promType = "counter"
+	case "GaugeVec":
+		promType = "gauge"
+	case "":
+		return -1, nil
+	default:
+		panic(fmt.Sprintf("Unsupported prometheus Metric: %s", metricType))
+	}
+	if !baseUnits[unit] {
+		unit = ""
+	}
+	return d.add(Desc{
+		Name:       d.opts.fqName(d.opts.FieldMetricName(parent, f.Name, metricType)),
+		Type:       promType,
+		Help:       help,
+		Unit:       unit,
+		LabelNames: rlr.Ln.Strings(),
+		Path:       path,
+	})
+}
+
+// describe returns the plan for recording values of t. inEntry is set
+// inside of map entries.
+func (d *describer) describe(t reflect.Type, rlr *label.RecursiveReflector, parent, path string, inEntry bool) (*recordNode, error) {
+	node := &recordNode{
+		lr: rlr.Lr,
+	}
+	for _, dm := range d.opts.Derived[path] {
+		if dm.T != t {
+			return nil, fmt.Errorf("derived metric `%s` is for type `%s` but `%s` has type `%s`", dm.Name, dm.T, path, t)
+		}
+		desc, err := d.add(Desc{
+			Name:       d.opts.fqName(d.opts.MetricName(parent, dm.Name)),
+			Type:       "gauge",
+			Help:       dm.Help,
+			LabelNames: rlr.Ln.Strings(),
+			Path:       path + "." + dm.Name + "()",
+		})
+		if err != nil {
+			return nil, err
+		}
+		node.derived = append(node.derived, recordDerived{
+			fun:  dm.Fun,
+			desc: desc,
+		})
+	}
+
+	for i, f := range reflect.VisibleFields(t) {
+		tag := f.Tag.Get("kpromcol")
+		if tag != "" {
+			metricType, help, unit := ParseColTag(tag)
+			desc, err := d.addField(f, metricType, help, unit, parent, rlr, path+"."+f.Name)
+			if err != nil {
+				return nil, err
+			}
+			if desc >= 0 {
+				node.fields = append(node.fields, recordField{
+					index: i,
+					desc:  desc,
+				})
+			}
+			continue
+		}
+		tag = f.Tag.Get("kprommap")
+		if tag != "" {
+			name, aggregate := ParseMapTag(tag)
+			mapPath := path + "." + f.Name
+			if _, ok := d.opts.Aggregate[mapPath]; ok {
+				aggregate = true
+			}
+			for _, filter := range d.opts.Filters[mapPath] {
+				if filter.Key != f.Type.Key() || filter.Value != f.Type.Elem() {
+					return nil, fmt.Errorf("map entry filter for `map[%s]%s` cannot filter `%s` of type `%s`", filter.Key, filter.Value, mapPath, f.Type)
+				}
+			}
+			m := recordMap{
+				index:  i,
+				filter: CombineFilters(d.opts.Filters[mapPath]),
+			}
+			if !aggregate {
+				entry, err := d.describe(f.Type.Elem(), rlr.Fields[i], JoinPrefix(parent, name), mapPath+"[]", true)
+				if err != nil {
+					return nil, err
+				}
+				m.entry = entry
+				node.maps = append(node.maps, m)
+				continue
+			}
+			for j, ef := range reflect.VisibleFields(f.Type.Elem()) {
+				etag := ef.Tag.Get("kpromcol")
+				a := ParseAggregation(ef.Tag.Get("kpromagg"))
+				if etag == "" || a == AggregateNone {
+					continue
+				}
+				metricType, help, unit := ParseColTag(etag)
+				desc, err := d.addField(ef, AggregatedMetricType(metricType, a), help, unit, parent, rlr, mapPath+"[]."+ef.Name)
+				if err != nil {
+					return nil, err
+				}
+				if desc >= 0 {
+					m.aggregated = append(m.aggregated, recordAggregated{
+						index:       j,
+						aggregation: a,
+						desc:        desc,
+						counter:     d.descs[desc].Type == "counter",
+					})
+				}
+			}
+			node.maps = append(node.maps, m)
+			continue
+		}
+		tag = f.Tag.Get("kprompnt")
+		if tag != "" && (!inEntry || d.opts.NestedInMapEntries) {
+			nested, err := d.describe(f.Type, rlr.Fields[i], JoinPrefix(parent, tag), path+"."+f.Name, inEntry)
+			if err != nil {
+				return nil, err
+			}
+			node.nested = append(node.nested, recordNested{
+				index: i,
+				node:  nested,
+			})
+		}
+	}
+	return node, nil
+}
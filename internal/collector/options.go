@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"strings"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Options influence which metrics are described and recorded for tagged types
+type Options struct {
+	MetricNameTransform types.MetricNameTransformer
+	// Namespace, Subsystem and ConstLabels are applied to all metrics
+	Namespace   string
+	Subsystem   string
+	ConstLabels prometheus.Labels
+	// Aggregate holds the paths of maps (e.g. `Stats.Topics[].Partitions`)
+	// which are exported as aggregate of their entries instead of per entry.
+	Aggregate map[string]struct{}
+	// Filters holds the filters for entries of maps keyed by the paths of
+	// the maps.
+	Filters map[string][]types.MapEntryFilter
+	// Derived holds the metrics computed from structs keyed by the paths of
+	// the structs (e.g. `Stats` or `Stats.Brokers[]`).
+	Derived map[string][]types.DerivedMetric
+	// NestedInMapEntries enables exporting structs tagged with `kprompnt`
+	// inside of map entries (e.g. `Stats.Brokers[].Rtt`)
+	NestedInMapEntries bool
+}
+
+// ParseMapTag splits a `kprommap` tag into the field name and whether the
+// map should be aggregated
+func ParseMapTag(tag string) (name string, aggregate bool) {
+	name, flags, _ := strings.Cut(tag, ",")
+	for _, flag := range strings.Split(flags, ",") {
+		if flag == "aggregate" {
+			aggregate = true
+		}
+	}
+	return name, aggregate
+}
+
+// CombineFilters returns a func accepting map entries which pass all of
+// filters or nil if there are no filters
+func CombineFilters(filters []types.MapEntryFilter) func(key, value interface{}) bool {
+	switch len(filters) {
+	case 0:
+		return nil
+	case 1:
+		return filters[0].Boxed
+	}
+	return func(key, value interface{}) bool {
+		for _, filter := range filters {
+			if !filter.Boxed(key, value) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// JoinPrefix appends `name` to the metric prefix `parent`
+func JoinPrefix(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "_" + name
+}
@@ -0,0 +1,268 @@
+package collector
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/abergmeier/kafka_stats_exporter/internal/assert"
+	"github.com/abergmeier/kafka_stats_exporter/internal/label"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RecordFunc receives the value of the metric with index `desc` in the
+// result of DescribeType for one combination of labels. series identifies
+// the struct holding the value by the keys of the map entries containing
+// it, so it does not change with the labels.
+type RecordFunc func(desc int, series string, labels prometheus.Labels, value float64)
+
+// Recorder walks values of a tagged type and records the values of all
+// metrics described by DescribeType. State between walks (e.g. of
+// aggregated counters) is kept in a RecordState.
+type Recorder struct {
+	root        *recordNode
+	descs       []Desc
+	t           reflect.Type
+	constLabels prometheus.Labels
+	transform   types.LabelNameTransformer
+}
+
+type recordNode struct {
+	lr      *label.Reflector
+	fields  []recordField
+	derived []recordDerived
+	nested  []recordNested
+	maps    []recordMap
+}
+
+type recordField struct {
+	index int
+	desc  int
+}
+
+type recordDerived struct {
+	fun  func(v interface{}) float64
+	desc int
+}
+
+type recordNested struct {
+	index int
+	node  *recordNode
+}
+
+type recordMap struct {
+	index      int
+	filter     func(key, value interface{}) bool
+	entry      *recordNode // nil if aggregated
+	aggregated []recordAggregated
+}
+
+type recordAggregated struct {
+	index       int
+	aggregation Aggregation
+	desc        int
+	counter     bool
+}
+
+// RecordState keeps the totals of aggregated counters between walks of a
+// Recorder, so they only increase like the ones of Collectors.
+type RecordState struct {
+	epoch      uint64
+	aggregates map[aggregateKey]*aggregateState
+}
+
+// aggregateKey identifies an aggregated map by the map and the map entries
+// containing it
+type aggregateKey struct {
+	m      *recordMap
+	series string
+}
+
+type aggregateState struct {
+	epoch   uint64
+	totals  []int64 // By aggregated metric
+	entries map[MapKey]*AggregatedEntry
+}
+
+// NewRecorder creates a Recorder for the metrics of DescribeType.
+// Label names are transformed with transform.
+func NewRecorder(t reflect.Type, rlr *label.RecursiveReflector, opts *Options, transform types.LabelNameTransformer) (*Recorder, error) {
+	d := describer{
+		opts:   opts,
+		byName: map[string]int{},
+	}
+	root, err := d.describe(t, rlr, "", t.Name(), false)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{
+		root:        root,
+		descs:       d.descs,
+		t:           t,
+		constLabels: opts.ConstLabels,
+		transform:   transform,
+	}, nil
+}
+
+// Descs returns the metrics as described by DescribeType
+func (r *Recorder) Descs() []Desc {
+	return r.descs
+}
+
+// Record walks rv and calls fun for every metric value with the labels of
+// the value, parent and the constant labels. Labels passed to fun must not
+// be retained.
+func (r *Recorder) Record(rv reflect.Value, parent prometheus.Labels, state *RecordState, fun RecordFunc) {
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	assert.AssertType(rv, r.t)
+	labels := prometheus.Labels{}
+	for k, v := range parent {
+		labels[k] = v
+	}
+	for k, v := range r.constLabels {
+		labels[k] = v
+	}
+	state.epoch++
+	r.record(r.root, rv, labels, "", state, fun)
+	// Aggregates of entries, which are gone
+	for k, as := range state.aggregates {
+		if as.epoch != state.epoch {
+			delete(state.aggregates, k)
+		}
+	}
+}
+
+// record records the value rv of node n. series identifies rv by the keys
+// of the map entries containing it.
+func (r *Recorder) record(n *recordNode, rv reflect.Value, parent prometheus.Labels, series string, state *RecordState, fun RecordFunc) {
+	labels := make(prometheus.Labels, len(parent)+len(n.lr.Generators))
+	for k, v := range parent {
+		labels[k] = v
+	}
+	n.lr.FillLabelsForValue(rv, labels, r.transform)
+
+	for _, f := range n.fields {
+		fun(f.desc, series, labels, float64(rv.Field(f.index).Int()))
+	}
+	if len(n.derived) != 0 {
+		if !rv.CanAddr() {
+			// Derived metrics get passed a pointer thus we need an
+			// addressable copy
+			pv := reflect.New(rv.Type()).Elem()
+			pv.Set(rv)
+			rv = pv
+		}
+		p := rv.Addr().Interface()
+		for _, d := range n.derived {
+			fun(d.desc, series, labels, d.fun(p))
+		}
+	}
+	for _, nested := range n.nested {
+		r.record(nested.node, rv.Field(nested.index), labels, series, state, fun)
+	}
+	for i := range n.maps {
+		m := &n.maps[i]
+		fv := rv.Field(m.index)
+		if m.entry == nil {
+			recordAggregatedMap(m, fv, labels, series, state, fun)
+			continue
+		}
+		iter := fv.MapRange()
+		for iter.Next() {
+			if m.filter != nil && !m.filter(iter.Key().Interface(), iter.Value().Interface()) {
+				continue
+			}
+			r.record(m.entry, iter.Value(), labels, entrySeries(series, iter.Key()), state, fun)
+		}
+	}
+}
+
+// entrySeries appends map key k to series
+func entrySeries(series string, k reflect.Value) string {
+	if k.CanInt() {
+		return series + "/" + strconv.FormatInt(k.Int(), 10)
+	}
+	return series + "/" + strconv.Quote(k.String())
+}
+
+// recordAggregatedMap combines the values of all map entries. Negative
+// values (e.g. -1 for unknown) are not summed. Counters add the increase of
+// every entry since the last walk with state. Entries showing up after the
+// first walk only count their increase from then on.
+func recordAggregatedMap(m *recordMap, fv reflect.Value, labels prometheus.Labels, series string, state *RecordState, fun RecordFunc) {
+	if state.aggregates == nil {
+		state.aggregates = map[aggregateKey]*aggregateState{}
+	}
+	key := aggregateKey{m: m, series: series}
+	as, ok := state.aggregates[key]
+	if !ok {
+		as = &aggregateState{
+			totals: make([]int64, len(m.aggregated)),
+		}
+		state.aggregates[key] = as
+	}
+	as.epoch = state.epoch
+	// On the first walk the entries make up the whole total
+	first := as.entries == nil
+	if first {
+		as.entries = map[MapKey]*AggregatedEntry{}
+	}
+
+	values := make([]int64, len(m.aggregated))
+	seen := false
+	iter := fv.MapRange()
+	for iter.Next() {
+		k := iter.Key()
+		if k.CanInt() && k.Int() < 0 {
+			// librdkafka internal entry (e.g. the -1 UA partition)
+			continue
+		}
+		if m.filter != nil && !m.filter(k.Interface(), iter.Value().Interface()) {
+			continue
+		}
+		mk := MakeMapKey(k)
+		e, known := as.entries[mk]
+		if !known {
+			e = &AggregatedEntry{Last: make([]int64, len(m.aggregated))}
+			as.entries[mk] = e
+		}
+		e.Epoch = state.epoch
+		v := iter.Value()
+		for i, a := range m.aggregated {
+			current := v.Field(a.index).Int()
+			switch a.aggregation {
+			case AggregateSum:
+				// Negative values mean unknown (e.g. consumer_lag)
+				if current < 0 {
+					continue
+				}
+				if !a.counter {
+					values[i] += current
+					continue
+				}
+				if diff := current - e.Last[i]; (known || first) && diff > 0 {
+					as.totals[i] += diff
+				}
+				e.Last[i] = current
+			case AggregateMax:
+				if !seen || current > values[i] {
+					values[i] = current
+				}
+			}
+		}
+		seen = true
+	}
+	for k, e := range as.entries {
+		if e.Epoch != state.epoch {
+			delete(as.entries, k)
+		}
+	}
+	for i, a := range m.aggregated {
+		if a.counter {
+			values[i] = as.totals[i]
+		}
+		fun(a.desc, series, labels, float64(values[i]))
+	}
+}
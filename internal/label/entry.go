@@ -4,16 +4,10 @@ import (
 	"reflect"
 	"strconv"
 
-	"github.com/abergmeier/kafka_stats_exporter/internal/assert"
 	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/types"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-type KeyValue struct {
-	Key   string
-	Value string
-}
-
 type KeyValueGenerator struct {
 	FieldIndex int
 	FieldType  reflect.Type
@@ -34,46 +28,18 @@ type RecursiveReflector struct {
 	T      reflect.Type
 }
 
-func (lr *Reflector) LabelsForValue(v interface{}) prometheus.Labels {
-	rv := reflect.ValueOf(v)
-	switch rv.Kind() {
-	case reflect.Pointer:
-		rv = rv.Elem()
-	}
-	assert.AssertType(rv, lr.T)
-	ls := make(prometheus.Labels, len(lr.Generators))
+// FillLabelsForValue sets the Labels of struct value rv in ls.
+// Label names are transformed with transform.
+func (lr *Reflector) FillLabelsForValue(rv reflect.Value, ls prometheus.Labels, transform types.LabelNameTransformer) {
 	for _, g := range lr.Generators {
-		kv := g.GenerateFromValue(v)
-		ls[kv.Key] = kv.Value
+		ls[transform(g.LabelName)] = g.valueOf(rv)
 	}
-	return ls
 }
 
-func (g *KeyValueGenerator) GenerateFromValue(v interface{}) KeyValue {
-	rv := reflect.ValueOf(v)
-	switch rv.Kind() {
-	case reflect.Pointer:
-		rv = rv.Elem()
-	}
-	assert.AssertType(rv, g.T)
-	rvk := rv.Kind()
-	switch rvk {
-	case reflect.Struct:
-	case reflect.Pointer:
-		rv = rv.Elem()
-	default:
-		panic("Unhandled code path")
-	}
-	fv := rv.FieldByIndex([]int{g.FieldIndex})
-	assert.AssertType(fv, g.FieldType)
+func (g *KeyValueGenerator) valueOf(rv reflect.Value) string {
+	fv := rv.Field(g.FieldIndex)
 	if fv.CanInt() {
-		return KeyValue{
-			Key:   g.LabelName,
-			Value: strconv.FormatInt(fv.Int(), 10),
-		}
-	}
-	return KeyValue{
-		Key:   g.LabelName,
-		Value: fv.String(),
+		return strconv.FormatInt(fv.Int(), 10)
 	}
+	return fv.String()
 }
@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// UnknownFields decodes the JSON `data` and returns the paths of all values,
+// which have no corresponding field in type `t` (thus get ignored when
+// unmarshalling into `t`). Path elements are separated by `.` and map keys
+// as well as array indices are represented by `*` (e.g. `brokers.*.foo`).
+func UnknownFields(data []byte, t reflect.Type) ([]string, error) {
+	var v interface{}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return nil, err
+	}
+	w := walker{
+		fields:  map[reflect.Type]map[string]reflect.Type{},
+		unknown: map[string]struct{}{},
+	}
+	w.walk(v, t, "")
+
+	paths := make([]string, 0, len(w.unknown))
+	for p := range w.unknown {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+type walker struct {
+	// fields caches the types of struct fields by JSON name
+	fields  map[reflect.Type]map[string]reflect.Type
+	unknown map[string]struct{}
+}
+
+// walk reports the paths below v, which are unknown to t
+func (w *walker) walk(v interface{}, t reflect.Type, path string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := w.fieldsOf(t)
+			for k, ev := range vv {
+				ft, ok := lookupField(fields, k)
+				if !ok {
+					w.unknown[join(path, k)] = struct{}{}
+					continue
+				}
+				w.walk(ev, ft, join(path, k))
+			}
+		case reflect.Map:
+			for _, ev := range vv {
+				w.walk(ev, t.Elem(), join(path, "*"))
+			}
+		}
+	case []interface{}:
+		switch t.Kind() {
+		case reflect.Slice, reflect.Array:
+			for _, ev := range vv {
+				w.walk(ev, t.Elem(), join(path, "*"))
+			}
+		}
+	}
+}
+
+func (w *walker) fieldsOf(t reflect.Type) map[string]reflect.Type {
+	fields, ok := w.fields[t]
+	if ok {
+		return fields
+	}
+	fields = map[string]reflect.Type{}
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	w.fields[t] = fields
+	return fields
+}
+
+// lookupField mimics encoding/json by preferring an exact match
+// but also accepting a case-insensitive one
+func lookupField(fields map[string]reflect.Type, name string) (reflect.Type, bool) {
+	t, ok := fields[name]
+	if ok {
+		return t, true
+	}
+	for fn, t := range fields {
+		if strings.EqualFold(fn, name) {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
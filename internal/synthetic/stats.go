@@ -0,0 +1,79 @@
+// Package synthetic creates statistics of arbitrary size for benchmarks.
+package synthetic
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+)
+
+// Size of synthetic statistics
+type Size struct {
+	Brokers    int
+	Topics     int
+	Partitions int // Per topic
+}
+
+// Sizes are commonly used for benchmarks
+var Sizes = []Size{
+	{3, 1, 6},
+	{6, 20, 12},
+	{12, 100, 24},
+}
+
+func (s Size) String() string {
+	return fmt.Sprintf("%dx%dx%d", s.Brokers, s.Topics, s.Partitions)
+}
+
+// Stats returns statistics of a consumer connected to all brokers and
+// consuming all topics. Counters grow with `round`.
+func Stats(size Size, round int) *typed.Stats {
+	stats := &typed.Stats{
+		Name:    "rdkafka#consumer-1",
+		Type:    "consumer",
+		Rxmsgs:  1000 * round,
+		Brokers: map[typed.BrokerName]typed.BrokerStats{},
+		Topics:  map[typed.TopicName]typed.TopicStats{},
+	}
+	for b := 0; b < size.Brokers; b++ {
+		name := typed.BrokerName(fmt.Sprintf("broker-%d:9092/%d", b, b))
+		stats.Brokers[name] = typed.BrokerStats{
+			Name:   string(name),
+			Nodeid: b,
+			Source: "learned",
+			State:  "UP",
+			Tx:     1000*round + b,
+			Rx:     1000*round + b,
+		}
+	}
+	for t := 0; t < size.Topics; t++ {
+		name := typed.TopicName(fmt.Sprintf("topic-%d", t))
+		ts := typed.TopicStats{
+			Topic:      string(name),
+			Partitions: map[typed.PartitionId]typed.PartitionStats{},
+		}
+		for p := 0; p < size.Partitions; p++ {
+			ts.Partitions[typed.PartitionId(p)] = typed.PartitionStats{
+				Partition:   p,
+				Broker:      p % size.Brokers,
+				Leader:      p % size.Brokers,
+				FetchState:  "active",
+				HiOffset:    100000*round + p,
+				ConsumerLag: p,
+				Rxmsgs:      5000*round + p,
+			}
+		}
+		stats.Topics[name] = ts
+	}
+	return stats
+}
+
+// JSON returns Stats encoded like librdkafka does
+func JSON(size Size, round int) string {
+	b, err := json.Marshal(Stats(size, round))
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,110 @@
+package v0
+
+import (
+	"sync"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/types"
+)
+
+// DefaultDerivedMetrics returns the metrics exported in addition to the
+// Stats fields unless disabled via WithoutDefaultDerivedMetrics. Keyed by
+// the Go path of the struct. Every call returns metrics with their own state,
+// which tracks a single client. Thus use the metrics of a call for a single
+// Exporter only.
+// Like all derived metrics they are only exported by UpdateWithStatString.
+func DefaultDerivedMetrics() map[string][]types.DerivedMetric {
+	commits := &commitTracker{}
+	return map[string][]types.DerivedMetric{
+		"Stats": {
+			gen.NewDerivedMetric("msg_cnt_fill_ratio", "Ratio of msg_cnt to msg_max (producer queue fill level)", func(s *typed.Stats) float64 {
+				return ratio(s.MsgCnt, s.MsgMax)
+			}),
+			gen.NewDerivedMetric("msg_size_fill_ratio", "Ratio of msg_size to msg_size_max (producer queue fill level)", func(s *typed.Stats) float64 {
+				return ratio(s.MsgSize, s.MsgSizeMax)
+			}),
+			gen.NewDerivedMetric("last_commit_age_seconds", "Seconds since the committed offset of any partition last advanced (0 before the first commit)", commits.age),
+		},
+		"Stats.Brokers[]": {
+			gen.NewDerivedMetric("tx_error_ratio", "Ratio of txerrs to tx", func(bs *typed.BrokerStats) float64 {
+				return ratio(bs.Txerrs, bs.Tx)
+			}),
+			gen.NewDerivedMetric("rx_error_ratio", "Ratio of rxerrs to rx", func(bs *typed.BrokerStats) float64 {
+				return ratio(bs.Rxerrs, bs.Rx)
+			}),
+		},
+		"Stats.Topics[].Partitions[]": {
+			gen.NewDerivedMetric("unacked_msgs", "Messages produced to the partition, which are not yet acknowledged by the broker (msgq_cnt + xmit_msgq_cnt + msgs_inflight)", func(ps *typed.PartitionStats) float64 {
+				return float64(nonNegative(ps.MsgqCnt) + nonNegative(ps.XmitMsgqCnt) + nonNegative(ps.MsgsInflight))
+			}),
+		},
+	}
+}
+
+// ratio returns 0 for an unknown (non-positive) denominator
+func ratio(numerator, denominator int) float64 {
+	if denominator <= 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
+}
+
+func nonNegative(v int) int {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// commitTracker remembers when the committed offsets of a client last
+// advanced. A client with another name starts over.
+type commitTracker struct {
+	mu      sync.Mutex
+	client  string
+	offsets map[topicPartition]int // Of the partitions in the last Stats
+	spare   map[topicPartition]int // Reused for the next Stats to not allocate
+	last    int                    // Time of Stats, which had an advanced committed offset
+}
+
+type topicPartition struct {
+	topic     typed.TopicName
+	partition typed.PartitionId
+}
+
+// age returns the seconds since a committed offset of s last advanced
+func (t *commitTracker) age(s *typed.Stats) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s.Name != t.client {
+		t.client = s.Name
+		t.offsets = nil
+		t.last = 0
+	}
+	offsets := t.spare
+	if offsets == nil {
+		offsets = map[topicPartition]int{}
+	}
+	for tp := range offsets {
+		delete(offsets, tp)
+	}
+	for topic, ts := range s.Topics {
+		for partition, ps := range ts.Partitions {
+			// Negative offsets mean nothing was committed (yet)
+			if partition < 0 || ps.CommittedOffset < 0 {
+				continue
+			}
+			tp := topicPartition{topic: topic, partition: partition}
+			offsets[tp] = ps.CommittedOffset
+			previous, ok := t.offsets[tp]
+			if !ok || ps.CommittedOffset > previous {
+				t.last = s.Time
+			}
+		}
+	}
+	t.offsets, t.spare = offsets, t.offsets
+	if t.last == 0 || s.Time < t.last {
+		return 0
+	}
+	return float64(s.Time - t.last)
+}
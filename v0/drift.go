@@ -0,0 +1,86 @@
+package v0
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/abergmeier/kafka_stats_exporter/internal/schema"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unknownFields tracks JSON paths of statistics, which are not mapped
+// by the Stats type (e.g. fields added by newer librdkafka versions).
+type unknownFields struct {
+	mu         sync.Mutex
+	paths      map[string]struct{}
+	metric     *prometheus.GaugeVec
+	registered bool
+	onUnknown  []func(path string)
+}
+
+func newUnknownFields(o selfOptions) *unknownFields {
+	return &unknownFields{
+		paths: map[string]struct{}{},
+		metric: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			Subsystem:   o.subsystem,
+			ConstLabels: o.constLabels,
+			Name:        "kafka_stats_exporter_unknown_fields",
+			Help:        "JSON paths of statistics, which are not mapped to Stats",
+		}, []string{"path"}),
+	}
+}
+
+func (u *unknownFields) track(r prometheus.Registerer, data []byte, t reflect.Type) error {
+	paths, err := schema.UnknownFields(data, t)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !u.registered {
+		u.metric, err = register(r, u.metric)
+		if err != nil {
+			return err
+		}
+		u.registered = true
+	}
+
+	for _, p := range paths {
+		_, ok := u.paths[p]
+		if ok {
+			continue
+		}
+		u.paths[p] = struct{}{}
+		u.metric.WithLabelValues(p).Set(1)
+		for _, fun := range u.onUnknown {
+			fun(p)
+		}
+	}
+	return nil
+}
+
+func (u *unknownFields) close(r prometheus.Registerer) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.registered {
+		unregister(r, u.metric)
+		u.registered = false
+	}
+}
+
+func (u *unknownFields) list() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	paths := make([]string, 0, len(u.paths))
+	for p := range u.paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
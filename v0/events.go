@@ -0,0 +1,109 @@
+package v0
+
+import (
+	"strconv"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+)
+
+// EventKind is a lifecycle transition of a Kafka client
+type EventKind int
+
+const (
+	// BrokerDown is a broker `state` changing to DOWN
+	BrokerDown EventKind = iota
+	// ConsumerGroupLeftUp is `cgrp.state` changing from up
+	ConsumerGroupLeftUp
+	// TransactionAbortable is `eos.txn_state` changing to AbortableError
+	TransactionAbortable
+	// Rebalance is `cgrp.rebalance_cnt` increasing
+	Rebalance
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case BrokerDown:
+		return "BrokerDown"
+	case ConsumerGroupLeftUp:
+		return "ConsumerGroupLeftUp"
+	case TransactionAbortable:
+		return "TransactionAbortable"
+	case Rebalance:
+		return "Rebalance"
+	default:
+		return "EventKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Event is a transition between consecutive Stats of a client
+type Event struct {
+	Kind   EventKind
+	Client string     // Name of the client
+	Path   typed.Path // Changed field (e.g. `brokers.localhost:9092/2.state`)
+	Old    string     // Previous label value
+	New    string     // Current label value
+	Count  int        // Increase of a counter (e.g. number of rebalances)
+}
+
+// events derives Events from the changes of client
+func events(client string, changes *typed.ChangeSet) []Event {
+	var evs []Event
+	for _, l := range changes.Labels {
+		ev := Event{
+			Client: client,
+			Path:   l.Path,
+			Old:    l.Old,
+			New:    l.New,
+		}
+		switch {
+		case len(l.Path) == 3 && l.Path[0] == "brokers" && l.Path[2] == "state" && l.New == "DOWN":
+			ev.Kind = BrokerDown
+		case l.Path.String() == "cgrp.state" && l.Old == "up":
+			ev.Kind = ConsumerGroupLeftUp
+		case l.Path.String() == "eos.txn_state" && l.New == "AbortableError":
+			ev.Kind = TransactionAbortable
+		default:
+			continue
+		}
+		evs = append(evs, ev)
+	}
+	for _, c := range changes.Counters {
+		if c.Path.String() != "cgrp.rebalance_cnt" || c.Delta <= 0 {
+			continue
+		}
+		evs = append(evs, Event{
+			Kind:   Rebalance,
+			Client: client,
+			Path:   c.Path,
+			Count:  c.Delta,
+		})
+	}
+	return evs
+}
+
+// observer calls fun for Events of kinds
+type observer struct {
+	fun   func(ev Event)
+	kinds []EventKind
+}
+
+func (o *observer) notify(evs []Event) {
+	for _, ev := range evs {
+		if !o.observes(ev.Kind) {
+			continue
+		}
+		o.fun(ev)
+	}
+}
+
+func (o *observer) observes(kind EventKind) bool {
+	if len(o.kinds) == 0 {
+		return true
+	}
+	for _, k := range o.kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
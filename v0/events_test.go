@@ -0,0 +1,71 @@
+package v0
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const lifecycleStats = `{
+	"name": "rdkafka#producer-1",
+	"brokers": {
+		"localhost:9092/2": {"name": "localhost:9092/2", "nodeid": 2, "state": %q}
+	},
+	"cgrp": {"state": %q, "rebalance_cnt": %d},
+	"eos": {"txn_state": %q}
+}`
+
+func TestWithEventObserver(t *testing.T) {
+	var all []Event
+	var rebalances []Event
+	e := NewExporter(prometheus.NewRegistry(),
+		WithEventObserver(func(ev Event) {
+			all = append(all, ev)
+		}),
+		WithEventObserver(func(ev Event) {
+			rebalances = append(rebalances, ev)
+		}, Rebalance),
+	)
+	for _, stats := range []string{
+		fmt.Sprintf(lifecycleStats, "UP", "up", 1, "InTransaction"),
+		fmt.Sprintf(lifecycleStats, "UP", "up", 1, "InTransaction"),
+		fmt.Sprintf(lifecycleStats, "DOWN", "wait-coord", 3, "AbortableError"),
+		fmt.Sprintf(lifecycleStats, "UP", "up", 3, "AbortingTransaction"),
+	} {
+		err := e.UpdateWithStatString(stats)
+		if err != nil {
+			t.Fatal("UpdateWithStatString failed:", err)
+		}
+	}
+	client := "rdkafka#producer-1"
+	expectedRebalances := []Event{
+		{Kind: Rebalance, Client: client, Path: typed.Path{"cgrp", "rebalance_cnt"}, Count: 2},
+	}
+	expected := []Event{
+		{Kind: BrokerDown, Client: client, Path: typed.Path{"brokers", "localhost:9092/2", "state"}, Old: "UP", New: "DOWN"},
+		{Kind: ConsumerGroupLeftUp, Client: client, Path: typed.Path{"cgrp", "state"}, Old: "up", New: "wait-coord"},
+		{Kind: TransactionAbortable, Client: client, Path: typed.Path{"eos", "txn_state"}, Old: "InTransaction", New: "AbortableError"},
+	}
+	expected = append(expected, expectedRebalances...)
+
+	d := cmp.Diff(expected, all)
+	if d != "" {
+		t.Error("Diff of all Events", d)
+	}
+	d = cmp.Diff(expectedRebalances, rebalances)
+	if d != "" {
+		t.Error("Diff of Rebalance Events", d)
+	}
+}
+
+func TestEventKindString(t *testing.T) {
+	if Rebalance.String() != "Rebalance" {
+		t.Error("Unexpected name", Rebalance.String())
+	}
+	if EventKind(42).String() != "EventKind(42)" {
+		t.Error("Unexpected name", EventKind(42).String())
+	}
+}
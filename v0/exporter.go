@@ -2,11 +2,17 @@ package v0
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"reflect"
 	"regexp"
+	"sync/atomic"
+	"time"
 
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/history"
 	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
 	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/typedcollector"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -16,54 +22,330 @@ var (
 
 type Exporter interface {
 	UpdateWithStatString(stats string) error
-	// Returns the last updated stats
+	// Updates the metrics directly from the JSON stats without decoding
+	// them into Stats. Neither updates Stats nor exports derived metrics.
+	// Fails with ErrStreamingUnsupported for options needing Stats (e.g.
+	// WithHistory).
+	StreamStatString(stats string) error
+	// Returns the stats of the last successful update. Safe to call
+	// concurrently with updates.
 	Stats() *typed.Stats
+	// Returns the JSON paths of all statistics seen so far, which are not
+	// mapped to Stats. Only tracked when using WithUnknownFieldDetection.
+	UnknownFields() []string
+	// Returns the last Stats if enabled via WithHistory, nil otherwise.
+	History() *history.History
+	// Deletes the series of the Exporter and unregisters its metrics unless
+	// shared with another Exporter. Updates fail afterwards.
+	// Fails if the final push to the Pushgateway or deleting the group fails.
+	Close() error
 }
 
+// ErrExporterClosed is returned when updating a closed Exporter
+var ErrExporterClosed = errors.New("exporter is closed")
+
+// ErrStreamingUnsupported is returned by StreamStatString for Exporters with
+// options needing decoded Stats (WithUnknownFieldDetection, WithHistory,
+// WithChangeCallback and WithEventObserver)
+var ErrStreamingUnsupported = errors.New("options need decoded stats, use UpdateWithStatString")
+
 type exporter struct {
-	registerer    prometheus.Registerer
-	stats         typed.Stats
-	cachedUpdater map[reflect.Type]struct {
-		collector prometheus.Collector
-		updater   gen.Updater
-	}
+	registerer prometheus.Registerer
+	genOpts    []gen.RecursiveMetricsOption
+	selfOpts   selfOptions
+	unknown    *unknownFields // Optional
+	self       *selfMetrics
+	history    *history.History // Optional
+	push       *pusher          // Optional
+	onChange   []func(client string, changes *typed.ChangeSet)
+	updated    bool         // Whether stats are from an update
+	stats      atomic.Value // *typed.Stats
+	collector  *typedcollector.StatsCollector
+	registered *typedcollector.StatsCollector // Shared by collector
+	closed     bool
 }
 
-func NewExporter(r prometheus.Registerer) Exporter {
+// NewExporter creates an Exporter registering its metrics with r on the
+// first update. This includes the metrics about the Exporter itself (e.g.
+// `kafka_stats_exporter_updates_total`), which are named with the same
+// Namespace, Subsystem and constant Labels.
+// If an Exporter with options creating the same metrics already registered
+// its metrics with r (e.g. for a recreated or another client), the metrics
+// are shared. Each Exporter keeps the series of its client and computes
+// its own filters and derived metrics, so Exporters sharing metrics may be
+// updated concurrently as long as their clients have different names.
+// Closing an Exporter deletes its series and the metrics are unregistered
+// once all Exporters sharing them are closed.
+func NewExporter(r prometheus.Registerer, opts ...ExporterOption) Exporter {
+	e := newExporter(opts)
+	e.registerer = r
+	e.self = newSelfMetrics(e.selfOpts)
+	e.stats.Store(&typed.Stats{})
+	return e
+}
+
+// MetricNaming resolves the names of the metrics exported by an Exporter
+// created with opts (e.g. for generating rules matching the metrics).
+func MetricNaming(opts ...ExporterOption) (*gen.GeneratedOptions, error) {
+	return gen.NewGeneratedOptions(reflect.TypeOf(typed.Stats{}), newExporter(opts).genOpts...)
+}
+
+// newExporter resolves opts without registering anything
+func newExporter(opts []ExporterOption) *exporter {
+	genOpts := []gen.RecursiveMetricsOption{
+		gen.WithMetricNameTransform(
+			func(value string) (labelName string) {
+				return string(labelNameExp.ReplaceAllString(value, "_"))
+			},
+		),
+	}
+	useDefaultFilters := true
+	useDefaultDerived := true
+	detectUnknown := false
+	var onUnknown []func(path string)
+	var self selfOptions
+	var hist *history.History
+	var onChange []func(client string, changes *typed.ChangeSet)
+	var push *pusher
+	deleteOnClose := false
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case *exporterMapEntryFilter:
+			genOpts = append(genOpts, gen.WithMapEntryFilter(o.path, o.fun))
+		case *exporterWithoutDefaultMapEntryFilters:
+			useDefaultFilters = false
+		case *exporterDerivedMetrics:
+			genOpts = append(genOpts, gen.WithDerivedMetrics(o.path, o.metrics...))
+		case *exporterWithoutDefaultDerivedMetrics:
+			useDefaultDerived = false
+		case *exporterNestedInMapEntries:
+			genOpts = append(genOpts, gen.WithNestedInMapEntries())
+		case *exporterAggregation:
+			genOpts = append(genOpts, gen.WithAggregation(o.paths...))
+		case *exporterNamespace:
+			genOpts = append(genOpts, gen.WithNamespace(o.namespace))
+			self.namespace = o.namespace
+		case *exporterSubsystem:
+			genOpts = append(genOpts, gen.WithSubsystem(o.subsystem))
+			self.subsystem = o.subsystem
+		case *exporterConstLabels:
+			genOpts = append(genOpts, gen.WithConstLabels(o.labels))
+			if self.constLabels == nil {
+				self.constLabels = prometheus.Labels{}
+			}
+			for k, v := range o.labels {
+				self.constLabels[k] = v
+			}
+		case *exporterUnknownFieldDetection:
+			detectUnknown = true
+		case *exporterUnknownFieldCallback:
+			detectUnknown = true
+			onUnknown = append(onUnknown, o.fun)
+		case *exporterHistory:
+			hist = history.New(o.size)
+		case *exporterChangeCallback:
+			fun := o.fun
+			onChange = append(onChange, func(_ string, changes *typed.ChangeSet) {
+				fun(changes)
+			})
+		case *exporterEventObserver:
+			obs := o.observer
+			onChange = append(onChange, func(client string, changes *typed.ChangeSet) {
+				obs.notify(events(client, changes))
+			})
+		case *exporterPushgateway:
+			push = &pusher{
+				url:      o.url,
+				job:      o.job,
+				interval: o.interval,
+			}
+		case *exporterPushgatewayDeleteOnClose:
+			deleteOnClose = true
+		default:
+			panic(fmt.Sprintf("Unrecognized option %#v", opt))
+		}
+	}
+	if useDefaultFilters {
+		for path, filter := range DefaultMapEntryFilters {
+			genOpts = append(genOpts, gen.WithMapEntryFilter(path, filter))
+		}
+	}
+	if useDefaultDerived {
+		for path, metrics := range DefaultDerivedMetrics() {
+			genOpts = append(genOpts, gen.WithDerivedMetrics(path, metrics...))
+		}
+	}
+	if push != nil {
+		push.deleteOnClose = deleteOnClose
+	}
+	var unknown *unknownFields
+	if detectUnknown {
+		unknown = newUnknownFields(self)
+		unknown.onUnknown = onUnknown
+	}
+
 	return &exporter{
-		registerer: r,
-		cachedUpdater: map[reflect.Type]struct {
-			collector prometheus.Collector
-			updater   gen.Updater
-		}{},
+		genOpts:  genOpts,
+		selfOpts: self,
+		unknown:  unknown,
+		history:  hist,
+		push:     push,
+		onChange: onChange,
 	}
 }
 
 func (e *exporter) UpdateWithStatString(stats string) error {
-	err := json.Unmarshal([]byte(stats), &e.stats)
+	if e.closed {
+		return ErrExporterClosed
+	}
+	start := time.Now()
+	err := e.registerSelf()
 	if err != nil {
 		return err
 	}
+	e.self.updates.Inc()
+	// Decoding may fail after overwriting some fields. Thus only
+	// completely decoded stats replace the previous ones.
+	decoded := &typed.Stats{}
+	err = json.Unmarshal([]byte(stats), decoded)
+	if err != nil {
+		e.self.parseFailures.Inc()
+		return err
+	}
 
-	t := reflect.TypeOf(e.stats)
-	ce, ok := e.cachedUpdater[t]
-	if !ok {
-		ce.collector, ce.updater = gen.NewRecursiveMetricsFromTags(e.stats, gen.WithMetricNameTransform(
-			func(value string) (labelName string) {
-				return string(labelNameExp.ReplaceAllString(value, "_"))
-			},
-		))
-		err := e.registerer.Register(ce.collector)
+	if e.unknown != nil {
+		err = e.unknown.track(e.registerer, []byte(stats), reflect.TypeOf(*decoded))
 		if err != nil {
 			return err
 		}
-		e.cachedUpdater[t] = ce
 	}
 
-	ce.updater.Update(e.stats, prometheus.Labels{})
+	err = e.ensureCollector()
+	if err != nil {
+		return err
+	}
+
+	e.collector.Update(decoded)
+	previous := e.Stats()
+	e.stats.Store(decoded)
+	if e.history != nil {
+		e.history.Add(decoded, start)
+	}
+	e.self.observe(e.collector, decoded.Name, start)
+	if e.push != nil {
+		e.push.start(e.collector, decoded.ClientId, decoded.Name)
+	}
+
+	if e.updated && len(e.onChange) != 0 {
+		changes := typed.Diff(previous, decoded)
+		if !changes.Empty() {
+			for _, fun := range e.onChange {
+				fun(decoded.Name, changes)
+			}
+		}
+	}
+	e.updated = true
 	return nil
 }
 
+func (e *exporter) StreamStatString(stats string) error {
+	if e.closed {
+		return ErrExporterClosed
+	}
+	if e.unknown != nil || e.history != nil || len(e.onChange) != 0 {
+		return ErrStreamingUnsupported
+	}
+	start := time.Now()
+	err := e.registerSelf()
+	if err != nil {
+		return err
+	}
+	e.self.updates.Inc()
+	err = e.ensureCollector()
+	if err != nil {
+		return err
+	}
+	err = e.collector.UpdateJSON(stats)
+	if err != nil {
+		e.self.parseFailures.Inc()
+		return err
+	}
+	e.self.observe(e.collector, clientName(stats), start)
+	if e.push != nil {
+		e.push.start(e.collector, topLevelString(stats, "client_id"), clientName(stats))
+	}
+	return nil
+}
+
+// registerSelf registers the metrics about the Exporter on the first update
+func (e *exporter) registerSelf() error {
+	registered, err := e.self.register(e.registerer)
+	if err != nil {
+		return err
+	}
+	if registered && e.push != nil {
+		e.push.failures = e.self.pushFailures
+	}
+	return nil
+}
+
+func (e *exporter) ensureCollector() error {
+	if e.collector != nil {
+		return nil
+	}
+	c, err := typedcollector.NewStatsCollector(e.genOpts...)
+	if err != nil {
+		return err
+	}
+	registered, err := register(e.registerer, c)
+	if err != nil {
+		return err
+	}
+	if registered != c {
+		c, err = registered.Share(c)
+		if err != nil {
+			unregister(e.registerer, registered)
+			return err
+		}
+	}
+	e.collector = c
+	e.registered = registered
+	return nil
+}
+
+func (e *exporter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	var err error
+	if e.push != nil {
+		err = e.push.close()
+	}
+	if e.collector != nil {
+		e.collector.Delete()
+		unregister(e.registerer, e.registered)
+		e.collector = nil
+		e.registered = nil
+	}
+	if e.unknown != nil {
+		e.unknown.close(e.registerer)
+	}
+	e.self.close(e.registerer)
+	return err
+}
+
 func (e *exporter) Stats() *typed.Stats {
-	return &e.stats
+	return e.stats.Load().(*typed.Stats)
+}
+
+func (e *exporter) History() *history.History {
+	return e.history
+}
+
+func (e *exporter) UnknownFields() []string {
+	if e.unknown == nil {
+		return nil
+	}
+	return e.unknown.list()
 }
@@ -0,0 +1,72 @@
+package v0
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/abergmeier/kafka_stats_exporter/internal/synthetic"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// benchmarkSizes measures steady state updates with `update` for all
+// synthetic sizes
+func benchmarkSizes(b *testing.B, update func(e Exporter, stats string) error) {
+	for _, size := range synthetic.Sizes {
+		stats := [2]string{synthetic.JSON(size, 1), synthetic.JSON(size, 2)}
+		b.Run(size.String(), func(b *testing.B) {
+			e := NewExporter(prometheus.NewRegistry())
+			err := update(e, stats[0])
+			if err != nil {
+				b.Fatal("Update failed:", err)
+			}
+			b.SetBytes(int64(len(stats[0])))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				err = update(e, stats[i%2])
+				if err != nil {
+					b.Fatal("Update failed:", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUpdateWithStatString(b *testing.B) {
+	benchmarkSizes(b, Exporter.UpdateWithStatString)
+}
+
+func BenchmarkStreamStatString(b *testing.B) {
+	benchmarkSizes(b, Exporter.StreamStatString)
+}
+
+func TestUpdateWithStatStringAllocations(t *testing.T) {
+	for _, size := range synthetic.Sizes {
+		stats := [2]string{synthetic.JSON(size, 1), synthetic.JSON(size, 2)}
+		t.Run(size.String(), func(t *testing.T) {
+			// Default options, so with map entry filters and derived metrics
+			e := NewExporter(prometheus.NewRegistry())
+			err := e.UpdateWithStatString(stats[0])
+			if err != nil {
+				t.Fatal("UpdateWithStatString failed:", err)
+			}
+
+			i := 0
+			allocs := testing.AllocsPerRun(10, func() {
+				i++
+				e.UpdateWithStatString(stats[i%2])
+			})
+			decoding := testing.AllocsPerRun(10, func() {
+				i++
+				json.Unmarshal([]byte(stats[i%2]), &typed.Stats{})
+			})
+			// Steady state updates must not allocate per entry or metric
+			// beyond decoding the JSON
+			const budget = 16
+			if allocs-decoding > budget {
+				t.Fatalf("UpdateWithStatString allocates %.0f times on top of decoding (%.0f). Budget: %d", allocs-decoding, decoding, budget)
+			}
+		})
+	}
+}
@@ -0,0 +1,640 @@
+package v0
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/history"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	bootstrapStats = `{
+	"name": "rdkafka#consumer-1",
+	"brokers": {
+		"localhost:9092/bootstrap": {"name": "localhost:9092/bootstrap", "nodeid": -1, "source": "configured", "rx": 1},
+		"localhost:9092/2": {"name": "localhost:9092/2", "nodeid": 2, "source": "learned", "rx": 2}
+	},
+	"topics": {
+		"test": {
+			"topic": "test",
+			"partitions": {
+				"0": {"partition": 0, "consumer_lag": 3},
+				"-1": {"partition": -1, "consumer_lag": -1}
+			}
+		}
+	}
+}`
+)
+
+func TestDefaultMapEntryFilters(t *testing.T) {
+	r := prometheus.NewRegistry()
+	e := NewExporter(r)
+	err := e.UpdateWithStatString(bootstrapStats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	count, err := testutil.GatherAndCount(r, "brokers_rx_total", "topics_partitions_consumer_lag")
+	if err != nil {
+		t.Fatal("GatherAndCount failed:", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 series without bootstrap broker and UA partition. Got: %d", count)
+	}
+}
+
+func TestWithoutDefaultMapEntryFilters(t *testing.T) {
+	r := prometheus.NewRegistry()
+	e := NewExporter(r, WithoutDefaultMapEntryFilters(), WithMapEntryFilter("Stats.Brokers", SkipBootstrapBroker))
+	err := e.UpdateWithStatString(bootstrapStats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	count, err := testutil.GatherAndCount(r, "brokers_rx_total", "topics_partitions_consumer_lag")
+	if err != nil {
+		t.Fatal("GatherAndCount failed:", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 series including UA partition. Got: %d", count)
+	}
+}
+
+func TestDefaultDerivedMetrics(t *testing.T) {
+	r := prometheus.NewRegistry()
+	e := NewExporter(r)
+	err := e.UpdateWithStatString(bootstrapStats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	count, err := testutil.GatherAndCount(r, "msg_cnt_fill_ratio", "brokers_tx_error_ratio", "topics_partitions_unacked_msgs")
+	if err != nil {
+		t.Fatal("GatherAndCount failed:", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 derived series. Got: %d", count)
+	}
+}
+
+func TestWithAggregation(t *testing.T) {
+	r := prometheus.NewRegistry()
+	e := NewExporter(r, WithAggregation("Stats.Topics[].Partitions"))
+	err := e.UpdateWithStatString(`{
+	"name": "rdkafka#consumer-1",
+	"topics": {
+		"test": {
+			"topic": "test",
+			"partitions": {
+				"0": {"partition": 0, "consumer_lag": 3},
+				"1": {"partition": 1, "consumer_lag": 4}
+			}
+		}
+	}
+}`)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	expected := `
+# HELP topics_consumer_lag Difference between (hi_offset or ls_offset) and committed_offset). hi_offset is used when isolation.level=read_uncommitted, otherwise ls_offset.
+# TYPE topics_consumer_lag gauge
+topics_consumer_lag{client_id="",name="rdkafka#consumer-1",topics_topic="test",type=""} 7
+`
+	err = testutil.GatherAndCompare(r, strings.NewReader(expected), "topics_consumer_lag")
+	if err != nil {
+		t.Fatal("Unexpected aggregated lag:", err)
+	}
+}
+
+func TestLastCommitAge(t *testing.T) {
+	r := prometheus.NewRegistry()
+	e := NewExporter(r)
+	for _, update := range []struct {
+		time      int
+		committed int
+		expected  string
+	}{
+		{time: 100, committed: -1001, expected: "0"},
+		{time: 110, committed: 5, expected: "0"},
+		{time: 140, committed: 5, expected: "30"},
+		{time: 150, committed: 6, expected: "0"},
+	} {
+		err := e.UpdateWithStatString(fmt.Sprintf(`{
+	"name": "rdkafka#consumer-1",
+	"time": %d,
+	"topics": {
+		"test": {"topic": "test", "partitions": {"0": {"partition": 0, "committed_offset": %d}}}
+	}
+}`, update.time, update.committed))
+		if err != nil {
+			t.Fatal("UpdateWithStatString failed:", err)
+		}
+		expected := `
+# HELP last_commit_age_seconds Seconds since the committed offset of any partition last advanced (0 before the first commit)
+# TYPE last_commit_age_seconds gauge
+last_commit_age_seconds{client_id="",name="rdkafka#consumer-1",type=""} ` + update.expected + "\n"
+		err = testutil.GatherAndCompare(r, strings.NewReader(expected), "last_commit_age_seconds")
+		if err != nil {
+			t.Fatalf("Unexpected age at time %d: %s", update.time, err)
+		}
+	}
+}
+
+func TestLastCommitAgeOfOtherClient(t *testing.T) {
+	commits := &commitTracker{}
+	stats := func(name string, time int) *typed.Stats {
+		return &typed.Stats{
+			Name: name,
+			Time: time,
+			Topics: map[typed.TopicName]typed.TopicStats{
+				"test": {Topic: "test", Partitions: map[typed.PartitionId]typed.PartitionStats{
+					0: {Partition: 0, CommittedOffset: 5},
+				}},
+			},
+		}
+	}
+	commits.age(stats("rdkafka#consumer-1", 100))
+	age := commits.age(stats("rdkafka#consumer-1", 130))
+	if age != 30 {
+		t.Fatal("Expected age 30. Got:", age)
+	}
+	age = commits.age(stats("rdkafka#consumer-2", 140))
+	if age != 0 {
+		t.Fatal("Expected other client to start over. Got:", age)
+	}
+	if len(commits.offsets) != 1 {
+		t.Fatal("Expected only offsets of the current client. Got:", commits.offsets)
+	}
+}
+
+func TestWithNamespace(t *testing.T) {
+	r := prometheus.NewRegistry()
+	e := NewExporter(r, WithNamespace("kafka"), WithConstLabels(prometheus.Labels{"service": "test"}))
+	err := e.UpdateWithStatString(bootstrapStats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	count, err := testutil.GatherAndCount(r, "kafka_brokers_rx_total")
+	if err != nil {
+		t.Fatal("GatherAndCount failed:", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 namespaced series. Got: %d", count)
+	}
+	// Metrics about the Exporter are named alike
+	expected := `
+# HELP kafka_kafka_stats_exporter_updates_total Statistics updates received
+# TYPE kafka_kafka_stats_exporter_updates_total counter
+kafka_kafka_stats_exporter_updates_total{service="test"} 1
+`
+	err = testutil.GatherAndCompare(r, strings.NewReader(expected), "kafka_kafka_stats_exporter_updates_total")
+	if err != nil {
+		t.Fatal("GatherAndCompare failed:", err)
+	}
+}
+
+func TestWithUnknownFieldDetection(t *testing.T) {
+	r := prometheus.NewRegistry()
+	var reported []string
+	e := NewExporter(r, WithUnknownFieldCallback(func(path string) {
+		reported = append(reported, path)
+	}))
+	err := e.UpdateWithStatString(`{
+	"name": "rdkafka#consumer-1",
+	"new_field": 1,
+	"brokers": {
+		"localhost:9092/2": {"name": "localhost:9092/2", "buf_grow": 0, "req": {"Produce": 1}}
+	},
+	"eos": {"txn_may_enq": false, "producer_epoch": -1, "epoch_cnt": 0},
+	"topics": {
+		"test": {"topic": "test", "partitions": {"0": {"partition": 0, "commited_offset": 1}}}
+	}
+}`)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	// Mapped fields are not reported, even if not exported (e.g. `req`)
+	expected := []string{"brokers.*.buf_grow", "new_field", "topics.*.partitions.*.commited_offset"}
+	if !reflect.DeepEqual(e.UnknownFields(), expected) {
+		t.Fatal("Unexpected unknown fields:", e.UnknownFields())
+	}
+	sort.Strings(reported)
+	if !reflect.DeepEqual(reported, expected) {
+		t.Fatal("Unexpected reported fields:", reported)
+	}
+	count, err := testutil.GatherAndCount(r, "kafka_stats_exporter_unknown_fields")
+	if err != nil {
+		t.Fatal("GatherAndCount failed:", err)
+	}
+	if count != len(expected) {
+		t.Fatalf("Expected %d unknown field series. Got: %d", len(expected), count)
+	}
+}
+
+func TestClose(t *testing.T) {
+	r := prometheus.NewRegistry()
+	e := NewExporter(r, WithUnknownFieldDetection())
+	err := e.UpdateWithStatString(bootstrapStats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	err = e.Close()
+	if err != nil {
+		t.Fatal("Close failed:", err)
+	}
+	mfs, err := r.Gather()
+	if err != nil {
+		t.Fatal("Gather failed:", err)
+	}
+	if len(mfs) != 0 {
+		t.Fatalf("Expected no metrics after Close. Got: %d", len(mfs))
+	}
+	err = e.UpdateWithStatString(bootstrapStats)
+	if !errors.Is(err, ErrExporterClosed) {
+		t.Fatal("Expected ErrExporterClosed. Got:", err)
+	}
+}
+
+func TestRecreateExporter(t *testing.T) {
+	r := prometheus.NewRegistry()
+	old := NewExporter(r)
+	err := old.UpdateWithStatString(bootstrapStats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	// Client got recreated without closing the old Exporter
+	e := NewExporter(r)
+	err = e.UpdateWithStatString(bootstrapStats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString of recreated Exporter failed:", err)
+	}
+
+	old.Close()
+	count, err := testutil.GatherAndCount(r, "brokers_rx_total")
+	if err != nil {
+		t.Fatal("GatherAndCount failed:", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected series of recreated Exporter. Got: %d", count)
+	}
+
+	e.Close()
+	count, err = testutil.GatherAndCount(r, "brokers_rx_total")
+	if err != nil {
+		t.Fatal("GatherAndCount failed:", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected no series after closing all Exporters. Got: %d", count)
+	}
+}
+
+func TestConcurrentClients(t *testing.T) {
+	r := prometheus.NewRegistry()
+	var wg sync.WaitGroup
+	exporters := make([]Exporter, 2)
+	for i := range exporters {
+		e := NewExporter(r)
+		exporters[i] = e
+		name := fmt.Sprintf("rdkafka#consumer-%d", i+1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rx := 1; rx <= 100; rx++ {
+				err := e.UpdateWithStatString(fmt.Sprintf(`{
+	"name": "%s",
+	"brokers": {"localhost:9092/2": {"name": "localhost:9092/2", "nodeid": 2, "source": "learned", "rx": %d}}
+}`, name, rx))
+				if err != nil {
+					t.Error("UpdateWithStatString failed:", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	expected := `
+# HELP brokers_rx_total Total number of responses received
+# TYPE brokers_rx_total counter
+brokers_rx_total{brokers_name="localhost:9092/2",brokers_nodeid="2",brokers_nodename="",brokers_source="learned",brokers_state="",client_id="",name="rdkafka#consumer-1",type=""} 100
+brokers_rx_total{brokers_name="localhost:9092/2",brokers_nodeid="2",brokers_nodename="",brokers_source="learned",brokers_state="",client_id="",name="rdkafka#consumer-2",type=""} 100
+`
+	err := testutil.GatherAndCompare(r, strings.NewReader(expected), "brokers_rx_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exporters[0].Close()
+	count, err := testutil.GatherAndCount(r, "brokers_rx_total")
+	if err != nil {
+		t.Fatal("GatherAndCount failed:", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected only series of the open Exporter. Got: %d", count)
+	}
+}
+
+func TestIncompatibleExporter(t *testing.T) {
+	r := prometheus.NewRegistry()
+	e := NewExporter(r)
+	err := e.UpdateWithStatString(bootstrapStats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	other := NewExporter(r, WithMapEntryFilter("Stats.Brokers", gen.NewMapEntryFilter(func(key typed.BrokerName, value typed.BrokerStats) bool {
+		return true
+	})))
+	err = other.UpdateWithStatString(bootstrapStats)
+	if !errors.Is(err, gen.ErrIncompatibleOptions) {
+		t.Fatal("Expected ErrIncompatibleOptions. Got:", err)
+	}
+}
+
+func TestStreamStatStringUnsupported(t *testing.T) {
+	for name, opt := range map[string]ExporterOption{
+		"unknown fields": WithUnknownFieldDetection(),
+		"history":        WithHistory(2),
+		"change":         WithChangeCallback(func(changes *typed.ChangeSet) {}),
+		"events":         WithEventObserver(func(ev Event) {}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			e := NewExporter(prometheus.NewRegistry(), opt)
+			err := e.StreamStatString(bootstrapStats)
+			if !errors.Is(err, ErrStreamingUnsupported) {
+				t.Fatal("Expected ErrStreamingUnsupported. Got:", err)
+			}
+		})
+	}
+}
+
+func TestStreamStatStringMalformed(t *testing.T) {
+	tests := map[string]string{
+		"truncated":  `{"name": "rdkafka#consumer-1", "rx": 7, "brokers": {"localhost:9092/2": {"name": "localhost:9092/2", "rx"`,
+		"garbage":    `{"name": "rdkafka#consumer-1", "rx": 7 "brokers": {}}`,
+		"wrong type": `{"name": "rdkafka#consumer-1", "rx": 7, "brokers": {"localhost:9092/2": {"name": "localhost:9092/2", "tx": "many"}}}`,
+	}
+	for name, stats := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := prometheus.NewRegistry()
+			e := NewExporter(r)
+			err := e.StreamStatString(bootstrapStats)
+			if err != nil {
+				t.Fatal("StreamStatString failed:", err)
+			}
+			before := gatherText(t, r, "rx_total", "brokers_rx_total", "brokers_tx_total")
+
+			err = e.StreamStatString(stats)
+			if err == nil {
+				t.Fatal("Expected malformed stats to fail")
+			}
+			after := gatherText(t, r, "rx_total", "brokers_rx_total", "brokers_tx_total")
+			if d := cmp.Diff(before, after); d != "" {
+				t.Fatal("Expected metrics to be unchanged (-want +got):\n", d)
+			}
+			failures := gatherText(t, r, "kafka_stats_exporter_parse_failures_total")
+			if !strings.Contains(failures, "\nkafka_stats_exporter_parse_failures_total 1\n") {
+				t.Fatal("Expected parse failure to be counted. Got:\n", failures)
+			}
+		})
+	}
+}
+
+// gatherText returns the metrics with names in text format
+func gatherText(t *testing.T, g prometheus.Gatherer, names ...string) string {
+	mfs, err := g.Gather()
+	if err != nil {
+		t.Fatal("Gather failed:", err)
+	}
+	var buf bytes.Buffer
+	for _, mf := range mfs {
+		for _, name := range names {
+			if mf.GetName() != name {
+				continue
+			}
+			_, err = expfmt.MetricFamilyToText(&buf, mf)
+			if err != nil {
+				t.Fatal("MetricFamilyToText failed:", err)
+			}
+		}
+	}
+	return buf.String()
+}
+
+func TestNilRegisterer(t *testing.T) {
+	e := NewExporter(nil)
+	err := e.Close()
+	if err != nil {
+		t.Fatal("Close failed:", err)
+	}
+}
+
+func TestSelfMetricsConflict(t *testing.T) {
+	r := prometheus.NewRegistry()
+	r.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kafka_stats_exporter_series",
+		Help: "Something else",
+	}))
+	e := NewExporter(r)
+	err := e.UpdateWithStatString(bootstrapStats)
+	if err == nil {
+		t.Fatal("Expected conflicting registration to fail")
+	}
+	count, err := testutil.GatherAndCount(r, "kafka_stats_exporter_updates_total")
+	if err != nil {
+		t.Fatal("GatherAndCount failed:", err)
+	}
+	if count != 0 {
+		t.Fatal("Expected no metrics to stay registered after failing")
+	}
+}
+
+func TestSelfMetrics(t *testing.T) {
+	r := prometheus.NewRegistry()
+	e := NewExporter(r)
+	count, err := testutil.GatherAndCount(r, "kafka_stats_exporter_updates_total")
+	if err != nil {
+		t.Fatal("GatherAndCount failed:", err)
+	}
+	if count != 0 {
+		t.Fatal("Expected nothing to be registered before the first update")
+	}
+
+	err = e.UpdateWithStatString(bootstrapStats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	err = e.UpdateWithStatString(`{"name": "rdkafka#consumer-1", "brokers": {`)
+	if err == nil {
+		t.Fatal("Expected truncated stats to fail")
+	}
+	expected := `
+# HELP kafka_stats_exporter_map_entries Entries tracked per statistics map (e.g. topics_partitions)
+# TYPE kafka_stats_exporter_map_entries gauge
+kafka_stats_exporter_map_entries{client="rdkafka#consumer-1",map="brokers"} 1
+kafka_stats_exporter_map_entries{client="rdkafka#consumer-1",map="topics"} 1
+kafka_stats_exporter_map_entries{client="rdkafka#consumer-1",map="topics_partitions"} 1
+# HELP kafka_stats_exporter_parse_failures_total Statistics updates, which could not be parsed
+# TYPE kafka_stats_exporter_parse_failures_total counter
+kafka_stats_exporter_parse_failures_total 1
+# HELP kafka_stats_exporter_updates_total Statistics updates received
+# TYPE kafka_stats_exporter_updates_total counter
+kafka_stats_exporter_updates_total 2
+`
+	err = testutil.GatherAndCompare(r, strings.NewReader(expected),
+		"kafka_stats_exporter_map_entries",
+		"kafka_stats_exporter_parse_failures_total",
+		"kafka_stats_exporter_updates_total",
+	)
+	if err != nil {
+		t.Fatal("GatherAndCompare failed:", err)
+	}
+
+	mfs, err := r.Gather()
+	if err != nil {
+		t.Fatal("Gather failed:", err)
+	}
+	series := 0
+	var exported, lastUpdate float64
+	for _, mf := range mfs {
+		switch mf.GetName() {
+		case "kafka_stats_exporter_series":
+			exported = mf.GetMetric()[0].GetGauge().GetValue()
+		case "kafka_stats_exporter_last_update_timestamp_seconds":
+			lastUpdate = mf.GetMetric()[0].GetGauge().GetValue()
+		}
+		if !strings.HasPrefix(mf.GetName(), "kafka_stats_exporter_") {
+			series += len(mf.GetMetric())
+		}
+	}
+	if int(exported) != series {
+		t.Fatalf("Expected %d exported series. Got: %.0f", series, exported)
+	}
+	if lastUpdate == 0 {
+		t.Fatal("Expected timestamp of last update")
+	}
+}
+
+// gatherStats returns the metrics exported from statistics in text format
+func gatherStats(t *testing.T, r *prometheus.Registry) string {
+	mfs, err := r.Gather()
+	if err != nil {
+		t.Fatal("Gather failed:", err)
+	}
+	var buf bytes.Buffer
+	for _, mf := range mfs {
+		if strings.HasPrefix(mf.GetName(), "kafka_stats_exporter_") {
+			continue
+		}
+		_, err = expfmt.MetricFamilyToText(&buf, mf)
+		if err != nil {
+			t.Fatal("MetricFamilyToText failed:", err)
+		}
+	}
+	return buf.String()
+}
+
+func TestUpdateWithMalformedStats(t *testing.T) {
+	r := prometheus.NewRegistry()
+	e := NewExporter(r)
+	err := e.UpdateWithStatString(bootstrapStats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	previous := *e.Stats()
+	metrics := gatherStats(t, r)
+
+	for name, stats := range map[string]string{
+		"truncated": bootstrapStats[:len(bootstrapStats)/2],
+		"garbage":   "rdkafka#consumer-1",
+		"empty":     "",
+		// Decoding continues after type errors and overwrites fields
+		"type": `{
+	"name": "rdkafka#consumer-2",
+	"brokers": {
+		"localhost:9092/2": {"name": "localhost:9092/2", "nodeid": 2, "source": "learned", "rx": 1000, "tx": "many"}
+	}
+}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := e.UpdateWithStatString(stats)
+			if err == nil {
+				t.Fatal("Expected UpdateWithStatString to fail")
+			}
+			d := cmp.Diff(previous, *e.Stats())
+			if d != "" {
+				t.Fatal("Stats changed", d)
+			}
+			d = cmp.Diff(metrics, gatherStats(t, r))
+			if d != "" {
+				t.Fatal("Metrics changed", d)
+			}
+		})
+	}
+
+	// Counters continue from the last successful update
+	err = e.UpdateWithStatString(strings.Replace(bootstrapStats, `"rx": 2`, `"rx": 5`, 1))
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	expected := `
+# HELP brokers_rx_total Total number of responses received
+# TYPE brokers_rx_total counter
+brokers_rx_total{brokers_name="localhost:9092/2",brokers_nodeid="2",brokers_nodename="",brokers_source="learned",brokers_state="",client_id="",name="rdkafka#consumer-1",type=""} 5
+`
+	err = testutil.GatherAndCompare(r, strings.NewReader(expected), "brokers_rx_total")
+	if err != nil {
+		t.Fatal("GatherAndCompare failed:", err)
+	}
+}
+
+func TestWithHistory(t *testing.T) {
+	e := NewExporter(prometheus.NewRegistry(), WithHistory(2))
+	for _, rx := range []string{"2", "4", "8"} {
+		err := e.UpdateWithStatString(strings.Replace(bootstrapStats, `"rx": 2`, `"rx": `+rx, 1))
+		if err != nil {
+			t.Fatal("UpdateWithStatString failed:", err)
+		}
+	}
+	samples := history.Broker(e.History().Snapshots(), "localhost:9092/2", func(bs *typed.BrokerStats) int {
+		return bs.Rx
+	})
+	delta, ok := history.Delta(samples)
+	if !ok || delta != 4 {
+		t.Fatalf("Unexpected delta of last 2 updates %d, %t", delta, ok)
+	}
+}
+
+func TestWithChangeCallback(t *testing.T) {
+	var changes []*typed.ChangeSet
+	e := NewExporter(prometheus.NewRegistry(), WithChangeCallback(func(cs *typed.ChangeSet) {
+		changes = append(changes, cs)
+	}))
+	for _, stats := range []string{
+		bootstrapStats,
+		bootstrapStats,
+		strings.Replace(bootstrapStats, `"source": "learned", "rx": 2`, `"source": "learned", "state": "DOWN", "rx": 2`, 1),
+	} {
+		err := e.UpdateWithStatString(stats)
+		if err != nil {
+			t.Fatal("UpdateWithStatString failed:", err)
+		}
+	}
+	expected := []*typed.ChangeSet{{
+		Labels: []typed.LabelChange{
+			{Path: typed.Path{"brokers", "localhost:9092/2", "state"}, Old: "", New: "DOWN"},
+		},
+	}}
+	d := cmp.Diff(expected, changes)
+	if d != "" {
+		t.Fatal("Diff", d)
+	}
+}
@@ -0,0 +1,243 @@
+package v0
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ExporterOption represents an opaque option implementation
+// for creating an Exporter
+type ExporterOption interface {
+}
+
+// WithMapEntryFilter creates an Option for skipping entries of the map at
+// the given Go path (e.g. `Stats.Topics[].Partitions`). Filters are applied
+// in addition to the default filters.
+func WithMapEntryFilter(path string, filter types.MapEntryFilter) ExporterOption {
+	return &exporterMapEntryFilter{
+		path: path,
+		fun:  filter,
+	}
+}
+
+// WithoutDefaultMapEntryFilters creates an Option for disabling the
+// filters from DefaultMapEntryFilters.
+func WithoutDefaultMapEntryFilters() ExporterOption {
+	return &exporterWithoutDefaultMapEntryFilters{}
+}
+
+// WithDerivedMetrics creates an Option for exporting metrics computed from
+// the struct at the given Go path (e.g. `Stats.Brokers[]` or `Stats`).
+// Metrics are exported in addition to DefaultDerivedMetrics.
+func WithDerivedMetrics(path string, metrics ...types.DerivedMetric) ExporterOption {
+	return &exporterDerivedMetrics{
+		path:    path,
+		metrics: metrics,
+	}
+}
+
+// WithoutDefaultDerivedMetrics creates an Option for disabling the
+// metrics from DefaultDerivedMetrics.
+func WithoutDefaultDerivedMetrics() ExporterOption {
+	return &exporterWithoutDefaultDerivedMetrics{}
+}
+
+// WithNestedInMapEntries creates an Option for exporting the windows of
+// map entries (e.g. the rtt of every broker), which are not exported by
+// default.
+func WithNestedInMapEntries() ExporterOption {
+	return &exporterNestedInMapEntries{}
+}
+
+// WithAggregation creates an Option for exporting the maps at the given Go
+// paths (e.g. `Stats.Topics[].Partitions`) summed up per owning struct
+// instead of per entry. Fields tagged `kpromagg:"max"` (e.g. ages) take
+// the maximum of the entries.
+func WithAggregation(paths ...string) ExporterOption {
+	return &exporterAggregation{
+		paths: paths,
+	}
+}
+
+// WithNamespace creates an Option for setting the Namespace (e.g. `kafka`)
+// of all exported metrics.
+func WithNamespace(namespace string) ExporterOption {
+	return &exporterNamespace{
+		namespace: namespace,
+	}
+}
+
+// WithSubsystem creates an Option for setting the Subsystem of all
+// exported metrics.
+func WithSubsystem(subsystem string) ExporterOption {
+	return &exporterSubsystem{
+		subsystem: subsystem,
+	}
+}
+
+// WithConstLabels creates an Option for adding constant Labels
+// (e.g. `service` or `pod`) to all exported metrics.
+func WithConstLabels(labels prometheus.Labels) ExporterOption {
+	return &exporterConstLabels{
+		labels: labels,
+	}
+}
+
+// WithUnknownFieldDetection creates an Option for tracking statistics fields,
+// which are not mapped to Stats (e.g. `brokers.*.foo` added by a newer
+// librdkafka). Each new field is exposed via the
+// `kafka_stats_exporter_unknown_fields` metric and Exporter.UnknownFields.
+// Exporter.StreamStatString fails with ErrStreamingUnsupported, as fields
+// are detected by decoding Stats.
+func WithUnknownFieldDetection() ExporterOption {
+	return &exporterUnknownFieldDetection{}
+}
+
+// WithUnknownFieldCallback creates an Option for calling `fun` once for
+// every new statistics field, which is not mapped to Stats (e.g. for
+// logging it). Enables WithUnknownFieldDetection.
+func WithUnknownFieldCallback(fun func(path string)) ExporterOption {
+	return &exporterUnknownFieldCallback{
+		fun: fun,
+	}
+}
+
+// WithHistory creates an Option for keeping the last `size` Stats (see
+// Exporter.History). Exporter.StreamStatString fails with
+// ErrStreamingUnsupported, as it does not decode Stats.
+func WithHistory(size int) ExporterOption {
+	return &exporterHistory{
+		size: size,
+	}
+}
+
+// WithChangeCallback creates an Option for calling fun with the changes
+// between consecutive Stats (e.g. added partitions or broker state changes).
+// Exporter.StreamStatString fails with ErrStreamingUnsupported, as it does
+// not decode Stats.
+func WithChangeCallback(fun func(changes *typed.ChangeSet)) ExporterOption {
+	return &exporterChangeCallback{
+		fun: fun,
+	}
+}
+
+// WithEventObserver creates an Option for calling fun with Events of the
+// given kinds (all kinds if none are given). Like WithChangeCallback, Events
+// are derived from Stats, thus Exporter.StreamStatString fails with
+// ErrStreamingUnsupported.
+func WithEventObserver(fun func(ev Event), kinds ...EventKind) ExporterOption {
+	return &exporterEventObserver{
+		observer: observer{
+			fun:   fun,
+			kinds: kinds,
+		},
+	}
+}
+
+// WithPushgateway creates an Option for pushing the metrics of the client
+// to the Pushgateway at url every interval and on Close. Metrics are grouped
+// by job and the `client_id` and `name` of the first update.
+// Panics if interval is not positive.
+func WithPushgateway(url, job string, interval time.Duration) ExporterOption {
+	if interval <= 0 {
+		panic(fmt.Sprintf("Pushgateway interval needs to be positive but is %s", interval))
+	}
+	return &exporterPushgateway{
+		url:      url,
+		job:      job,
+		interval: interval,
+	}
+}
+
+// WithPushgatewayDeleteOnClose creates an Option for deleting the group of
+// the client from the Pushgateway on Close after pushing the final metrics.
+// Only has an effect together with WithPushgateway.
+func WithPushgatewayDeleteOnClose() ExporterOption {
+	return &exporterPushgatewayDeleteOnClose{}
+}
+
+type exporterMapEntryFilter struct {
+	path string
+	fun  types.MapEntryFilter
+}
+
+type exporterWithoutDefaultMapEntryFilters struct {
+}
+
+type exporterDerivedMetrics struct {
+	path    string
+	metrics []types.DerivedMetric
+}
+
+type exporterNestedInMapEntries struct {
+}
+
+type exporterAggregation struct {
+	paths []string
+}
+
+type exporterWithoutDefaultDerivedMetrics struct {
+}
+
+type exporterUnknownFieldDetection struct {
+}
+
+type exporterUnknownFieldCallback struct {
+	fun func(path string)
+}
+
+type exporterNamespace struct {
+	namespace string
+}
+
+type exporterSubsystem struct {
+	subsystem string
+}
+
+type exporterConstLabels struct {
+	labels prometheus.Labels
+}
+
+// DefaultMapEntryFilters are applied to Stats unless disabled via
+// WithoutDefaultMapEntryFilters.
+var DefaultMapEntryFilters = map[string]types.MapEntryFilter{
+	"Stats.Brokers":             SkipBootstrapBroker,
+	"Stats.Topics[].Partitions": SkipUnassignedPartition,
+}
+
+// SkipUnassignedPartition filters the internal UA/UnAssigned partition (-1)
+var SkipUnassignedPartition = gen.NewMapEntryFilter(func(key typed.PartitionId, value typed.PartitionStats) bool {
+	return value.Partition != -1
+})
+
+// SkipBootstrapBroker filters brokers originating from the configured
+// bootstrap servers
+var SkipBootstrapBroker = gen.NewMapEntryFilter(func(key typed.BrokerName, value typed.BrokerStats) bool {
+	return value.Source != "configured"
+})
+
+type exporterHistory struct {
+	size int
+}
+
+type exporterChangeCallback struct {
+	fun func(changes *typed.ChangeSet)
+}
+
+type exporterEventObserver struct {
+	observer observer
+}
+
+type exporterPushgateway struct {
+	url      string
+	job      string
+	interval time.Duration
+}
+
+type exporterPushgatewayDeleteOnClose struct {
+}
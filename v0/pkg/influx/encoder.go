@@ -0,0 +1,251 @@
+// Package influx encodes Stats as InfluxDB line protocol.
+package influx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+)
+
+// measurements of the struct levels of Stats. Fields of other nested
+// structs (e.g. `cgrp`) belong to the measurement of their parent.
+var measurements = map[reflect.Type]string{
+	reflect.TypeOf(typed.Stats{}):          "kafka_client",
+	reflect.TypeOf(typed.BrokerStats{}):    "kafka_broker",
+	reflect.TypeOf(typed.TopicStats{}):     "kafka_topic",
+	reflect.TypeOf(typed.PartitionStats{}): "kafka_partition",
+	reflect.TypeOf(typed.WindowStats{}):    "kafka_window",
+}
+
+// field is where the value of a metric goes
+type field struct {
+	measurement string
+	window      string // Name of the WindowStats field or ""
+	name        string
+	integer     bool
+	// Labels of nested structs (e.g. `cgrp_state`), which are fields
+	// instead of tags to keep the tags of the measurement
+	labelFields []string
+}
+
+// Encoder writes the metrics of Stats as one line per struct value. Tags
+// are the labels of the Prometheus metrics. Windows (e.g. `rtt`) are
+// additionally tagged with `window`, if exported via
+// v0.WithNestedInMapEntries.
+type Encoder struct {
+	w      *bufio.Writer
+	rec    *gen.Recorder
+	fields map[string]field // By metric name
+
+	lines map[string]*line // By measurement and tags
+	keys  []string
+}
+
+type line struct {
+	fields map[string]string // Encoded values by escaped key
+}
+
+// NewEncoder creates an Encoder writing the metrics named by naming (see
+// `v0.MetricNaming`) to w.
+func NewEncoder(w io.Writer, naming *gen.GeneratedOptions) *Encoder {
+	e := &Encoder{
+		w:      bufio.NewWriter(w),
+		rec:    naming.NewRecorder(),
+		fields: map[string]field{},
+	}
+	for _, d := range naming.Describe() {
+		e.fields[d.Name] = fieldAt(d.Path, naming)
+	}
+	return e
+}
+
+// fieldAt resolves the Go path of a metric (e.g. `Stats.Brokers[].Rtt.P99`)
+func fieldAt(path string, naming *gen.GeneratedOptions) field {
+	segments := strings.Split(path, ".")
+	t := reflect.TypeOf(typed.Stats{})
+	f := field{
+		measurement: measurements[t],
+	}
+	prefix := ""
+	metricPrefix := ""
+	for _, s := range segments[1 : len(segments)-1] {
+		sf, ok := t.FieldByName(strings.TrimSuffix(s, "[]"))
+		if !ok {
+			panic(fmt.Sprintf("No field for `%s` in `%s`", s, path))
+		}
+		t = sf.Type
+		if t.Kind() == reflect.Map {
+			t = t.Elem()
+			name, _, _ := strings.Cut(sf.Tag.Get("kprommap"), ",")
+			metricPrefix = joinPrefix(metricPrefix, name)
+		} else {
+			metricPrefix = joinPrefix(metricPrefix, sf.Tag.Get("kprompnt"))
+		}
+		m, ok := measurements[t]
+		switch {
+		case !ok:
+			prefix += jsonName(sf) + "_"
+			for _, lf := range reflect.VisibleFields(t) {
+				tag := lf.Tag.Get("kpromlbl")
+				if tag != "" {
+					f.labelFields = append(f.labelFields, naming.LabelName(metricPrefix, tag))
+				}
+			}
+		case t == reflect.TypeOf(typed.WindowStats{}):
+			f.measurement = m
+			f.window = jsonName(sf)
+			f.labelFields = nil
+			prefix = ""
+		default:
+			f.measurement = m
+			f.window = ""
+			f.labelFields = nil
+			prefix = ""
+		}
+	}
+	last := segments[len(segments)-1]
+	if strings.HasSuffix(last, "()") {
+		// Derived metric
+		f.name = prefix + strings.TrimSuffix(last, "()")
+		return f
+	}
+	sf, ok := t.FieldByName(last)
+	if !ok {
+		panic(fmt.Sprintf("No field for `%s` in `%s`", last, path))
+	}
+	f.name = prefix + jsonName(sf)
+	f.integer = true
+	return f
+}
+
+func joinPrefix(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "_" + name
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+// Encode writes the lines for stats timestamped with the `time` of stats
+func (e *Encoder) Encode(stats *typed.Stats) error {
+	e.lines = map[string]*line{}
+	e.keys = e.keys[:0]
+	e.rec.Record(stats, e)
+	sort.Strings(e.keys)
+
+	timestamp := ""
+	if stats.Time != 0 {
+		timestamp = " " + strconv.FormatInt(int64(stats.Time)*1e9, 10)
+	}
+	for _, k := range e.keys {
+		l := e.lines[k]
+		keys := make([]string, 0, len(l.fields))
+		for fk := range l.fields {
+			keys = append(keys, fk)
+		}
+		sort.Strings(keys)
+		e.w.WriteString(k)
+		for i, fk := range keys {
+			if i == 0 {
+				e.w.WriteByte(' ')
+			} else {
+				e.w.WriteByte(',')
+			}
+			e.w.WriteString(fk)
+			e.w.WriteByte('=')
+			e.w.WriteString(l.fields[fk])
+		}
+		e.w.WriteString(timestamp)
+		e.w.WriteByte('\n')
+	}
+	return e.w.Flush()
+}
+
+// Record implements gen.MetricSink
+func (e *Encoder) Record(desc *gen.MetricDesc, labels map[string]string, value float64) {
+	f := e.fields[desc.Name]
+	tags := labels
+	if f.window != "" || len(f.labelFields) != 0 {
+		tags = make(map[string]string, len(labels)+1)
+		for k, v := range labels {
+			tags[k] = v
+		}
+		if f.window != "" {
+			tags["window"] = f.window
+		}
+		for _, lf := range f.labelFields {
+			delete(tags, lf)
+		}
+	}
+	key := seriesKey(f.measurement, tags)
+	l, ok := e.lines[key]
+	if !ok {
+		l = &line{
+			fields: map[string]string{},
+		}
+		e.lines[key] = l
+		e.keys = append(e.keys, key)
+	}
+	if f.integer {
+		l.fields[escapeKey(f.name)] = strconv.FormatInt(int64(value), 10) + "i"
+	} else {
+		l.fields[escapeKey(f.name)] = strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	for _, lf := range f.labelFields {
+		l.fields[escapeKey(lf)] = quoteString(labels[lf])
+	}
+}
+
+var stringReplacer = strings.NewReplacer("\\", "\\\\", "\"", "\\\"")
+
+// quoteString encodes a string field value
+func quoteString(s string) string {
+	return "\"" + stringReplacer.Replace(s) + "\""
+}
+
+// seriesKey is the measurement and the tags sorted by name. Empty tags
+// are dropped as line protocol does not allow them.
+func seriesKey(measurement string, tags map[string]string) string {
+	names := make([]string, 0, len(tags))
+	for k, v := range tags {
+		if v == "" {
+			continue
+		}
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	b := &strings.Builder{}
+	b.WriteString(escapeMeasurement(measurement))
+	for _, k := range names {
+		b.WriteByte(',')
+		b.WriteString(escapeKey(k))
+		b.WriteByte('=')
+		b.WriteString(escapeKey(tags[k]))
+	}
+	return b.String()
+}
+
+var (
+	measurementReplacer = strings.NewReplacer(",", "\\,", " ", "\\ ")
+	keyReplacer         = strings.NewReplacer(",", "\\,", "=", "\\=", " ", "\\ ")
+)
+
+func escapeMeasurement(s string) string {
+	return measurementReplacer.Replace(s)
+}
+
+// escapeKey escapes tag keys, tag values and field keys
+func escapeKey(s string) string {
+	return keyReplacer.Replace(s)
+}
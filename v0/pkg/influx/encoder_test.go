@@ -0,0 +1,69 @@
+package influx
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	v0 "github.com/abergmeier/kafka_stats_exporter/v0"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEncode(t *testing.T) {
+	b, err := os.ReadFile("../prometheus/gen/testdata/full.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := &typed.Stats{}
+	err = json.Unmarshal(b, stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	naming, err := v0.MetricNaming(v0.WithNestedInMapEntries())
+	if err != nil {
+		t.Fatal("MetricNaming failed:", err)
+	}
+
+	out := &bytes.Buffer{}
+	err = NewEncoder(out, naming).Encode(stats)
+	if err != nil {
+		t.Fatal("Encode failed:", err)
+	}
+	expected, err := os.ReadFile("testdata/full.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := cmp.Diff(string(expected), out.String())
+	if d != "" {
+		t.Fatal("Diff", d)
+	}
+}
+
+func TestFieldAt(t *testing.T) {
+	naming, err := v0.MetricNaming(v0.WithNestedInMapEntries())
+	if err != nil {
+		t.Fatal("MetricNaming failed:", err)
+	}
+	for path, expected := range map[string]field{
+		"Stats.MsgCnt":                       {measurement: "kafka_client", name: "msg_cnt", integer: true},
+		"Stats.msg_cnt_fill_ratio()":         {measurement: "kafka_client", name: "msg_cnt_fill_ratio"},
+		"Stats.Cgrp.RebalanceCnt":            {measurement: "kafka_client", name: "cgrp_rebalance_cnt", integer: true, labelFields: []string{"cgrp_state", "cgrp_join_state", "cgrp_rebalance_reason"}},
+		"Stats.Brokers[].Rtt.P99":            {measurement: "kafka_window", window: "rtt", name: "p99", integer: true},
+		"Stats.Topics[].Partitions[].Rxmsgs": {measurement: "kafka_partition", name: "rxmsgs", integer: true},
+	} {
+		d := cmp.Diff(expected, fieldAt(path, naming), cmp.AllowUnexported(field{}))
+		if d != "" {
+			t.Errorf("Diff for `%s` %s", path, d)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	key := seriesKey("kafka client", map[string]string{"b": "x=y, z", "a": ""})
+	expected := `kafka\ client,b=x\=y\,\ z`
+	if key != expected {
+		t.Errorf("Expected `%s` but got `%s`", expected, key)
+	}
+}
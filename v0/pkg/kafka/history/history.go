@@ -0,0 +1,218 @@
+// Package history keeps the last snapshots of librdkafka Statistics and
+// computes deltas and rates of their fields.
+package history
+
+import (
+	"sync"
+	"time"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+)
+
+// Snapshot is a copy of Stats at the time it got added
+type Snapshot struct {
+	Time  time.Time
+	Stats typed.Stats
+}
+
+// Sample is the value of a field in a Snapshot
+type Sample struct {
+	Time  time.Time
+	Ts    int // librdkafka monotonic clock (microseconds)
+	Value int
+}
+
+// History is a ring buffer of the last Stats snapshots.
+// Safe for concurrent use.
+type History struct {
+	mu        sync.RWMutex
+	snapshots []Snapshot
+	next      int
+	full      bool
+}
+
+// New creates a History keeping the last `size` snapshots.
+// Panics if size is smaller than 1.
+func New(size int) *History {
+	if size < 1 {
+		panic("History needs to keep at least one snapshot")
+	}
+	return &History{
+		snapshots: make([]Snapshot, size),
+	}
+}
+
+// Add stores a deep copy of s, replacing the oldest snapshot if full.
+func (h *History) Add(s *typed.Stats, t time.Time) {
+	snapshot := Snapshot{
+		Time:  t,
+		Stats: s.DeepCopy(),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.snapshots[h.next] = snapshot
+	h.next = (h.next + 1) % len(h.snapshots)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Snapshots returns all snapshots, oldest first.
+// Snapshots must not be modified.
+func (h *History) Snapshots() []Snapshot {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if !h.full {
+		return append([]Snapshot(nil), h.snapshots[:h.next]...)
+	}
+	snapshots := make([]Snapshot, 0, len(h.snapshots))
+	snapshots = append(snapshots, h.snapshots[h.next:]...)
+	return append(snapshots, h.snapshots[:h.next]...)
+}
+
+// Since returns the snapshots added at or after t, oldest first.
+func (h *History) Since(t time.Time) []Snapshot {
+	snapshots := h.Snapshots()
+	for i, s := range snapshots {
+		if !s.Time.Before(t) {
+			return snapshots[i:]
+		}
+	}
+	return nil
+}
+
+// Root returns the samples of a field of Stats
+func Root(snapshots []Snapshot, field func(s *typed.Stats) int) []Sample {
+	samples := make([]Sample, 0, len(snapshots))
+	for i := range snapshots {
+		s := &snapshots[i]
+		samples = append(samples, sample(s, field(&s.Stats)))
+	}
+	return samples
+}
+
+// Broker returns the samples of a field of a broker. Snapshots without
+// the broker are skipped.
+func Broker(snapshots []Snapshot, broker typed.BrokerName, field func(bs *typed.BrokerStats) int) []Sample {
+	var samples []Sample
+	for i := range snapshots {
+		s := &snapshots[i]
+		bs, ok := s.Stats.Brokers[broker]
+		if !ok {
+			continue
+		}
+		samples = append(samples, sample(s, field(&bs)))
+	}
+	return samples
+}
+
+// Topic returns the samples of a field of a topic. Snapshots without
+// the topic are skipped.
+func Topic(snapshots []Snapshot, topic typed.TopicName, field func(ts *typed.TopicStats) int) []Sample {
+	var samples []Sample
+	for i := range snapshots {
+		s := &snapshots[i]
+		ts, ok := s.Stats.Topics[topic]
+		if !ok {
+			continue
+		}
+		samples = append(samples, sample(s, field(&ts)))
+	}
+	return samples
+}
+
+// Partition returns the samples of a field of a partition. Snapshots
+// without the partition are skipped.
+func Partition(snapshots []Snapshot, topic typed.TopicName, partition typed.PartitionId, field func(ps *typed.PartitionStats) int) []Sample {
+	var samples []Sample
+	for i := range snapshots {
+		s := &snapshots[i]
+		ps, ok := s.Stats.Topics[topic].Partitions[partition]
+		if !ok {
+			continue
+		}
+		samples = append(samples, sample(s, field(&ps)))
+	}
+	return samples
+}
+
+func sample(s *Snapshot, value int) Sample {
+	return Sample{
+		Time:  s.Time,
+		Ts:    s.Stats.Ts,
+		Value: value,
+	}
+}
+
+// Delta returns the difference between the newest and the oldest sample.
+// Fails with less than 2 samples.
+func Delta(samples []Sample) (int, bool) {
+	if len(samples) < 2 {
+		return 0, false
+	}
+	return samples[len(samples)-1].Value - samples[0].Value, true
+}
+
+// Increase returns the increase of a counter over all samples. Like in
+// Prometheus a decreasing value is treated as counter reset (e.g. after
+// the client got recreated).
+// Fails with less than 2 samples.
+func Increase(samples []Sample) (int, bool) {
+	if len(samples) < 2 {
+		return 0, false
+	}
+	increase := 0
+	for i := 1; i < len(samples); i++ {
+		diff := samples[i].Value - samples[i-1].Value
+		if diff < 0 {
+			// Counter reset
+			diff = samples[i].Value
+		}
+		increase += diff
+	}
+	return increase, true
+}
+
+// Rate returns the per-second increase of a counter over all samples.
+// Time is taken from the librdkafka clock if available.
+// Fails with less than 2 samples or without time passing.
+func Rate(samples []Sample) (float64, bool) {
+	increase, ok := Increase(samples)
+	if !ok {
+		return 0, false
+	}
+	seconds := Duration(samples).Seconds()
+	if seconds <= 0 {
+		return 0, false
+	}
+	return float64(increase) / seconds, true
+}
+
+// Duration returns the time between the oldest and the newest sample.
+// Time is taken from the librdkafka clock if available.
+func Duration(samples []Sample) time.Duration {
+	if len(samples) < 2 {
+		return 0
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	if first.Ts > 0 && last.Ts > first.Ts {
+		return time.Duration(last.Ts-first.Ts) * time.Microsecond
+	}
+	return last.Time.Sub(first.Time)
+}
+
+// Growing reports whether each sample is larger than the previous one
+// (e.g. for consumer lag, which keeps growing).
+// False with less than 2 samples.
+func Growing(samples []Sample) bool {
+	if len(samples) < 2 {
+		return false
+	}
+	for i := 1; i < len(samples); i++ {
+		if samples[i].Value <= samples[i-1].Value {
+			return false
+		}
+	}
+	return true
+}
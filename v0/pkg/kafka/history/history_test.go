@@ -0,0 +1,89 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+)
+
+func stats(round int) *typed.Stats {
+	return &typed.Stats{
+		Ts: round * 1000000,
+		Rx: round * 10,
+		Topics: map[typed.TopicName]typed.TopicStats{
+			"test": {
+				Topic: "test",
+				Partitions: map[typed.PartitionId]typed.PartitionStats{
+					0: {Partition: 0, ConsumerLag: round},
+				},
+			},
+		},
+	}
+}
+
+func TestRingBuffer(t *testing.T) {
+	h := New(3)
+	start := time.Unix(0, 0)
+	for round := 1; round <= 5; round++ {
+		h.Add(stats(round), start.Add(time.Duration(round)*time.Second))
+	}
+	snapshots := h.Snapshots()
+	if len(snapshots) != 3 {
+		t.Fatalf("Expected 3 snapshots. Got: %d", len(snapshots))
+	}
+	for i, s := range snapshots {
+		if s.Stats.Rx != (i+3)*10 {
+			t.Fatalf("Snapshot %d is out of order: %d", i, s.Stats.Rx)
+		}
+	}
+	since := h.Since(start.Add(4 * time.Second))
+	if len(since) != 2 {
+		t.Fatalf("Expected 2 snapshots since 4s. Got: %d", len(since))
+	}
+}
+
+func TestSnapshotIsCopy(t *testing.T) {
+	h := New(1)
+	s := stats(1)
+	h.Add(s, time.Now())
+	s.Topics["test"].Partitions[0] = typed.PartitionStats{ConsumerLag: 100}
+	lag := h.Snapshots()[0].Stats.Topics["test"].Partitions[0].ConsumerLag
+	if lag != 1 {
+		t.Fatalf("Snapshot got modified. Lag: %d", lag)
+	}
+}
+
+func TestRates(t *testing.T) {
+	h := New(10)
+	for round := 1; round <= 4; round++ {
+		h.Add(stats(round), time.Now())
+	}
+	// Client got recreated
+	h.Add(stats(1), time.Now())
+
+	rx := Root(h.Snapshots(), func(s *typed.Stats) int { return s.Rx })
+	increase, ok := Increase(rx)
+	if !ok || increase != 40 {
+		t.Fatalf("Unexpected increase %d, %t", increase, ok)
+	}
+	delta, ok := Delta(rx)
+	if !ok || delta != 0 {
+		t.Fatalf("Unexpected delta %d, %t", delta, ok)
+	}
+
+	lag := Partition(h.Snapshots()[:4], "test", 0, func(ps *typed.PartitionStats) int { return ps.ConsumerLag })
+	if !Growing(lag) {
+		t.Fatal("Expected lag to be growing")
+	}
+	rate, ok := Rate(lag)
+	if !ok || rate != 1 {
+		t.Fatalf("Unexpected rate %f, %t", rate, ok)
+	}
+
+	missing := Partition(h.Snapshots(), "test", 1, func(ps *typed.PartitionStats) int { return ps.ConsumerLag })
+	_, ok = Rate(missing)
+	if ok {
+		t.Fatal("Expected no rate for unknown partition")
+	}
+}
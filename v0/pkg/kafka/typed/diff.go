@@ -0,0 +1,129 @@
+package typed
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path of a value in Stats. Elements are JSON names of fields and map keys
+// (e.g. `topics.test.partitions.0.fetch_state`).
+type Path []string
+
+func (p Path) String() string {
+	return strings.Join(p, ".")
+}
+
+// Field returns the last element of the Path
+func (p Path) Field() string {
+	if len(p) == 0 {
+		return ""
+	}
+	return p[len(p)-1]
+}
+
+func (p Path) child(elems ...string) Path {
+	c := make(Path, 0, len(p)+len(elems))
+	c = append(c, p...)
+	return append(c, elems...)
+}
+
+// LabelChange is a field tagged with `kpromlbl` (e.g. a broker `state`)
+// having a different value
+type LabelChange struct {
+	Path Path
+	Old  string
+	New  string
+}
+
+// CounterDelta is the change of a field tagged as `CounterVec`
+type CounterDelta struct {
+	Path  Path
+	Delta int
+}
+
+// ChangeSet lists the differences between two Stats. Entries are sorted
+// by Path.
+type ChangeSet struct {
+	Added    []Path // Map entries only in the newer Stats
+	Removed  []Path // Map entries only in the older Stats
+	Labels   []LabelChange
+	Counters []CounterDelta
+}
+
+// Empty reports whether there are no changes
+func (cs *ChangeSet) Empty() bool {
+	return len(cs.Added) == 0 && len(cs.Removed) == 0 && len(cs.Labels) == 0 && len(cs.Counters) == 0
+}
+
+// Diff compares Stats a with the newer Stats b. Only fields exported as
+// metrics are compared. Entries of maps present in both are compared
+// recursively.
+func Diff(a, b *Stats) *ChangeSet {
+	cs := &ChangeSet{}
+	diffStruct(cs, nil, reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem())
+	sort.Slice(cs.Added, func(i, j int) bool { return cs.Added[i].String() < cs.Added[j].String() })
+	sort.Slice(cs.Removed, func(i, j int) bool { return cs.Removed[i].String() < cs.Removed[j].String() })
+	sort.Slice(cs.Labels, func(i, j int) bool { return cs.Labels[i].Path.String() < cs.Labels[j].Path.String() })
+	sort.Slice(cs.Counters, func(i, j int) bool { return cs.Counters[i].Path.String() < cs.Counters[j].Path.String() })
+	return cs
+}
+
+func diffStruct(cs *ChangeSet, path Path, a, b reflect.Value) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		fa, fb := a.Field(i), b.Field(i)
+		switch {
+		case f.Tag.Get("kpromlbl") != "":
+			older, newer := valueString(fa), valueString(fb)
+			if older != newer {
+				cs.Labels = append(cs.Labels, LabelChange{
+					Path: path.child(name),
+					Old:  older,
+					New:  newer,
+				})
+			}
+		case strings.HasPrefix(f.Tag.Get("kpromcol"), "CounterVec"):
+			delta := int(fb.Int() - fa.Int())
+			if delta != 0 {
+				cs.Counters = append(cs.Counters, CounterDelta{
+					Path:  path.child(name),
+					Delta: delta,
+				})
+			}
+		case f.Tag.Get("kprommap") != "":
+			diffMap(cs, path.child(name), fa, fb)
+		case f.Tag.Get("kprompnt") != "":
+			diffStruct(cs, path.child(name), fa, fb)
+		}
+	}
+}
+
+func diffMap(cs *ChangeSet, path Path, a, b reflect.Value) {
+	iter := a.MapRange()
+	for iter.Next() {
+		key := valueString(iter.Key())
+		vb := b.MapIndex(iter.Key())
+		if !vb.IsValid() {
+			cs.Removed = append(cs.Removed, path.child(key))
+			continue
+		}
+		diffStruct(cs, path.child(key), iter.Value(), vb)
+	}
+	iter = b.MapRange()
+	for iter.Next() {
+		if !a.MapIndex(iter.Key()).IsValid() {
+			cs.Added = append(cs.Added, path.child(valueString(iter.Key())))
+		}
+	}
+}
+
+func valueString(v reflect.Value) string {
+	if v.CanInt() {
+		return strconv.FormatInt(v.Int(), 10)
+	}
+	return v.String()
+}
@@ -0,0 +1,56 @@
+package typed
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func readExample(t *testing.T) *Stats {
+	b, err := os.ReadFile("testdata/example.json")
+	if err != nil {
+		t.Fatal("Reading stats failed:", err)
+	}
+	stats := &Stats{}
+	err = json.Unmarshal(b, stats)
+	if err != nil {
+		t.Fatal("Unmarshal failed:", err)
+	}
+	return stats
+}
+
+func TestDiff(t *testing.T) {
+	a := readExample(t)
+	if cs := Diff(a, a); !cs.Empty() {
+		t.Fatalf("Expected no changes. Got: %#v", cs)
+	}
+
+	b := a.DeepCopy()
+	b.Tx += 5
+	bs := b.Brokers["localhost:9092/2"]
+	bs.State = "DOWN"
+	b.Brokers["localhost:9092/2"] = bs
+	b.Brokers["localhost:9095/5"] = BrokerStats{Name: "localhost:9095/5"}
+	delete(b.Topics["test"].Partitions, 1)
+	ps := b.Topics["test"].Partitions[0]
+	ps.FetchState = "stopped"
+	b.Topics["test"].Partitions[0] = ps
+
+	expected := &ChangeSet{
+		Added:   []Path{{"brokers", "localhost:9095/5"}},
+		Removed: []Path{{"topics", "test", "partitions", "1"}},
+		Labels: []LabelChange{
+			{Path: Path{"brokers", "localhost:9092/2", "state"}, Old: "UP", New: "DOWN"},
+			{Path: Path{"topics", "test", "partitions", "0", "fetch_state"}, Old: "none", New: "stopped"},
+		},
+		Counters: []CounterDelta{
+			{Path: Path{"tx"}, Delta: 5},
+		},
+	}
+	d := cmp.Diff(expected, Diff(a, &b))
+	if d != "" {
+		t.Fatal("Diff", d)
+	}
+}
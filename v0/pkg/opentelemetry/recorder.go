@@ -0,0 +1,114 @@
+// Package opentelemetry exports Stats as OpenTelemetry metrics instead of
+// Prometheus metrics.
+package opentelemetry
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Recorder observes the Stats of the last update via asynchronous
+// instruments. Counters are Float64ObservableCounters, gauges are
+// Float64ObservableGauges. Attributes are the labels of the Prometheus
+// metrics.
+type Recorder struct {
+	naming       *gen.GeneratedOptions
+	mu           sync.Mutex // Guards rec, since callbacks may run concurrently
+	rec          *gen.Recorder
+	instruments  map[string]metric.Float64Observable
+	registration metric.Registration
+	stats        atomic.Value // *typed.Stats
+}
+
+// NewRecorder creates instruments with meter for all metrics named by
+// naming (see `v0.MetricNaming`). Instruments are named like the Prometheus
+// metrics, without the `_total` suffix of counters.
+// Recorders of multiple clients may share a meter.
+func NewRecorder(meter metric.Meter, naming *gen.GeneratedOptions) (*Recorder, error) {
+	r := &Recorder{
+		naming:      naming,
+		rec:         naming.NewRecorder(),
+		instruments: map[string]metric.Float64Observable{},
+	}
+	var observables []metric.Observable
+	for _, d := range naming.Describe() {
+		var inst metric.Float64Observable
+		var err error
+		switch d.Type {
+		case "counter":
+			inst, err = meter.Float64ObservableCounter(strings.TrimSuffix(d.Name, "_total"), metric.WithDescription(d.Help))
+		default:
+			inst, err = meter.Float64ObservableGauge(d.Name, metric.WithDescription(d.Help))
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.instruments[d.Name] = inst
+		observables = append(observables, inst)
+	}
+	reg, err := meter.RegisterCallback(r.observe, observables...)
+	if err != nil {
+		return nil, err
+	}
+	r.registration = reg
+	return r, nil
+}
+
+// Update sets the Stats observed from now on
+func (r *Recorder) Update(stats *typed.Stats) {
+	r.stats.Store(stats)
+}
+
+// UpdateWithStatString decodes the JSON statistics of librdkafka and sets
+// them as the Stats observed from now on
+func (r *Recorder) UpdateWithStatString(stats string) error {
+	decoded := &typed.Stats{}
+	err := json.Unmarshal([]byte(stats), decoded)
+	if err != nil {
+		return err
+	}
+	r.Update(decoded)
+	return nil
+}
+
+// Close unregisters the instruments' callback. Nothing is observed
+// afterwards.
+func (r *Recorder) Close() error {
+	return r.registration.Unregister()
+}
+
+func (r *Recorder) observe(_ context.Context, o metric.Observer) error {
+	stats, _ := r.stats.Load().(*typed.Stats)
+	if stats == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rec.Record(stats, &observerSink{
+		instruments: r.instruments,
+		o:           o,
+	})
+	return nil
+}
+
+// observerSink observes recorded values with the matching instruments
+type observerSink struct {
+	instruments map[string]metric.Float64Observable
+	o           metric.Observer
+}
+
+func (s *observerSink) Record(desc *gen.MetricDesc, labels map[string]string, value float64) {
+	attrs := make([]attribute.KeyValue, 0, len(labels))
+	for k, v := range labels {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	s.o.ObserveFloat64(s.instruments[desc.Name], value, metric.WithAttributes(attrs...))
+}
@@ -0,0 +1,92 @@
+package opentelemetry
+
+import (
+	"context"
+	"testing"
+
+	v0 "github.com/abergmeier/kafka_stats_exporter/v0"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+const stats = `{
+	"name": "rdkafka#consumer-1",
+	"client_id": "rdkafka",
+	"type": "consumer",
+	"rx": 5,
+	"msg_cnt": 3,
+	"brokers": {
+		"localhost:9092/2": {"name": "localhost:9092/2", "nodeid": 2, "state": "UP", "rtt": {"p99": 42}}
+	}
+}`
+
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Metrics {
+	rm := metricdata.ResourceMetrics{}
+	err := reader.Collect(context.Background(), &rm)
+	if err != nil {
+		t.Fatal("Collect failed:", err)
+	}
+	ms := map[string]metricdata.Metrics{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			ms[m.Name] = m
+		}
+	}
+	return ms
+}
+
+func TestRecorder(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	naming, err := v0.MetricNaming(v0.WithNestedInMapEntries())
+	if err != nil {
+		t.Fatal("MetricNaming failed:", err)
+	}
+	r, err := NewRecorder(provider.Meter("kafka"), naming)
+	if err != nil {
+		t.Fatal("NewRecorder failed:", err)
+	}
+	if len(collect(t, reader)) != 0 {
+		t.Error("Expected no metrics before the first update")
+	}
+
+	err = r.UpdateWithStatString(stats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	ms := collect(t, reader)
+
+	rx, ok := ms["rx"].Data.(metricdata.Sum[float64])
+	if !ok || !rx.IsMonotonic || len(rx.DataPoints) != 1 || rx.DataPoints[0].Value != 5 {
+		t.Errorf("Unexpected counter rx: %#v", ms["rx"])
+	}
+	if ms["rx"].Description != "Total number of responses received from Kafka brokers" {
+		t.Error("Unexpected description", ms["rx"].Description)
+	}
+	expected := attribute.NewSet(
+		attribute.String("client_id", "rdkafka"),
+		attribute.String("name", "rdkafka#consumer-1"),
+		attribute.String("type", "consumer"),
+	)
+	if !rx.DataPoints[0].Attributes.Equals(&expected) {
+		t.Error("Unexpected attributes", rx.DataPoints[0].Attributes.Encoded(attribute.DefaultEncoder()))
+	}
+
+	rtt, ok := ms["brokers_rtt_p_99"].Data.(metricdata.Gauge[float64])
+	if !ok || len(rtt.DataPoints) != 1 || rtt.DataPoints[0].Value != 42 {
+		t.Fatalf("Unexpected gauge brokers_rtt_p_99: %#v", ms["brokers_rtt_p_99"])
+	}
+	state, _ := rtt.DataPoints[0].Attributes.Value("brokers_state")
+	if state.AsString() != "UP" {
+		t.Error("Unexpected broker state", state.AsString())
+	}
+
+	err = r.Close()
+	if err != nil {
+		t.Fatal("Close failed:", err)
+	}
+	if len(collect(t, reader)) != 0 {
+		t.Error("Expected no metrics after Close")
+	}
+}
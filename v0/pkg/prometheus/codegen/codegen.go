@@ -0,0 +1,1087 @@
+// Package codegen generates static prometheus Collectors for types tagged
+// with `kpromcol`, `kpromlbl`, `kprommap` and `kprompnt`.
+// Generated Collectors export the same metrics as
+// gen.NewRecursiveMetricsFromTags without using reflection on update.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/abergmeier/kafka_stats_exporter/internal/collector"
+)
+
+// Generate writes the Go source of package `pkg` containing a Collector
+// for the tagged struct type `t`.
+// The Collector is named after the type (e.g. `StatsCollector` for `Stats`)
+// and gets created via `New<Type>Collector(opts ...gen.RecursiveMetricsOption)`.
+func Generate(w io.Writer, pkg string, t reflect.Type) error {
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("type `%s` is not a struct", t)
+	}
+	g := &generator{
+		base:    lowerFirst(t.Name()),
+		imports: map[string]string{},
+	}
+	root, err := g.makeNode(t, "", t.Name(), "", false)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	g.buf = &body
+	g.writeRoot(t, root)
+	g.writeNode(root)
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by codegen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	std := []string{"reflect"}
+	if g.usesStrconv {
+		std = append(std, "strconv")
+	}
+	imports := []string{
+		"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen",
+		"github.com/prometheus/client_golang/prometheus",
+	}
+	for p := range g.imports {
+		imports = append(imports, p)
+	}
+	sort.Strings(std)
+	sort.Strings(imports)
+	for _, p := range std {
+		fmt.Fprintf(&src, "\t%q\n", p)
+	}
+	src.WriteString("\n")
+	for _, p := range imports {
+		fmt.Fprintf(&src, "\t%q\n", p)
+	}
+	src.WriteString(")\n\n")
+	src.Write(body.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting generated source failed: %w", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+type generator struct {
+	buf         *bytes.Buffer
+	base        string
+	imports     map[string]string // package path to name
+	usesStrconv bool
+}
+
+// node is a struct type at a metric prefix
+type node struct {
+	t       reflect.Type
+	prefix  string
+	path    string // Go path (e.g. `Stats.Brokers[]`)
+	ident   string
+	entry   bool // Value of a map
+	inEntry bool // Value of a map or nested inside of one
+	labels  []labelField
+	metrics []metricField
+	scalars []scalarField
+	nested  []nestedField
+	maps    []mapField
+}
+
+type labelField struct {
+	field string
+	tag   string
+	value string // Go expression for the label value of `v`
+	equal string // Go format for comparing the label value of `v` to %s
+}
+
+type metricField struct {
+	field      string
+	metricType string
+	help       string
+	agg        collector.Aggregation
+}
+
+// scalarField is decoded from JSON on streaming updates
+type scalarField struct {
+	field    string
+	json     string
+	decode   string // gen function decoding the raw value
+	typeExpr string
+}
+
+type nestedField struct {
+	field string
+	json  string
+	node  *node
+	// optional is set inside of map entries, where nested structs are only
+	// exported via gen.WithNestedInMapEntries
+	optional bool
+}
+
+type mapField struct {
+	field        string
+	json         string
+	prefix       string
+	path         string
+	tagAggregate bool
+	keyType      string
+	intKey       bool
+	node         *node
+	aggregated   []metricField
+}
+
+func (g *generator) makeNode(t reflect.Type, prefix, path, ident string, inEntry bool) (*node, error) {
+	n := &node{
+		t:       t,
+		prefix:  prefix,
+		path:    path,
+		ident:   ident,
+		inEntry: inEntry,
+	}
+	for _, f := range reflect.VisibleFields(t) {
+		jsonName := jsonFieldName(f)
+		if jsonName != "" && f.Tag.Get("kprommap") == "" && f.Tag.Get("kprompnt") == "" {
+			scalar, err := g.makeScalar(f, jsonName)
+			if err != nil {
+				return nil, err
+			}
+			if scalar != nil {
+				n.scalars = append(n.scalars, *scalar)
+			}
+		}
+		tag := f.Tag.Get("kpromlbl")
+		if tag != "" {
+			value, equal, err := g.labelValue(f)
+			if err != nil {
+				return nil, err
+			}
+			n.labels = append(n.labels, labelField{
+				field: f.Name,
+				tag:   tag,
+				value: value,
+				equal: equal,
+			})
+		}
+		tag = f.Tag.Get("kpromcol")
+		if tag != "" {
+			m, err := makeMetric(f, tag)
+			if err != nil {
+				return nil, err
+			}
+			if m != nil {
+				n.metrics = append(n.metrics, *m)
+			}
+			continue
+		}
+		tag = f.Tag.Get("kprommap")
+		if tag != "" {
+			if f.Type.Kind() != reflect.Map || f.Type.Elem().Kind() != reflect.Struct {
+				return nil, fmt.Errorf("field `%s.%s` tagged with kprommap is not a map of structs", t.Name(), f.Name)
+			}
+			name, aggregate := collector.ParseMapTag(tag)
+			keyType, err := g.typeExpr(f.Type.Key())
+			if err != nil {
+				return nil, err
+			}
+			child, err := g.makeNode(f.Type.Elem(), collector.JoinPrefix(prefix, name), path+"."+f.Name+"[]", ident+f.Name, true)
+			if err != nil {
+				return nil, err
+			}
+			child.entry = true
+			m := mapField{
+				field:        f.Name,
+				json:         jsonName,
+				prefix:       child.prefix,
+				path:         path + "." + f.Name,
+				tagAggregate: aggregate,
+				keyType:      keyType,
+				node:         child,
+			}
+			switch f.Type.Key().Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				m.intKey = true
+			}
+			for _, cm := range child.metrics {
+				if cm.agg != collector.AggregateNone {
+					cm.metricType = collector.AggregatedMetricType(cm.metricType, cm.agg)
+					m.aggregated = append(m.aggregated, cm)
+				}
+			}
+			n.maps = append(n.maps, m)
+			continue
+		}
+		tag = f.Tag.Get("kprompnt")
+		if tag != "" {
+			if f.Type.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("field `%s.%s` tagged with kprompnt is not a struct", t.Name(), f.Name)
+			}
+			child, err := g.makeNode(f.Type, collector.JoinPrefix(prefix, tag), path+"."+f.Name, ident+f.Name, inEntry)
+			if err != nil {
+				return nil, err
+			}
+			n.nested = append(n.nested, nestedField{
+				field:    f.Name,
+				json:     jsonName,
+				node:     child,
+				optional: inEntry,
+			})
+		}
+	}
+	return n, nil
+}
+
+func makeMetric(f reflect.StructField, tag string) (*metricField, error) {
+	metricType, help, _ := collector.ParseColTag(tag)
+	switch metricType {
+	case "CounterVec", "GaugeVec":
+	case "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported prometheus Metric `%s` of field `%s`", metricType, f.Name)
+	}
+	switch f.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	default:
+		return nil, fmt.Errorf("field `%s` of type `%s` is not an integer", f.Name, f.Type)
+	}
+	agg := collector.ParseAggregation(f.Tag.Get("kpromagg"))
+	return &metricField{
+		field:      f.Name,
+		metricType: metricType,
+		help:       help,
+		agg:        agg,
+	}, nil
+}
+
+// jsonFieldName returns the name of `f` in JSON or "" if it is not decoded
+func jsonFieldName(f reflect.StructField) string {
+	if !f.IsExported() || f.Anonymous {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return name
+}
+
+// makeScalar returns nil for fields, which are neither integers, strings
+// nor booleans
+func (g *generator) makeScalar(f reflect.StructField, jsonName string) (*scalarField, error) {
+	var decode string
+	switch f.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		decode = "JSONInt"
+	case reflect.String:
+		decode = "JSONString"
+	case reflect.Bool:
+		decode = "JSONBool"
+	default:
+		return nil, nil
+	}
+	typeExpr, err := g.typeExpr(f.Type)
+	if err != nil {
+		return nil, err
+	}
+	return &scalarField{
+		field:    f.Name,
+		json:     jsonName,
+		decode:   decode,
+		typeExpr: typeExpr,
+	}, nil
+}
+
+func (g *generator) labelValue(f reflect.StructField) (string, string, error) {
+	switch f.Type.Kind() {
+	case reflect.String:
+		value := "v." + f.Name
+		if f.Type != reflect.TypeOf("") {
+			value = "string(v." + f.Name + ")"
+		}
+		return value, "%s == " + value, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		g.usesStrconv = true
+		return "strconv.FormatInt(int64(v." + f.Name + "), 10)", "gen.EqualIntLabel(%s, int64(v." + f.Name + "))", nil
+	default:
+		return "", "", fmt.Errorf("label field `%s` of type `%s` is neither a string nor an integer", f.Name, f.Type)
+	}
+}
+
+// typeExpr returns the Go expression for `t` and records needed imports
+func (g *generator) typeExpr(t reflect.Type) (string, error) {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name(), nil
+		}
+		name := path.Base(t.PkgPath())
+		for p, n := range g.imports {
+			if n == name && p != t.PkgPath() {
+				return "", fmt.Errorf("packages `%s` and `%s` have the same name", p, t.PkgPath())
+			}
+		}
+		g.imports[t.PkgPath()] = name
+		return name + "." + t.Name(), nil
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem, err := g.typeExpr(t.Elem())
+		return "*" + elem, err
+	case reflect.Map:
+		key, err := g.typeExpr(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.typeExpr(t.Elem())
+		return "map[" + key + "]" + elem, err
+	default:
+		return "", fmt.Errorf("unsupported type `%s`", t)
+	}
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(g.buf, format, args...)
+}
+
+func (g *generator) metricsType(n *node) string {
+	return g.base + "Metrics" + n.ident
+}
+
+func (g *generator) stateType(n *node) string {
+	return g.base + "State" + n.ident
+}
+
+func (g *generator) rawType(n *node) string {
+	return g.base + "Raw" + n.ident
+}
+
+func (g *generator) writeRoot(t reflect.Type, root *node) {
+	typeName, _ := g.typeExpr(t)
+	name := upperFirst(t.Name()) + "Collector"
+	g.printf(`// %[1]s is a prometheus.Collector for %[2]s
+type %[1]s struct {
+	o      *gen.GeneratedOptions
+	m      *%[3]s
+	s      %[4]s
+	owners *gen.SeriesOwners // Shared by all Collectors sharing m
+}
+
+// New%[1]s creates the metrics for %[2]s.
+// Fails if metrics cannot be built with the given options.
+func New%[1]s(opts ...gen.RecursiveMetricsOption) (*%[1]s, error) {
+	o, err := gen.NewGeneratedOptions(reflect.TypeOf(%[2]s{}), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &%[1]s{
+		o:      o,
+		m:      new%[5]s(o, nil),
+		owners: &gen.SeriesOwners{},
+	}, nil
+}
+
+// Share creates a %[1]s updating the metrics of c with the state, filters
+// and derived metrics of other (e.g. for another client exported via the
+// same Registerer). Both can be updated concurrently as long as the labels
+// of their values differ.
+// Fails with gen.ErrIncompatibleOptions if other was created with
+// options creating different metrics (see gen.GeneratedOptions.Equal).
+func (c *%[1]s) Share(other *%[1]s) (*%[1]s, error) {
+	if !c.o.Equal(other.o) {
+		return nil, gen.ErrIncompatibleOptions
+	}
+	return &%[1]s{
+		o:      other.o,
+		m:      c.m.share(other.o),
+		owners: c.owners,
+	}, nil
+}
+
+// Delete removes all series updated by c and forgets their values.
+// Series, which got updated by another sharing %[1]s since (e.g. of a
+// recreated client), are kept.
+// Must not be called concurrently with updates.
+func (c *%[1]s) Delete() {
+	if c.owners.Release(c) {
+		c.s.delete(c.m)
+	}
+	c.s = %[4]s{}
+}
+
+// Describe implements prometheus.Collector
+func (c *%[1]s) Describe(ch chan<- *prometheus.Desc) {
+	c.m.describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *%[1]s) Collect(ch chan<- prometheus.Metric) {
+	c.m.collect(ch)
+}
+
+// Update updates all metrics with the values of v.
+// Must not be called concurrently.
+func (c *%[1]s) Update(v *%[2]s) {
+	c.s.update(c.m, v, nil)
+	c.owners.Own(c.s.labels, c)
+}
+
+// UpdateJSON updates all metrics with the JSON encoded statistics in data
+// without decoding them into a %[2]s. Values are looked up by the JSON
+// names of the tagged fields and all of them get decoded before writing
+// any into the metrics. Entries of maps with filters are decoded completely
+// for passing them to the filters. Derived metrics are not exported, as
+// they need complete values.
+// Fails without updating if data is not valid JSON or a value does not
+// match the type of its field.
+// Must not be called concurrently.
+func (c *%[1]s) UpdateJSON(data string) error {
+	if !gen.ValidJSON(data) {
+		return gen.ErrJSONSyntax
+	}
+	var r %[6]s
+	err := r.decode(data)
+	if err != nil {
+		return err
+	}
+	err = r.filter(c.m)
+	if err != nil {
+		return err
+	}
+	c.s.stream(c.m, &r, nil)
+	c.owners.Own(c.s.labels, c)
+	return nil
+}
+
+// Count returns the number of exported series. Adds the number of tracked
+// entries of each map to entries (keyed by metric prefix, e.g.
+// "topics_partitions"). Aggregated maps do not track entries.
+// Must not be called concurrently with updates.
+func (c *%[1]s) Count(entries map[string]int) int {
+	return c.s.count(c.m, entries)
+}
+
+`, name, typeName, g.metricsType(root), g.stateType(root), upperFirst(g.metricsType(root)), g.rawType(root))
+}
+
+func (g *generator) writeNode(n *node) {
+	typeName, _ := g.typeExpr(n.t)
+	mt := g.metricsType(n)
+	st := g.stateType(n)
+	counters := 0
+	for _, m := range n.metrics {
+		if m.metricType == "CounterVec" {
+			counters++
+		}
+	}
+
+	// Metrics shared by all values at this prefix
+	g.printf("type %s struct {\n\tlabelNames []string\n", mt)
+	for _, l := range n.labels {
+		g.printf("\tl%s string\n", l.field)
+	}
+	for _, m := range n.metrics {
+		g.printf("\tm%s *prometheus.%s\n", m.field, m.metricType)
+	}
+	g.printf("\tderived []gen.GeneratedDerived\n")
+	for _, nf := range n.nested {
+		g.printf("\tn%s *%s\n", nf.field, g.metricsType(nf.node))
+	}
+	for _, mf := range n.maps {
+		valueType, _ := g.typeExpr(mf.node.t)
+		g.printf("\te%s *%s // nil if aggregated\n\tf%s func(key %s, value %s) bool\n", mf.field, g.metricsType(mf.node), mf.field, mf.keyType, valueType)
+		for _, am := range mf.aggregated {
+			g.printf("\ta%s%s *prometheus.%s\n", mf.field, am.field, am.metricType)
+		}
+	}
+	g.printf("}\n\n")
+
+	g.printf("func new%s(o *gen.GeneratedOptions, parentLabelNames []string) *%s {\n", upperFirst(mt), mt)
+	g.printf("\tm := &%s{}\n", mt)
+	g.printf("\tm.labelNames = append(m.labelNames, parentLabelNames...)\n")
+	for _, l := range n.labels {
+		g.printf("\tm.l%s = o.LabelName(%q, %q)\n", l.field, n.prefix, l.tag)
+		g.printf("\tm.labelNames = append(m.labelNames, m.l%s)\n", l.field)
+	}
+	for _, m := range n.metrics {
+		g.printf("\tm.m%s = o.New%s(%q, %q, %s, m.labelNames)\n", m.field, m.metricType, n.prefix, m.field, strconv.Quote(m.help))
+	}
+	g.printf("\tm.derived = o.NewDerived(%q, %q, reflect.TypeOf(%s{}), m.labelNames)\n", n.prefix, n.path, typeName)
+	for _, nf := range n.nested {
+		if nf.optional {
+			g.printf("\tif o.NestedInMapEntries() {\n\t\tm.n%s = new%s(o, m.labelNames)\n\t}\n", nf.field, upperFirst(g.metricsType(nf.node)))
+		} else {
+			g.printf("\tm.n%s = new%s(o, m.labelNames)\n", nf.field, upperFirst(g.metricsType(nf.node)))
+		}
+	}
+	for _, mf := range n.maps {
+		valueType, _ := g.typeExpr(mf.node.t)
+		g.printf("\tm.f%s = gen.MapEntryFilterFunc[%s, %s](o, %q)\n", mf.field, mf.keyType, valueType, mf.path)
+		if mf.tagAggregate {
+			g.printf("\t{\n")
+		} else {
+			g.printf("\tif o.Aggregate(%q) {\n", mf.path)
+		}
+		for _, am := range mf.aggregated {
+			g.printf("\t\tm.a%s%s = o.New%s(%q, %q, %s, m.labelNames)\n", mf.field, am.field, am.metricType, n.prefix, am.field, strconv.Quote(am.help))
+		}
+		if !mf.tagAggregate {
+			g.printf("\t} else {\n\t\tm.e%s = new%s(o, m.labelNames)\n", mf.field, upperFirst(g.metricsType(mf.node)))
+		}
+		g.printf("\t}\n")
+	}
+	g.printf("\treturn m\n}\n\n")
+
+	g.printf("// share returns a copy of m computing filters and derived metrics with\n// the functions of o\n")
+	g.printf("func (m *%s) share(o *gen.GeneratedOptions) *%s {\n\tc := *m\n", mt, mt)
+	g.printf("\tc.derived = o.ShareDerived(m.derived, %q)\n", n.path)
+	for _, nf := range n.nested {
+		if nf.optional {
+			g.printf("\tif m.n%s != nil {\n\t\tc.n%s = m.n%s.share(o)\n\t}\n", nf.field, nf.field, nf.field)
+		} else {
+			g.printf("\tc.n%s = m.n%s.share(o)\n", nf.field, nf.field)
+		}
+	}
+	for _, mf := range n.maps {
+		valueType, _ := g.typeExpr(mf.node.t)
+		g.printf("\tc.f%s = gen.MapEntryFilterFunc[%s, %s](o, %q)\n", mf.field, mf.keyType, valueType, mf.path)
+		g.printf("\tif m.e%s != nil {\n\t\tc.e%s = m.e%s.share(o)\n\t}\n", mf.field, mf.field, mf.field)
+	}
+	g.printf("\treturn &c\n}\n\n")
+
+	for _, method := range []struct{ name, arg string }{
+		{"describe", "ch chan<- *prometheus.Desc"},
+		{"collect", "ch chan<- prometheus.Metric"},
+	} {
+		call := upperFirst(method.name)
+		g.printf("func (m *%s) %s(%s) {\n", mt, method.name, method.arg)
+		for _, m := range n.metrics {
+			g.printf("\tm.m%s.%s(ch)\n", m.field, call)
+		}
+		g.printf("\tfor i := range m.derived {\n\t\tm.derived[i].Vec.%s(ch)\n\t}\n", call)
+		for _, nf := range n.nested {
+			g.writeNestedCall(nf, "m.n%s.%s(ch)", nf.field, method.name)
+		}
+		for _, mf := range n.maps {
+			g.printf("\tif m.e%s != nil {\n\t\tm.e%s.%s(ch)\n\t} else {\n", mf.field, mf.field, method.name)
+			for _, am := range mf.aggregated {
+				g.printf("\t\tm.a%s%s.%s(ch)\n", mf.field, am.field, call)
+			}
+			g.printf("\t}\n")
+		}
+		g.printf("}\n\n")
+	}
+
+	g.printf("// delete removes the series with labels ls of this prefix\n")
+	g.printf("func (m *%s) delete(ls prometheus.Labels) {\n", mt)
+	for _, m := range n.metrics {
+		g.printf("\tm.m%s.Delete(ls)\n", m.field)
+	}
+	g.printf("\tfor i := range m.derived {\n\t\tm.derived[i].Vec.Delete(ls)\n\t}\n")
+	for _, mf := range n.maps {
+		if len(mf.aggregated) == 0 {
+			continue
+		}
+		g.printf("\tif m.e%s == nil {\n", mf.field)
+		for _, am := range mf.aggregated {
+			g.printf("\t\tm.a%s%s.Delete(ls)\n", mf.field, am.field)
+		}
+		g.printf("\t}\n")
+	}
+	g.printf("}\n\n")
+
+	// State of a single value at this prefix
+	g.printf("type %s struct {\n\tlabels prometheus.Labels\n", st)
+	if n.entry {
+		g.printf("\tepoch  uint64 // Of last streaming update\n")
+	}
+	gauges := len(n.metrics) - counters
+	if counters != 0 {
+		g.printf("\tlast [%d]int64\n", counters)
+	}
+	g.printf("\t// Children of the metrics bound to labels\n")
+	if counters != 0 {
+		g.printf("\tcounters [%d]prometheus.Counter\n", counters)
+	}
+	if gauges != 0 {
+		g.printf("\tgauges [%d]prometheus.Gauge\n", gauges)
+	}
+	g.printf("\tderived []prometheus.Gauge\n")
+	for _, nf := range n.nested {
+		g.printf("\tn%s %s\n", nf.field, g.stateType(nf.node))
+	}
+	for _, mf := range n.maps {
+		valueType, _ := g.typeExpr(mf.node.t)
+		g.printf("\te%s map[%s]*%s\n\tg%s uint64 // Epoch of streaming updates\n", mf.field, mf.keyType, g.stateType(mf.node), mf.field)
+		g.printf("\tv%s %s // Entry being updated, held here to not allocate per entry\n", mf.field, valueType)
+		if c := aggregatedCounters(mf); c != 0 {
+			g.printf("\tla%s map[%s]%s // Last values of aggregated counters by entry\n\tac%s [%d]prometheus.Counter\n", mf.field, mf.keyType, g.lastType(mf), mf.field, c)
+		}
+		if c := len(mf.aggregated) - aggregatedCounters(mf); c != 0 {
+			g.printf("\tag%s [%d]prometheus.Gauge\n", mf.field, c)
+		}
+	}
+	g.printf("}\n\n")
+	for _, mf := range n.maps {
+		if c := aggregatedCounters(mf); c != 0 {
+			g.printf("type %s struct {\n\tepoch  uint64 // Epoch of the last update of the entry\n\tvalues [%d]int64\n}\n\n", g.lastType(mf), c)
+		}
+	}
+
+	g.printf("// equalLabels reports whether the labels of v and parent did not change\n")
+	g.printf("func (s *%s) equalLabels(m *%s, v *%s, parent prometheus.Labels) bool {\n", st, mt, typeName)
+	g.printf("\tif s.labels == nil || len(s.labels) != len(parent)+%d || !gen.ContainsLabels(s.labels, parent) {\n\t\treturn false\n\t}\n", len(n.labels))
+	g.printf("\treturn true")
+	for _, l := range n.labels {
+		g.printf(" &&\n\t\t"+l.equal, "s.labels[m.l"+l.field+"]")
+	}
+	g.printf("\n}\n\n")
+
+	g.printf("// values updates the metrics of this prefix and returns the labels of v\n")
+	g.printf("func (s *%s) values(m *%s, v *%s, parent prometheus.Labels) prometheus.Labels {\n", st, mt, typeName)
+	g.printf("\tif !s.equalLabels(m, v, parent) {\n")
+	g.printf("\t\tls := make(prometheus.Labels, len(parent)+%d)\n", len(n.labels))
+	g.printf("\t\tfor k, lv := range parent {\n\t\t\tls[k] = lv\n\t\t}\n")
+	for _, l := range n.labels {
+		g.printf("\t\tls[m.l%s] = %s\n", l.field, l.value)
+	}
+	g.printf("\t\t// Series of outdated labels are dropped\n")
+	g.printf("\t\tif s.labels != nil {\n\t\t\tm.delete(s.labels)\n\t\t}\n\t\ts.labels = gen.CloneLabels(ls)\n")
+	if counters != 0 {
+		g.printf("\t\ts.last = [%d]int64{}\n", counters)
+	}
+	counter, gauge := 0, 0
+	for _, m := range n.metrics {
+		switch m.metricType {
+		case "CounterVec":
+			g.printf("\t\ts.counters[%d] = m.m%s.With(s.labels)\n", counter, m.field)
+			counter++
+		case "GaugeVec":
+			g.printf("\t\ts.gauges[%d] = m.m%s.With(s.labels)\n", gauge, m.field)
+			gauge++
+		}
+	}
+	g.printf("\t\ts.derived = nil\n")
+	for _, mf := range n.maps {
+		if len(mf.aggregated) == 0 {
+			continue
+		}
+		if aggregatedCounters(mf) != 0 {
+			g.printf("\t\ts.la%s = nil\n", mf.field)
+		}
+		g.printf("\t\tif m.e%s == nil {\n", mf.field)
+		counter, gauge := 0, 0
+		for _, am := range mf.aggregated {
+			switch am.metricType {
+			case "CounterVec":
+				g.printf("\t\t\ts.ac%s[%d] = m.a%s%s.With(s.labels)\n", mf.field, counter, mf.field, am.field)
+				counter++
+			case "GaugeVec":
+				g.printf("\t\t\ts.ag%s[%d] = m.a%s%s.With(s.labels)\n", mf.field, gauge, mf.field, am.field)
+				gauge++
+			}
+		}
+		g.printf("\t\t}\n")
+	}
+	g.printf("\t}\n")
+	counter, gauge = 0, 0
+	for _, m := range n.metrics {
+		switch m.metricType {
+		case "CounterVec":
+			g.printf("\ts.last[%d] = gen.AddToCounter(s.counters[%d], s.last[%d], int64(v.%s))\n", counter, counter, counter, m.field)
+			counter++
+		case "GaugeVec":
+			g.printf("\ts.gauges[%d].Set(float64(v.%s))\n", gauge, m.field)
+			gauge++
+		}
+	}
+	g.printf("\treturn s.labels\n}\n\n")
+
+	g.printf("// derive updates the derived metrics with the complete value v. Their\n// series are created on the first call after a change of labels.\n")
+	g.printf("func (s *%s) derive(m *%s, v *%s) {\n", st, mt, typeName)
+	g.printf("\tif s.derived == nil && len(m.derived) != 0 {\n\t\ts.derived = make([]prometheus.Gauge, len(m.derived))\n")
+	g.printf("\t\tfor i := range m.derived {\n\t\t\ts.derived[i] = m.derived[i].Vec.With(s.labels)\n\t\t}\n\t}\n")
+	g.printf("\tfor i, d := range s.derived {\n\t\td.Set(m.derived[i].Fun(v))\n\t}\n}\n\n")
+
+	g.printf("func (s *%s) update(m *%s, v *%s, parent prometheus.Labels) {\n", st, mt, typeName)
+	if len(n.nested) == 0 && len(n.maps) == 0 {
+		g.printf("\ts.values(m, v, parent)\n")
+	} else {
+		g.printf("\tls := s.values(m, v, parent)\n")
+	}
+	g.printf("\ts.derive(m, v)\n")
+	for _, nf := range n.nested {
+		g.writeNestedCall(nf, "s.n%s.update(m.n%s, &v.%s, ls)", nf.field, nf.field, nf.field)
+	}
+	for _, mf := range n.maps {
+		g.writeMapUpdate(mf)
+	}
+	g.printf("}\n\n")
+
+	g.writeStream(n)
+
+	g.printf("func (s *%s) count(m *%s, entries map[string]int) int {\n", st, mt)
+	g.printf("\tif s.labels == nil {\n\t\treturn 0\n\t}\n")
+	g.printf("\tseries := %d + len(s.derived)\n", len(n.metrics))
+	for _, nf := range n.nested {
+		g.writeNestedCall(nf, "series += s.n%s.count(m.n%s, entries)", nf.field, nf.field)
+	}
+	for _, mf := range n.maps {
+		g.printf("\tif m.e%s == nil {\n\t\tseries += %d\n\t} else {\n", mf.field, len(mf.aggregated))
+		g.printf("\t\tentries[%q] += len(s.e%s)\n", mf.prefix, mf.field)
+		g.printf("\t\tfor _, es := range s.e%s {\n\t\t\tseries += es.count(m.e%s, entries)\n\t\t}\n\t}\n", mf.field, mf.field)
+	}
+	g.printf("\treturn series\n}\n\n")
+
+	g.printf("// delete removes all series of this value and the values it contains\n")
+	g.printf("func (s *%s) delete(m *%s) {\n", st, mt)
+	g.printf("\tif s.labels != nil {\n\t\tm.delete(s.labels)\n\t}\n")
+	for _, nf := range n.nested {
+		g.writeNestedCall(nf, "s.n%s.delete(m.n%s)", nf.field, nf.field)
+	}
+	for _, mf := range n.maps {
+		g.printf("\tfor _, es := range s.e%s {\n\t\tes.delete(m.e%s)\n\t}\n", mf.field, mf.field)
+	}
+	g.printf("}\n\n")
+
+	for _, nf := range n.nested {
+		g.writeNode(nf.node)
+	}
+	for _, mf := range n.maps {
+		g.writeNode(mf.node)
+	}
+}
+
+// writeNestedCall writes the statement `format` for nested field nf.
+// Optional nested fields are skipped when their metrics are not created.
+func (g *generator) writeNestedCall(nf nestedField, format string, args ...interface{}) {
+	if nf.optional {
+		g.printf("\tif m.n%s != nil {\n\t", nf.field)
+	}
+	g.printf("\t"+format+"\n", args...)
+	if nf.optional {
+		g.printf("\t}\n")
+	}
+}
+
+func (g *generator) writeMapUpdate(mf mapField) {
+	f := mf.field
+	g.printf("\tif m.e%s == nil {\n", f)
+	if len(mf.aggregated) != 0 {
+		g.writeAggregatedInit(mf)
+		g.printf("\t\tfor k, ev := range v.%s {\n", f)
+		skip := fmt.Sprintf("m.f%s != nil && !m.f%s(k, ev)", f, f)
+		if mf.intKey {
+			// Negative keys are librdkafka internal entries
+			skip = "k < 0 || " + skip
+		}
+		g.printf("\t\t\tif %s {\n\t\t\t\tcontinue\n\t\t\t}\n", skip)
+		g.writeAggregatedEntry(mf, "ev")
+		g.printf("\t\t}\n")
+		g.writeAggregatedSet(mf)
+	}
+	g.printf("\t} else {\n")
+	g.printf("\t\tif s.e%s == nil {\n\t\t\ts.e%s = map[%s]*%s{}\n\t\t}\n", f, f, mf.keyType, g.stateType(mf.node))
+	g.printf("\t\tfor k, es := range s.e%s {\n", f)
+	g.printf("\t\t\tev, ok := v.%s[k]\n", f)
+	g.printf("\t\t\tif !ok || (m.f%s != nil && !m.f%s(k, ev)) {\n", f, f)
+	g.printf("\t\t\t\tes.delete(m.e%s)\n\t\t\t\tdelete(s.e%s, k)\n\t\t\t}\n\t\t}\n", f, f)
+	g.printf("\t\tfor k, ev := range v.%s {\n", f)
+	g.printf("\t\t\tif m.f%s != nil && !m.f%s(k, ev) {\n\t\t\t\tcontinue\n\t\t\t}\n", f, f)
+	g.printf("\t\t\tes, ok := s.e%s[k]\n\t\t\tif !ok {\n\t\t\t\tes = &%s{}\n\t\t\t\ts.e%s[k] = es\n\t\t\t}\n", f, g.stateType(mf.node), f)
+	g.printf("\t\t\ts.v%s = ev\n\t\t\tes.update(m.e%s, &s.v%s, ls)\n\t\t}\n", f, f, f)
+	g.printf("\t}\n")
+}
+
+// writeStream writes the raw value of n, its decoding from JSON and the
+// streaming update of the state. Decoding converts all values, so a
+// malformed value fails before any metric gets updated.
+func (g *generator) writeStream(n *node) {
+	typeName, _ := g.typeExpr(n.t)
+	mt := g.metricsType(n)
+	st := g.stateType(n)
+	rt := g.rawType(n)
+
+	g.printf("// %s holds the decoded fields of a JSON value. Maps and nested\n// structs are held by the raw values they contain.\n", rt)
+	g.printf("type %s struct {\n\tv %s\n", rt, typeName)
+	for _, nf := range n.nested {
+		g.printf("\tn%s %s\n", nf.field, g.rawType(nf.node))
+	}
+	for _, mf := range n.maps {
+		g.printf("\te%s []%sEntry\n", mf.field, g.rawType(mf.node))
+	}
+	g.printf("}\n\n")
+	for _, mf := range n.maps {
+		g.printf("type %sEntry struct {\n\tk    %s\n\tr    %s\n\tdata string // Raw JSON of the entry\n\tskip bool   // Whether the entry got filtered\n}\n\n", g.rawType(mf.node), mf.keyType, g.rawType(mf.node))
+	}
+
+	g.printf("func (r *%s) decode(data string) error {\n", rt)
+	g.printf("\to := gen.NewJSONObject(data)\n\tfor o.Next() {\n")
+	if len(n.scalars) != 0 || len(n.nested) != 0 || len(n.maps) != 0 {
+		g.printf("\t\tvar err error\n")
+	}
+	g.printf("\t\tswitch o.Key() {\n")
+	for _, sf := range n.scalars {
+		var zero string
+		switch sf.decode {
+		case "JSONInt":
+			zero = "int64"
+		case "JSONString":
+			zero = "string"
+		case "JSONBool":
+			zero = "bool"
+		}
+		g.printf("\t\tcase %q:\n\t\t\tvar x %s\n\t\t\tx, err = gen.%s(o.Value())\n\t\t\tr.v.%s = %s(x)\n", sf.json, zero, sf.decode, sf.field, sf.typeExpr)
+	}
+	for _, nf := range n.nested {
+		g.printf("\t\tcase %q:\n\t\t\terr = r.n%s.decode(o.Value())\n", nf.json, nf.field)
+	}
+	for _, mf := range n.maps {
+		g.printf("\t\tcase %q:\n\t\t\terr = r.decode%s(o.Value())\n", mf.json, mf.field)
+	}
+	g.printf("\t\t}\n")
+	if len(n.scalars) != 0 || len(n.nested) != 0 || len(n.maps) != 0 {
+		g.printf("\t\tif err != nil {\n\t\t\treturn gen.JSONFieldError(o.Key(), err)\n\t\t}\n")
+	}
+	g.printf("\t}\n\treturn o.Err()\n}\n\n")
+
+	for _, mf := range n.maps {
+		g.writeMapDecode(rt, mf)
+	}
+	g.writeFilter(n)
+
+	g.printf("func (s *%s) stream(m *%s, r *%s, parent prometheus.Labels) {\n", st, mt, rt)
+	if len(n.nested) == 0 && len(n.maps) == 0 {
+		g.printf("\ts.values(m, &r.v, parent)\n")
+	} else {
+		g.printf("\tls := s.values(m, &r.v, parent)\n")
+	}
+	for _, nf := range n.nested {
+		g.writeNestedCall(nf, "s.n%s.stream(m.n%s, &r.n%s, ls)", nf.field, nf.field, nf.field)
+	}
+	for _, mf := range n.maps {
+		g.writeMapStream(mf)
+	}
+	g.printf("}\n\n")
+}
+
+// writeMapDecode writes decoding the entries of map `mf` into the raw
+// value of type `rt`
+func (g *generator) writeMapDecode(rt string, mf mapField) {
+	f := mf.field
+	g.printf("func (r *%s) decode%s(data string) error {\n", rt, f)
+	g.printf("\to := gen.NewJSONObject(data)\n\tfor o.Next() {\n")
+	if mf.intKey {
+		g.usesStrconv = true
+		g.printf("\t\tk, err := strconv.ParseInt(o.Key(), 10, 64)\n")
+		g.printf("\t\tif err != nil {\n\t\t\treturn gen.JSONFieldError(o.Key(), err)\n\t\t}\n")
+		g.printf("\t\tr.e%s = append(r.e%s, %sEntry{k: %s(k), data: o.Value()})\n", f, f, g.rawType(mf.node), mf.keyType)
+	} else {
+		g.printf("\t\tr.e%s = append(r.e%s, %sEntry{k: %s(o.Key()), data: o.Value()})\n", f, f, g.rawType(mf.node), mf.keyType)
+	}
+	assign := ":="
+	if mf.intKey {
+		assign = "="
+	}
+	g.printf("\t\terr %s r.e%s[len(r.e%s)-1].r.decode(o.Value())\n", assign, f, f)
+	g.printf("\t\tif err != nil {\n\t\t\treturn gen.JSONFieldError(o.Key(), err)\n\t\t}\n")
+	g.printf("\t}\n\treturn o.Err()\n}\n\n")
+}
+
+// writeFilter writes applying the filters of maps to the decoded entries
+// of n and the values it contains. Filters get passed the completely
+// decoded entries.
+func (g *generator) writeFilter(n *node) {
+	rt := g.rawType(n)
+	g.printf("// filter marks the entries of maps skipped by the filters of m. Fails\n// if an entry to filter cannot be decoded completely.\n")
+	g.printf("func (r *%s) filter(m *%s) error {\n", rt, g.metricsType(n))
+	if hasMaps(n) {
+		g.printf("\tvar err error\n")
+	}
+	for _, nf := range n.nested {
+		if !hasMaps(nf.node) {
+			continue
+		}
+		if nf.optional {
+			g.printf("\tif m.n%s != nil {\n\t\terr = r.n%s.filter(m.n%s)\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n\t}\n", nf.field, nf.field, nf.field)
+		} else {
+			g.printf("\terr = r.n%s.filter(m.n%s)\n\tif err != nil {\n\t\treturn err\n\t}\n", nf.field, nf.field)
+		}
+	}
+	for _, mf := range n.maps {
+		f := mf.field
+		valueType, _ := g.typeExpr(mf.node.t)
+		g.printf("\tfor i := range r.e%s {\n\t\te := &r.e%s[i]\n", f, f)
+		g.printf("\t\tif m.f%s != nil {\n\t\t\tvar v %s\n\t\t\terr = gen.DecodeJSON(e.data, &v)\n", f, valueType)
+		g.printf("\t\t\tif err != nil {\n\t\t\t\treturn gen.JSONFieldError(%q, err)\n\t\t\t}\n", mf.json)
+		g.printf("\t\t\te.skip = !m.f%s(e.k, v)\n\t\t}\n", f)
+		if hasMaps(mf.node) {
+			g.printf("\t\tif !e.skip && m.e%s != nil {\n\t\t\terr = e.r.filter(m.e%s)\n\t\t\tif err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\t}\n", f, f)
+		}
+		g.printf("\t}\n")
+	}
+	g.printf("\treturn nil\n}\n\n")
+}
+
+// hasMaps reports whether n or a struct it contains has maps
+func hasMaps(n *node) bool {
+	if len(n.maps) != 0 {
+		return true
+	}
+	for _, nf := range n.nested {
+		if hasMaps(nf.node) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMapStream writes the streaming update of map `mf` from its decoded
+// entries
+func (g *generator) writeMapStream(mf mapField) {
+	f := mf.field
+	entry := func(indent string, key bool) {
+		g.printf("%sfor i := range r.e%s {\n", indent, f)
+		g.printf("%s\tif r.e%s[i].skip {\n%s\t\tcontinue\n%s\t}\n", indent, f, indent, indent)
+		if key {
+			g.printf("%s\tk, er := r.e%s[i].k, &r.e%s[i].r\n", indent, f, f)
+		} else {
+			g.printf("%s\ter := &r.e%s[i].r\n", indent, f)
+		}
+	}
+
+	g.printf("\tif m.e%s == nil {\n", f)
+	if len(mf.aggregated) != 0 {
+		g.writeAggregatedInit(mf)
+		entry("\t\t", mf.intKey || aggregatedCounters(mf) != 0)
+		if mf.intKey {
+			// Negative keys are librdkafka internal entries
+			g.printf("\t\t\tif k < 0 {\n\t\t\t\tcontinue\n\t\t\t}\n")
+		}
+		g.writeAggregatedEntry(mf, "er.v")
+		g.printf("\t\t}\n")
+		g.writeAggregatedSet(mf)
+	}
+	g.printf("\t} else {\n")
+	g.printf("\t\tif s.e%s == nil {\n\t\t\ts.e%s = map[%s]*%s{}\n\t\t}\n", f, f, mf.keyType, g.stateType(mf.node))
+	g.printf("\t\t// Entries not updated in this epoch are gone\n\t\ts.g%s++\n", f)
+	entry("\t\t", true)
+	g.printf("\t\t\tes, ok := s.e%s[k]\n\t\t\tif !ok {\n\t\t\t\tes = &%s{}\n\t\t\t\ts.e%s[k] = es\n\t\t\t}\n", f, g.stateType(mf.node), f)
+	g.printf("\t\t\tes.epoch = s.g%s\n", f)
+	g.printf("\t\t\tes.stream(m.e%s, er, ls)\n", f)
+	g.printf("\t\t}\n")
+	g.printf("\t\tfor k, es := range s.e%s {\n\t\t\tif es.epoch != s.g%s {\n", f, f)
+	g.printf("\t\t\t\tes.delete(m.e%s)\n\t\t\t\tdelete(s.e%s, k)\n\t\t\t}\n\t\t}\n", f, f)
+	g.printf("\t}\n")
+}
+
+// writeAggregatedInit writes the declarations needed for aggregating the
+// entries of map `mf`
+func (g *generator) writeAggregatedInit(mf mapField) {
+	g.printf("\t\tvar agg [%d]int64\n", len(mf.aggregated))
+	for _, am := range mf.aggregated {
+		if am.agg == collector.AggregateMax {
+			g.printf("\t\tfirst := true\n")
+			break
+		}
+	}
+	if aggregatedCounters(mf) != 0 {
+		// Entries seen first after the first update only set their last
+		// values, as their counts before happened outside of the aggregate
+		g.printf("\t\tinitial := s.la%s == nil\n\t\tif initial {\n\t\t\ts.la%s = map[%s]%s{}\n\t\t}\n", mf.field, mf.field, mf.keyType, g.lastType(mf))
+		g.printf("\t\ts.g%s++\n", mf.field)
+	}
+}
+
+// writeAggregatedEntry writes adding the entry `ev` with key `k` to the
+// values in `agg`. Negative values are not summed. Counters add the
+// increase since the last value of the entry.
+func (g *generator) writeAggregatedEntry(mf mapField, ev string) {
+	f := mf.field
+	counters := aggregatedCounters(mf)
+	if counters != 0 {
+		g.printf("\t\t\tlast, known := s.la%s[k]\n\t\t\tlast.epoch = s.g%s\n", f, f)
+	}
+	hasMax := false
+	counter := 0
+	for i, am := range mf.aggregated {
+		switch {
+		case am.agg == collector.AggregateMax:
+			hasMax = true
+			g.printf("\t\t\tif first || int64(%s.%s) > agg[%d] {\n\t\t\t\tagg[%d] = int64(%s.%s)\n\t\t\t}\n", ev, am.field, i, i, ev, am.field)
+		case am.metricType == "CounterVec":
+			g.printf("\t\t\tif x := int64(%s.%s); x >= 0 {\n", ev, am.field)
+			g.printf("\t\t\t\tif (known || initial) && x > last.values[%d] {\n\t\t\t\t\tagg[%d] += x - last.values[%d]\n\t\t\t\t}\n\t\t\t\tlast.values[%d] = x\n\t\t\t}\n", counter, i, counter, counter)
+			counter++
+		default:
+			g.printf("\t\t\tif x := int64(%s.%s); x > 0 {\n\t\t\t\tagg[%d] += x\n\t\t\t}\n", ev, am.field, i)
+		}
+	}
+	if counters != 0 {
+		g.printf("\t\t\ts.la%s[k] = last\n", f)
+	}
+	if hasMax {
+		g.printf("\t\t\tfirst = false\n")
+	}
+}
+
+// writeAggregatedSet writes setting the aggregated metrics of map `mf` to
+// the values in `agg`. Counters get added their increase. Last values of
+// entries gone from the map are dropped.
+func (g *generator) writeAggregatedSet(mf mapField) {
+	f := mf.field
+	if aggregatedCounters(mf) != 0 {
+		g.printf("\t\tfor k, last := range s.la%s {\n\t\t\tif last.epoch != s.g%s {\n\t\t\t\tdelete(s.la%s, k)\n\t\t\t}\n\t\t}\n", f, f, f)
+	}
+	counter, gauge := 0, 0
+	for i, am := range mf.aggregated {
+		switch am.metricType {
+		case "CounterVec":
+			g.printf("\t\ts.ac%s[%d].Add(float64(agg[%d]))\n", f, counter, i)
+			counter++
+		case "GaugeVec":
+			g.printf("\t\ts.ag%s[%d].Set(float64(agg[%d]))\n", f, gauge, i)
+			gauge++
+		}
+	}
+}
+
+// lastType is the type of the last values of the aggregated counters of an
+// entry of map `mf`
+func (g *generator) lastType(mf mapField) string {
+	return g.stateType(mf.node) + "Last"
+}
+
+func aggregatedCounters(mf mapField) int {
+	c := 0
+	for _, am := range mf.aggregated {
+		if am.metricType == "CounterVec" {
+			c++
+		}
+	}
+	return c
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func upperFirst(s string) string {
+	for i, r := range s {
+		return string(unicode.ToUpper(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
@@ -0,0 +1,296 @@
+// Package dashboard generates a Grafana dashboard for the metrics exported
+// from Stats. Metric and label names are taken from the naming of the
+// exported metrics.
+package dashboard
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+	"github.com/iancoleman/strcase"
+)
+
+// Dashboard is the subset of the Grafana dashboard JSON model in use
+type Dashboard struct {
+	UID           string     `json:"uid"`
+	Title         string     `json:"title"`
+	Tags          []string   `json:"tags"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Editable      bool       `json:"editable"`
+	Refresh       string     `json:"refresh"`
+	Time          TimeRange  `json:"time"`
+	Templating    Templating `json:"templating"`
+	Panels        []Panel    `json:"panels"`
+}
+
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Templating struct {
+	List []Variable `json:"list"`
+}
+
+// Variable is a templated variable of a Dashboard
+type Variable struct {
+	Name       string      `json:"name"`
+	Label      string      `json:"label,omitempty"`
+	Type       string      `json:"type"`
+	Datasource *Datasource `json:"datasource,omitempty"`
+	Query      string      `json:"query"`
+	Refresh    int         `json:"refresh,omitempty"`
+	Multi      bool        `json:"multi,omitempty"`
+	IncludeAll bool        `json:"includeAll,omitempty"`
+	AllValue   string      `json:"allValue,omitempty"`
+}
+
+type Datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+// Panel is either a row (holding Panels) or a timeseries panel
+type Panel struct {
+	ID          int         `json:"id"`
+	Type        string      `json:"type"`
+	Title       string      `json:"title"`
+	Description string      `json:"description,omitempty"`
+	Collapsed   bool        `json:"collapsed,omitempty"`
+	GridPos     GridPos     `json:"gridPos"`
+	Datasource  *Datasource `json:"datasource,omitempty"`
+	Targets     []Target    `json:"targets,omitempty"`
+	Panels      []Panel     `json:"panels,omitempty"`
+}
+
+type GridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type Target struct {
+	RefID      string      `json:"refId"`
+	Datasource *Datasource `json:"datasource,omitempty"`
+	Expr       string      `json:"expr"`
+}
+
+// Option represents an opaque option implementation
+// for building a Dashboard
+type Option interface {
+}
+
+// WithTitle creates an Option for setting the title of the Dashboard.
+func WithTitle(title string) Option {
+	return &dashboardTitle{
+		title: title,
+	}
+}
+
+// WithUID creates an Option for setting the UID of the Dashboard.
+func WithUID(uid string) Option {
+	return &dashboardUID{
+		uid: uid,
+	}
+}
+
+type dashboardTitle struct {
+	title string
+}
+
+type dashboardUID struct {
+	uid string
+}
+
+const (
+	panelsPerLine = 3
+	panelHeight   = 8
+)
+
+var datasource = &Datasource{
+	Type: "prometheus",
+	UID:  "${datasource}",
+}
+
+// variable is templated for the `kpromlbl` tag of the struct with the
+// metric prefix
+type variable struct {
+	name   string
+	prefix string
+	tag    string
+}
+
+var variables = []variable{
+	{name: "client_id", prefix: "", tag: "client_id"},
+	{name: "topic", prefix: "topics", tag: "topic"},
+	{name: "partition", prefix: "topics_partitions", tag: "partition"},
+	{name: "broker", prefix: "brokers", tag: "nodename"},
+}
+
+// Build creates a Dashboard for metrics named by naming (see
+// `v0.MetricNaming`). Metrics are grouped into one row per subtree of
+// Stats (e.g. brokers or topics). Counters are shown as rates.
+func Build(naming *gen.GeneratedOptions, opts ...Option) (*Dashboard, error) {
+	d := &Dashboard{
+		UID:           "kafka-stats-exporter",
+		Title:         "Kafka client statistics",
+		Tags:          []string{"kafka"},
+		SchemaVersion: 36,
+		Editable:      true,
+		Refresh:       "30s",
+		Time: TimeRange{
+			From: "now-1h",
+			To:   "now",
+		},
+	}
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case *dashboardTitle:
+			d.Title = o.title
+		case *dashboardUID:
+			d.UID = o.uid
+		default:
+			panic(fmt.Sprintf("Unrecognized option %#v", opt))
+		}
+	}
+
+	descs := naming.Describe()
+	if len(descs) == 0 {
+		return nil, fmt.Errorf("no metrics exported")
+	}
+
+	d.Templating.List = append(d.Templating.List, Variable{
+		Name:  "datasource",
+		Label: "Data source",
+		Type:  "datasource",
+		Query: "prometheus",
+	})
+	// Label names of the variables in use
+	labelNames := map[string]string{}
+	for _, v := range variables {
+		ln := naming.LabelName(v.prefix, v.tag)
+		metric := metricWithLabel(descs, ln)
+		if metric == "" {
+			continue
+		}
+		labelNames[v.name] = ln
+		d.Templating.List = append(d.Templating.List, Variable{
+			Name:       v.name,
+			Type:       "query",
+			Datasource: datasource,
+			Query:      fmt.Sprintf("label_values(%s, %s)", metric, ln),
+			Refresh:    2,
+			Multi:      true,
+			IncludeAll: true,
+			AllValue:   ".*",
+		})
+	}
+
+	// Rows in order of their first metric
+	var rows []*Panel
+	byTitle := map[string]*Panel{}
+	for _, desc := range descs {
+		title := rowTitle(desc.Path)
+		row, ok := byTitle[title]
+		if !ok {
+			row = &Panel{
+				Type:      "row",
+				Title:     title,
+				Collapsed: true,
+				GridPos: GridPos{
+					H: 1,
+					W: 24,
+					Y: len(rows),
+				},
+			}
+			rows = append(rows, row)
+			byTitle[title] = row
+		}
+		i := len(row.Panels)
+		row.Panels = append(row.Panels, Panel{
+			Type:        "timeseries",
+			Title:       desc.Name,
+			Description: desc.Help,
+			GridPos: GridPos{
+				H: panelHeight,
+				W: 24 / panelsPerLine,
+				X: (i % panelsPerLine) * 24 / panelsPerLine,
+				Y: row.GridPos.Y + 1 + (i/panelsPerLine)*panelHeight,
+			},
+			Datasource: datasource,
+			Targets: []Target{{
+				RefID:      "A",
+				Datasource: datasource,
+				Expr:       expr(desc, labelNames),
+			}},
+		})
+	}
+
+	id := 1
+	for _, row := range rows {
+		row.ID = id
+		id++
+		for i := range row.Panels {
+			row.Panels[i].ID = id
+			id++
+		}
+		d.Panels = append(d.Panels, *row)
+	}
+	return d, nil
+}
+
+// rowTitle names the subtree of the field at path (e.g.
+// `Stats.Topics[].Partitions[].Rxmsgs` is in `partitions`). Nested structs
+// of maps (e.g. `Stats.Brokers[].Rtt`) belong to the map.
+func rowTitle(path string) string {
+	segments := strings.Split(path, ".")
+	// Drop type and field
+	segments = segments[1 : len(segments)-1]
+	for i := len(segments) - 1; i >= 0; i-- {
+		if strings.HasSuffix(segments[i], "[]") {
+			return strcase.ToSnake(strings.TrimSuffix(segments[i], "[]"))
+		}
+	}
+	if len(segments) == 0 {
+		return "client"
+	}
+	return strcase.ToSnake(segments[0])
+}
+
+// expr queries the metric of desc filtered by the variables of its labels.
+// Counters are queried as rates.
+func expr(desc gen.MetricDesc, labelNames map[string]string) string {
+	var matchers []string
+	for _, v := range variables {
+		ln, ok := labelNames[v.name]
+		if !ok || !contains(desc.LabelNames, ln) {
+			continue
+		}
+		matchers = append(matchers, fmt.Sprintf("%s=~\"$%s\"", ln, v.name))
+	}
+	selector := desc.Name + "{" + strings.Join(matchers, ", ") + "}"
+	if desc.Type == "counter" {
+		return "rate(" + selector + "[$__rate_interval])"
+	}
+	return selector
+}
+
+func metricWithLabel(descs []gen.MetricDesc, labelName string) string {
+	for _, d := range descs {
+		if contains(d.LabelNames, labelName) {
+			return d.Name
+		}
+	}
+	return ""
+}
+
+func contains(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package dashboard
+
+import (
+	"reflect"
+	"testing"
+
+	v0 "github.com/abergmeier/kafka_stats_exporter/v0"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuild(t *testing.T) {
+	naming, err := v0.MetricNaming(v0.WithNestedInMapEntries())
+	if err != nil {
+		t.Fatal("MetricNaming failed:", err)
+	}
+	d, err := Build(naming, WithTitle("Kafka"))
+	if err != nil {
+		t.Fatal("Build failed:", err)
+	}
+	if d.Title != "Kafka" {
+		t.Error("Unexpected title", d.Title)
+	}
+
+	var variables []string
+	for _, v := range d.Templating.List {
+		variables = append(variables, v.Name)
+	}
+	diff := cmp.Diff([]string{"datasource", "client_id", "topic", "partition", "broker"}, variables)
+	if diff != "" {
+		t.Error("Diff of variables", diff)
+	}
+
+	var rows []string
+	panels := map[string]Panel{}
+	count := 0
+	for _, row := range d.Panels {
+		rows = append(rows, row.Title)
+		for _, p := range row.Panels {
+			panels[p.Title] = p
+			count++
+		}
+	}
+	diff = cmp.Diff([]string{"client", "brokers", "topics", "partitions", "cgrp", "eos"}, rows)
+	if diff != "" {
+		t.Error("Diff of rows", diff)
+	}
+	if count != len(naming.Describe()) {
+		t.Errorf("Expected a panel per metric (%d) but got %d", len(naming.Describe()), count)
+	}
+
+	expected := map[string]string{
+		"topics_partitions_rxmsgs_total": `rate(topics_partitions_rxmsgs_total{client_id=~"$client_id", topics_topic=~"$topic", topics_partitions_partition=~"$partition"}[$__rate_interval])`,
+		"brokers_rtt_p_99":               `brokers_rtt_p_99{client_id=~"$client_id", brokers_nodename=~"$broker"}`,
+	}
+	for name, e := range expected {
+		p, ok := panels[name]
+		if !ok {
+			t.Errorf("Missing panel for `%s`", name)
+			continue
+		}
+		if p.Targets[0].Expr != e {
+			t.Errorf("Expected `%s` but got `%s`", e, p.Targets[0].Expr)
+		}
+	}
+	if panels["rx_total"].Description != "Total number of responses received from Kafka brokers" {
+		t.Error("Unexpected description", panels["rx_total"].Description)
+	}
+}
+
+func TestBuildWithoutLabel(t *testing.T) {
+	aggregated, err := gen.NewGeneratedOptions(reflect.TypeOf(typed.Stats{}), gen.WithAggregation("Stats.Topics[].Partitions"))
+	if err != nil {
+		t.Fatal("NewGeneratedOptions failed:", err)
+	}
+	d, err := Build(aggregated)
+	if err != nil {
+		t.Fatal("Build failed:", err)
+	}
+	for _, v := range d.Templating.List {
+		if v.Name == "partition" {
+			t.Error("Unexpected variable for aggregated partitions")
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/abergmeier/kafka_stats_exporter/internal/synthetic"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func BenchmarkUpdate(b *testing.B) {
+	for _, size := range synthetic.Sizes {
+		stats := [2]*typed.Stats{synthetic.Stats(size, 1), synthetic.Stats(size, 2)}
+		b.Run(size.String(), func(b *testing.B) {
+			_, u := NewRecursiveMetricsFromTags(typed.Stats{})
+			u.Update(stats[0], prometheus.Labels{})
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				u.Update(stats[i%2], prometheus.Labels{})
+			}
+		})
+	}
+}
+
+func BenchmarkCollect(b *testing.B) {
+	for _, size := range synthetic.Sizes {
+		stats := synthetic.Stats(size, 1)
+		b.Run(size.String(), func(b *testing.B) {
+			c, u := NewRecursiveMetricsFromTags(typed.Stats{})
+			u.Update(stats, prometheus.Labels{})
+			ch := make(chan prometheus.Metric, 1024)
+			done := make(chan struct{})
+			go func() {
+				for range ch {
+				}
+				close(done)
+			}()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Collect(ch)
+			}
+			close(ch)
+			<-done
+		})
+	}
+}
@@ -34,6 +34,58 @@ func WithMetricNameTransform(fun types.MetricNameTransformer) RecursiveMetricsOp
 	}
 }
 
+// WithAggregation creates an Option for exporting the maps at the given Go
+// paths (e.g. `Stats.Topics[].Partitions`) aggregated on the level of the
+// struct owning the map. Has the same effect as tagging the map with
+// `kprommap:"<name>,aggregate"`.
+func WithAggregation(paths ...string) RecursiveMetricsOption {
+	return &recursiveMetricsAggregation{
+		paths: paths,
+	}
+}
+
+// WithMapEntryFilter creates an Option for skipping entries of the map at
+// the given Go path (e.g. `Stats.Topics[].Partitions`). Entries for which
+// `filter` returns false are not exported. Create `filter` via
+// NewMapEntryFilter for the key and value types of the map.
+// Multiple filters for the same map all need to pass.
+func WithMapEntryFilter(path string, filter types.MapEntryFilter) RecursiveMetricsOption {
+	return &recursiveMetricsMapEntryFilter{
+		path: path,
+		fun:  filter,
+	}
+}
+
+// WithNestedInMapEntries creates an Option for exporting structs tagged with
+// `kprompnt` inside of map entries (e.g. the rtt window of every broker).
+// These are not exported by default since they multiply the number of
+// series per entry.
+func WithNestedInMapEntries() RecursiveMetricsOption {
+	return &recursiveMetricsNestedInMapEntries{}
+}
+
+// WithNamespace creates an Option for setting the Namespace of all metrics.
+func WithNamespace(namespace string) RecursiveMetricsOption {
+	return &recursiveMetricsNamespace{
+		namespace: namespace,
+	}
+}
+
+// WithSubsystem creates an Option for setting the Subsystem of all metrics.
+func WithSubsystem(subsystem string) RecursiveMetricsOption {
+	return &recursiveMetricsSubsystem{
+		subsystem: subsystem,
+	}
+}
+
+// WithConstLabels creates an Option for adding constant Labels (e.g. `service`)
+// to all metrics.
+func WithConstLabels(labels prometheus.Labels) RecursiveMetricsOption {
+	return &recursiveMetricsConstLabels{
+		labels: labels,
+	}
+}
+
 type recursiveMetricsLabelNameTransform struct {
 	fun types.LabelNameTransformer
 }
@@ -42,25 +94,112 @@ type recursiveMetricNameTransform struct {
 	fun types.MetricNameTransformer
 }
 
+type recursiveMetricsAggregation struct {
+	paths []string
+}
+
+type recursiveMetricsNestedInMapEntries struct {
+}
+
+type recursiveMetricsNamespace struct {
+	namespace string
+}
+
+type recursiveMetricsSubsystem struct {
+	subsystem string
+}
+
+type recursiveMetricsConstLabels struct {
+	labels prometheus.Labels
+}
+
+type recursiveMetricsMapEntryFilter struct {
+	path string
+	fun  types.MapEntryFilter
+}
+
 // NewRecursiveMetricsFromTags builds Metrics recursively for the type of `tagged`.
 // Uses tags to build Metrics. Does not expose metrics directly.
 // Returns `Collector` for reading created Metrics and `Updater` for
 // updating the Metrics with a Type of `tagged`.
+// Panics if the Metrics cannot be built. See BuildRecursiveMetricsFromTags.
 func NewRecursiveMetricsFromTags(tagged interface{}, opts ...RecursiveMetricsOption) (prometheus.Collector, Updater) {
+	c, u, err := BuildRecursiveMetricsFromTags(tagged, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c, u
+}
+
+// BuildRecursiveMetricsFromTags is like NewRecursiveMetricsFromTags but
+// returns an error if Metrics cannot be built (e.g. when two fields
+// result in the same metric name).
+func BuildRecursiveMetricsFromTags(tagged interface{}, opts ...RecursiveMetricsOption) (prometheus.Collector, Updater, error) {
 	t := reflect.TypeOf(tagged)
 	switch t.Kind() {
 	case reflect.Pointer:
 		t = t.Elem()
 	}
 
+	o, err := NewGeneratedOptions(t, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	sink := newPrometheusSink(o)
+	u := &updater{
+		rec:  o.NewRecorder(),
+		sink: sink,
+	}
+	return sink, u, nil
+}
+
+// describeType builds the label reflectors for `t` and validates, that
+// all metrics can be generated.
+func describeType(t reflect.Type, opts *collector.Options, labelNameTransform types.LabelNameTransformer) (*label.RecursiveReflector, error) {
+	rlr := &label.RecursiveReflector{}
+	fillLabels(t, rlr, "", types.LabelNames{}, labelNameTransform)
+
+	_, err := collector.DescribeType(t, rlr, opts)
+	if err != nil {
+		return nil, err
+	}
+	return rlr, nil
+}
+
+func resolveOptions(opts []RecursiveMetricsOption) (*collector.Options, types.LabelNameTransformer) {
 	labelNameTransforms := []types.LabelNameTransformer{}
 	metricNameTransforms := []types.MetricNameTransformer{}
+	aggregate := map[string]struct{}{}
+	filters := map[string][]types.MapEntryFilter{}
+	derived := map[string][]types.DerivedMetric{}
+	collectorOpts := &collector.Options{}
 	for _, opt := range opts {
 		switch trans := opt.(type) {
 		case *recursiveMetricsLabelNameTransform:
 			labelNameTransforms = append(labelNameTransforms, trans.fun)
 		case *recursiveMetricNameTransform:
 			metricNameTransforms = append(metricNameTransforms, trans.fun)
+		case *recursiveMetricsAggregation:
+			for _, path := range trans.paths {
+				aggregate[path] = struct{}{}
+			}
+		case *recursiveMetricsNestedInMapEntries:
+			collectorOpts.NestedInMapEntries = true
+		case *recursiveMetricsMapEntryFilter:
+			filters[trans.path] = append(filters[trans.path], trans.fun)
+		case *recursiveMetricsNamespace:
+			collectorOpts.Namespace = trans.namespace
+		case *recursiveMetricsSubsystem:
+			collectorOpts.Subsystem = trans.subsystem
+		case *recursiveMetricsConstLabels:
+			if collectorOpts.ConstLabels == nil {
+				collectorOpts.ConstLabels = prometheus.Labels{}
+			}
+			for k, v := range trans.labels {
+				collectorOpts.ConstLabels[k] = v
+			}
+		case *recursiveMetricsDerived:
+			derived[trans.path] = append(derived[trans.path], trans.metrics...)
 		default:
 			panic(fmt.Sprintf("Unrecognized option %#v", opt))
 		}
@@ -96,15 +235,9 @@ func NewRecursiveMetricsFromTags(tagged interface{}, opts ...RecursiveMetricsOpt
 		}
 	}
 
-	rlr := label.RecursiveReflector{}
-	fillLabels(t, &rlr, "", types.LabelNames{}, labelNameTransform)
-
-	cs := &collector.Collectors{}
-	cs.Fill(t, &rlr, "", metricNameTransform)
-	u := &updater{
-		c:                   cs,
-		labelNameTransform:  labelNameTransform,
-		metricNameTransform: metricNameTransform,
-	}
-	return cs, u
+	collectorOpts.MetricNameTransform = metricNameTransform
+	collectorOpts.Aggregate = aggregate
+	collectorOpts.Filters = filters
+	collectorOpts.Derived = derived
+	return collectorOpts, labelNameTransform
 }
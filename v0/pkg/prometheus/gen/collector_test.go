@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"io"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/google/go-cmp/cmp"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 )
@@ -125,3 +127,281 @@ func TestUpdateFull(t *testing.T) {
 		t.Fatal("CollectAndCompare failed:", err)
 	}
 }
+
+func TestUpdateFullAggregated(t *testing.T) {
+	col, upd := NewRecursiveMetricsFromTags(&full, WithAggregation("Stats.Topics[].Partitions"))
+	upd.Update(full, prometheus.Labels{})
+	expected := `
+# HELP topics_consumer_lag Difference between (hi_offset or ls_offset) and committed_offset). hi_offset is used when isolation.level=read_uncommitted, otherwise ls_offset.
+# TYPE topics_consumer_lag gauge
+topics_consumer_lag{client_id="rdkafka",name="rdkafka#producer-1",topics_topic="test",type="producer"} 0
+# HELP topics_msgs_total Total number of messages received (consumer, same as rxmsgs), or total number of messages produced (possibly not yet transmitted) (producer).
+# TYPE topics_msgs_total counter
+topics_msgs_total{client_id="rdkafka",name="rdkafka#producer-1",topics_topic="test",type="producer"} 4320245
+`
+	err := testutil.CollectAndCompare(col, strings.NewReader(expected), "topics_consumer_lag", "topics_hi_offset", "topics_msgs_total", "topics_partitions_msgs_total", "topics_next_ack_seq")
+	if err != nil {
+		t.Fatal("CollectAndCompare failed:", err)
+	}
+}
+
+func TestUpdateAggregatedPartitionReturns(t *testing.T) {
+	col, upd := NewRecursiveMetricsFromTags(typed.Stats{}, WithAggregation("Stats.Topics[].Partitions"))
+	stats := typed.Stats{
+		Name: "rdkafka#consumer-1",
+		Topics: map[typed.TopicName]typed.TopicStats{
+			"test": {Topic: "test", Partitions: map[typed.PartitionId]typed.PartitionStats{
+				0: {Partition: 0, Msgs: 10, ConsumerLag: 5},
+				1: {Partition: 1, Msgs: 20, ConsumerLag: -1},
+			}},
+		},
+	}
+	upd.Update(&stats, prometheus.Labels{})
+	// Partition 1 gets revoked and assigned again. Its messages before the
+	// assignment are unknown.
+	partitions := stats.Topics["test"].Partitions
+	returned := partitions[1]
+	delete(partitions, 1)
+	upd.Update(&stats, prometheus.Labels{})
+	returned.Msgs = 25
+	partitions[1] = returned
+	upd.Update(&stats, prometheus.Labels{})
+	returned.Msgs = 30
+	partitions[1] = returned
+	upd.Update(&stats, prometheus.Labels{})
+	expected := `
+# HELP topics_consumer_lag Difference between (hi_offset or ls_offset) and committed_offset). hi_offset is used when isolation.level=read_uncommitted, otherwise ls_offset.
+# TYPE topics_consumer_lag gauge
+topics_consumer_lag{client_id="",name="rdkafka#consumer-1",topics_topic="test",type=""} 5
+# HELP topics_msgs_total Total number of messages received (consumer, same as rxmsgs), or total number of messages produced (possibly not yet transmitted) (producer).
+# TYPE topics_msgs_total counter
+topics_msgs_total{client_id="",name="rdkafka#consumer-1",topics_topic="test",type=""} 35
+`
+	err := testutil.CollectAndCompare(col, strings.NewReader(expected), "topics_consumer_lag", "topics_msgs_total")
+	if err != nil {
+		t.Fatal("CollectAndCompare failed:", err)
+	}
+}
+
+func TestUpdateSimpleMapEntryFilter(t *testing.T) {
+	col, upd := NewRecursiveMetricsFromTags(simpleStats{}, WithMapEntryFilter("simpleStats.Brokers", NewMapEntryFilter(func(key typed.BrokerName, value simpleBrokerStats) bool {
+		return key != "localhost:9092/2"
+	})))
+	upd.Update(&simple, prometheus.Labels{})
+	count := testutil.CollectAndCount(col, "brokers_rxbytes_total")
+	if count != 0 {
+		t.Fatalf("Expected filtered broker to not be exported. Got %d series", count)
+	}
+}
+
+func TestBuildMapEntryFilterType(t *testing.T) {
+	_, _, err := BuildRecursiveMetricsFromTags(simpleStats{}, WithMapEntryFilter("simpleStats.Brokers", NewMapEntryFilter(func(key typed.BrokerName, value typed.BrokerStats) bool {
+		return true
+	})))
+	if err == nil {
+		t.Fatal("Expected error for filter of other value type")
+	}
+}
+
+func TestUpdateSimpleDerived(t *testing.T) {
+	col, upd := NewRecursiveMetricsFromTags(simpleStats{},
+		WithDerivedMetrics("simpleStats", NewDerivedMetric("rx_kilobytes", "Received kilobytes", func(s *simpleStats) float64 {
+			return float64(s.RxBytes) / 1000
+		})),
+		WithDerivedMetrics("simpleStats.Brokers[]", NewDerivedMetric("rx_share", "Share of received bytes", func(bs *simpleBrokerStats) float64 {
+			return float64(bs.Rxbytes) / float64(simple.RxBytes)
+		})),
+	)
+	upd.Update(simple, prometheus.Labels{})
+	expected := `
+# HELP brokers_rx_share Share of received bytes
+# TYPE brokers_rx_share gauge
+brokers_rx_share{brokers_name="localhost:9092/2",name="rdkafka#producer-1"} 0.5053403680350019
+# HELP rx_kilobytes Received kilobytes
+# TYPE rx_kilobytes gauge
+rx_kilobytes{name="rdkafka#producer-1"} 31.084
+`
+	err := testutil.CollectAndCompare(col, strings.NewReader(expected), "brokers_rx_share", "rx_kilobytes")
+	if err != nil {
+		t.Fatal("CollectAndCompare failed:", err)
+	}
+}
+
+func TestUpdateSimpleNamespace(t *testing.T) {
+	col, upd := NewRecursiveMetricsFromTags(simpleStats{},
+		WithNamespace("kafka"),
+		WithSubsystem("client"),
+		WithConstLabels(prometheus.Labels{"service": "test"}),
+	)
+	upd.Update(&simple, prometheus.Labels{})
+	expected := `
+# HELP kafka_client_brokers_rxbytes_total Total number of bytes received
+# TYPE kafka_client_brokers_rxbytes_total counter
+kafka_client_brokers_rxbytes_total{brokers_name="localhost:9092/2",name="rdkafka#producer-1",service="test"} 15708
+# HELP kafka_client_rx_bytes_total Total number of bytes received from Kafka brokers
+# TYPE kafka_client_rx_bytes_total counter
+kafka_client_rx_bytes_total{name="rdkafka#producer-1",service="test"} 31084
+`
+	err := testutil.CollectAndCompare(col, strings.NewReader(expected))
+	if err != nil {
+		t.Fatal("CollectAndCompare failed:", err)
+	}
+}
+
+func TestUpdateSimpleLabelChange(t *testing.T) {
+	col, upd := NewRecursiveMetricsFromTags(simpleStats{})
+	stats := simpleStats{
+		Name:    "rdkafka#producer-1",
+		RxBytes: 10,
+		Brokers: map[typed.BrokerName]simpleBrokerStats{
+			"localhost:9092/2": {Name: "localhost:9092/2", Rxbytes: 10},
+		},
+	}
+	upd.Update(&stats, prometheus.Labels{})
+	stats.RxBytes = 20
+	stats.Brokers["localhost:9092/2"] = simpleBrokerStats{Name: "renamed:9092/2", Rxbytes: 5}
+	upd.Update(&stats, prometheus.Labels{})
+	expected := `
+# HELP brokers_rxbytes_total Total number of bytes received
+# TYPE brokers_rxbytes_total counter
+brokers_rxbytes_total{brokers_name="renamed:9092/2",name="rdkafka#producer-1"} 5
+# HELP rx_bytes_total Total number of bytes received from Kafka brokers
+# TYPE rx_bytes_total counter
+rx_bytes_total{name="rdkafka#producer-1"} 20
+`
+	err := testutil.CollectAndCompare(col, strings.NewReader(expected))
+	if err != nil {
+		t.Fatal("CollectAndCompare failed:", err)
+	}
+}
+
+type collidingStats struct {
+	BrokersRx int                             `json:"brokers_rx" kpromcol:"CounterVec,Total number of responses"`
+	Brokers   map[string]collidingBrokerStats `json:"brokers"    kprommap:"brokers"`
+}
+
+type collidingBrokerStats struct {
+	Rx int `json:"rx" kpromcol:"CounterVec,Total number of responses received"`
+}
+
+func TestBuildColliding(t *testing.T) {
+	_, _, err := BuildRecursiveMetricsFromTags(collidingStats{})
+	if err == nil {
+		t.Fatal("Expected error for colliding metric names")
+	}
+	expected := "metric name `brokers_rx_total` generated for both `collidingStats.BrokersRx` and `collidingStats.Brokers[].Rx`"
+	if err.Error() != expected {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+type prefixStats struct {
+	TopicsPartitions map[string]prefixPartitionStats `json:"topics_partitions" kprommap:"topics_partitions"`
+	Topics           map[string]prefixTopicStats     `json:"topics"            kprommap:"topics"`
+}
+
+type prefixTopicStats struct {
+	Partitions map[string]prefixPartitionStats `json:"partitions" kprommap:"partitions"`
+}
+
+type prefixPartitionStats struct {
+	Name  string `json:"name"  kpromlbl:"name"`
+	Msgs  int    `json:"msgs"  kpromcol:"CounterVec,Messages"`
+	Bytes int    `json:"bytes" kpromcol:"CounterVec,Bytes"`
+}
+
+func TestBuildSamePrefix(t *testing.T) {
+	descs, err := DescribeMetrics(&prefixStats{}, WithAggregation("prefixStats.Topics[].Partitions"))
+	if err != nil {
+		t.Fatal("DescribeMetrics failed:", err)
+	}
+	var paths []string
+	for _, d := range descs {
+		paths = append(paths, d.Path)
+	}
+	expected := []string{
+		"prefixStats.TopicsPartitions[].Msgs",
+		"prefixStats.TopicsPartitions[].Bytes",
+		"prefixStats.Topics[].Partitions[].Msgs",
+		"prefixStats.Topics[].Partitions[].Bytes",
+	}
+	if d := cmp.Diff(expected, paths); d != "" {
+		t.Fatal("Diff", d)
+	}
+}
+
+func TestGeneratedOptionsEqual(t *testing.T) {
+	accept := NewMapEntryFilter(func(key typed.BrokerName, value simpleBrokerStats) bool { return true })
+	reject := NewMapEntryFilter(func(key typed.BrokerName, value simpleBrokerStats) bool { return false })
+	kilobytes := func(s *simpleStats) float64 { return float64(s.RxBytes) / 1024 }
+	megabytes := func(s *simpleStats) float64 { return float64(s.RxBytes) / 1024 / 1024 }
+	tests := map[string]struct {
+		lhs   []RecursiveMetricsOption
+		rhs   []RecursiveMetricsOption
+		equal bool
+	}{
+		"none": {
+			equal: true,
+		},
+		"same": {
+			lhs:   []RecursiveMetricsOption{WithMapEntryFilter("simpleStats.Brokers", accept), WithDerivedMetrics("simpleStats", NewDerivedMetric("rx", "Received", kilobytes))},
+			rhs:   []RecursiveMetricsOption{WithMapEntryFilter("simpleStats.Brokers", accept), WithDerivedMetrics("simpleStats", NewDerivedMetric("rx", "Received", kilobytes))},
+			equal: true,
+		},
+		"filter": {
+			lhs: []RecursiveMetricsOption{WithMapEntryFilter("simpleStats.Brokers", accept)},
+		},
+		"other funcs": {
+			lhs:   []RecursiveMetricsOption{WithMapEntryFilter("simpleStats.Brokers", accept), WithDerivedMetrics("simpleStats", NewDerivedMetric("rx", "Received", kilobytes))},
+			rhs:   []RecursiveMetricsOption{WithMapEntryFilter("simpleStats.Brokers", reject), WithDerivedMetrics("simpleStats", NewDerivedMetric("rx", "Received", megabytes))},
+			equal: true,
+		},
+		"derived": {
+			lhs: []RecursiveMetricsOption{WithDerivedMetrics("simpleStats", NewDerivedMetric("rx_kilobytes", "Received", kilobytes))},
+			rhs: []RecursiveMetricsOption{WithDerivedMetrics("simpleStats", NewDerivedMetric("rx_megabytes", "Received", megabytes))},
+		},
+		"const labels": {
+			lhs: []RecursiveMetricsOption{WithConstLabels(prometheus.Labels{"a": "b"})},
+			rhs: []RecursiveMetricsOption{WithConstLabels(prometheus.Labels{"a": "c"})},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			lhs, err := NewGeneratedOptions(reflect.TypeOf(simpleStats{}), test.lhs...)
+			if err != nil {
+				t.Fatal("NewGeneratedOptions failed:", err)
+			}
+			rhs, err := NewGeneratedOptions(reflect.TypeOf(simpleStats{}), test.rhs...)
+			if err != nil {
+				t.Fatal("NewGeneratedOptions failed:", err)
+			}
+			if lhs.Equal(rhs) != test.equal {
+				t.Fatalf("Expected Equal to be %t", test.equal)
+			}
+		})
+	}
+}
+
+func TestDescribeMetrics(t *testing.T) {
+	descs, err := DescribeMetrics(&simple, WithNamespace("kafka"))
+	if err != nil {
+		t.Fatal("DescribeMetrics failed:", err)
+	}
+	expected := []MetricDesc{{
+		Name:       "kafka_rx_bytes_total",
+		Type:       "counter",
+		Help:       "Total number of bytes received from Kafka brokers",
+		LabelNames: []string{"name"},
+		Path:       "simpleStats.RxBytes",
+	}, {
+		Name:       "kafka_brokers_rxbytes_total",
+		Type:       "counter",
+		Help:       "Total number of bytes received",
+		LabelNames: []string{"brokers_name", "name"},
+		Path:       "simpleStats.Brokers[].Rxbytes",
+	}}
+	d := cmp.Diff(expected, descs)
+	if d != "" {
+		t.Fatal("Diff", d)
+	}
+}
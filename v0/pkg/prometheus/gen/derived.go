@@ -0,0 +1,37 @@
+package gen
+
+import (
+	"reflect"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/types"
+)
+
+// NewDerivedMetric creates a DerivedMetric which gets computed by `fun` on
+// every update of a value of type T.
+func NewDerivedMetric[T any](name, help string, fun func(*T) float64) types.DerivedMetric {
+	return types.DerivedMetric{
+		Name: name,
+		Help: help,
+		T:    reflect.TypeOf((*T)(nil)).Elem(),
+		Fun: func(v interface{}) float64 {
+			return fun(v.(*T))
+		},
+	}
+}
+
+// WithDerivedMetrics creates an Option for exporting metrics computed from
+// the struct at the given Go path (e.g. `Stats.Brokers[]` or `Stats` for
+// the root). Derived metrics are exported as Gauges with the labels of the
+// struct. Not evaluated for aggregated maps or by streaming updates of
+// generated Collectors.
+func WithDerivedMetrics(path string, metrics ...types.DerivedMetric) RecursiveMetricsOption {
+	return &recursiveMetricsDerived{
+		path:    path,
+		metrics: metrics,
+	}
+}
+
+type recursiveMetricsDerived struct {
+	path    string
+	metrics []types.DerivedMetric
+}
@@ -0,0 +1,46 @@
+package gen
+
+import (
+	"reflect"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/types"
+)
+
+// NewMapEntryFilter creates a MapEntryFilter for maps of type map[K]V. Entries
+// for which `fun` returns false are not exported.
+func NewMapEntryFilter[K comparable, V any](fun func(key K, value V) bool) types.MapEntryFilter {
+	return types.MapEntryFilter{
+		Key:   reflect.TypeOf((*K)(nil)).Elem(),
+		Value: reflect.TypeOf((*V)(nil)).Elem(),
+		Fun:   fun,
+		Boxed: func(key, value interface{}) bool {
+			return fun(key.(K), value.(V))
+		},
+	}
+}
+
+// MapEntryFilterFunc returns the filters for entries of the map at Go path
+// `path` combined or nil if there are none. Used by generated Collectors
+// to filter without boxing keys and values. The types of the filters are
+// checked by NewGeneratedOptions.
+func MapEntryFilterFunc[K comparable, V any](o *GeneratedOptions, path string) func(key K, value V) bool {
+	filters := o.opts.Filters[path]
+	funs := make([]func(key K, value V) bool, len(filters))
+	for i, filter := range filters {
+		funs[i] = filter.Fun.(func(key K, value V) bool)
+	}
+	switch len(funs) {
+	case 0:
+		return nil
+	case 1:
+		return funs[0]
+	}
+	return func(key K, value V) bool {
+		for _, fun := range funs {
+			if !fun(key, value) {
+				return false
+			}
+		}
+		return true
+	}
+}
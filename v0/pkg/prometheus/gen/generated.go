@@ -0,0 +1,298 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/abergmeier/kafka_stats_exporter/internal/collector"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrIncompatibleOptions is returned when sharing the metrics of a generated
+// Collector with one created with different options
+var ErrIncompatibleOptions = errors.New("options differ from the ones of the shared metrics")
+
+// GeneratedOptions are resolved RecursiveMetricsOption for use by
+// Collectors generated with package codegen. Generated Collectors create all
+// their metrics via GeneratedOptions, so metric names are identical to
+// the ones of NewRecursiveMetricsFromTags.
+type GeneratedOptions struct {
+	recorder           *collector.Recorder
+	descs              []MetricDesc
+	opts               *collector.Options
+	labelNameTransform types.LabelNameTransformer
+}
+
+// MetricDesc describes a metric exported for a tagged type
+type MetricDesc struct {
+	Name       string   `json:"name"` // Fully qualified metric name
+	Type       string   `json:"type"` // Either `counter` or `gauge`
+	Help       string   `json:"help"`
+	Unit       string   `json:"unit,omitempty"` // Optional OpenMetrics base unit suffixing Name (e.g. `bytes`)
+	LabelNames []string `json:"labelNames"`
+	Path       string   `json:"path"` // Go path of the source field (e.g. `Stats.Brokers[].Rtt.P99`)
+}
+
+// DescribeMetrics lists all metrics exported for the type of `tagged`
+// in the order of the fields. Fails if Metrics cannot be built (see
+// BuildRecursiveMetricsFromTags).
+func DescribeMetrics(tagged interface{}, opts ...RecursiveMetricsOption) ([]MetricDesc, error) {
+	t := reflect.TypeOf(tagged)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	o, err := NewGeneratedOptions(t, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return o.Describe(), nil
+}
+
+// GeneratedDerived is a derived metric of a generated Collector
+type GeneratedDerived struct {
+	Vec *prometheus.GaugeVec
+	Fun func(v interface{}) float64
+}
+
+// NewGeneratedOptions resolves `opts` for tagged type `t`.
+// Fails if Metrics cannot be built (see BuildRecursiveMetricsFromTags).
+func NewGeneratedOptions(t reflect.Type, opts ...RecursiveMetricsOption) (*GeneratedOptions, error) {
+	collectorOpts, labelNameTransform := resolveOptions(opts)
+	rlr, err := describeType(t, collectorOpts, labelNameTransform)
+	if err != nil {
+		return nil, err
+	}
+	recorder, err := collector.NewRecorder(t, rlr, collectorOpts, labelNameTransform)
+	if err != nil {
+		return nil, err
+	}
+	descs := recorder.Descs()
+	mds := make([]MetricDesc, len(descs))
+	for i, d := range descs {
+		mds[i] = MetricDesc(d)
+	}
+	return &GeneratedOptions{
+		recorder:           recorder,
+		descs:              mds,
+		opts:               collectorOpts,
+		labelNameTransform: labelNameTransform,
+	}, nil
+}
+
+// Equal reports whether o and other create the same metrics with filters
+// for the same maps. Functions of filters and derived metrics cannot be
+// compared, thus Collectors sharing metrics each evaluate their own (see
+// ShareDerived and MapEntryFilterFunc).
+func (o *GeneratedOptions) Equal(other *GeneratedOptions) bool {
+	if !reflect.DeepEqual(o.descs, other.descs) ||
+		!equalMaps(o.opts.ConstLabels, other.opts.ConstLabels) ||
+		!equalMaps(o.opts.Aggregate, other.opts.Aggregate) ||
+		len(o.opts.Filters) != len(other.opts.Filters) {
+		return false
+	}
+	for path, filters := range o.opts.Filters {
+		if len(filters) != len(other.opts.Filters[path]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalMaps[V comparable](lhs, rhs map[string]V) bool {
+	if len(lhs) != len(rhs) {
+		return false
+	}
+	for k, v := range lhs {
+		ov, ok := rhs[k]
+		if !ok || ov != v {
+			return false
+		}
+	}
+	return true
+}
+
+// LabelName returns the label name for a field tagged with `kpromlbl:"<tag>"`
+// in the struct with metric prefix `parent`.
+func (o *GeneratedOptions) LabelName(parent, tag string) string {
+	return o.labelNameTransform(collector.JoinPrefix(parent, tag))
+}
+
+// Describe lists all metrics exported for the tagged type
+func (o *GeneratedOptions) Describe() []MetricDesc {
+	mds := make([]MetricDesc, len(o.descs))
+	for i, d := range o.descs {
+		mds[i] = d
+		mds[i].LabelNames = append([]string(nil), d.LabelNames...)
+	}
+	return mds
+}
+
+// NewCounterVec creates the metric for field `fieldName` tagged with
+// `kpromcol:"CounterVec,<help>"`.
+func (o *GeneratedOptions) NewCounterVec(parent, fieldName, help string, labelNames []string) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   o.opts.Namespace,
+		Subsystem:   o.opts.Subsystem,
+		Name:        o.opts.FieldMetricName(parent, fieldName, "CounterVec"),
+		Help:        help,
+		ConstLabels: o.opts.ConstLabels,
+	}, labelNames)
+}
+
+// NewGaugeVec creates the metric for field `fieldName` tagged with
+// `kpromcol:"GaugeVec,<help>"`.
+func (o *GeneratedOptions) NewGaugeVec(parent, fieldName, help string, labelNames []string) *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   o.opts.Namespace,
+		Subsystem:   o.opts.Subsystem,
+		Name:        o.opts.FieldMetricName(parent, fieldName, "GaugeVec"),
+		Help:        help,
+		ConstLabels: o.opts.ConstLabels,
+	}, labelNames)
+}
+
+// NewDerived creates the derived metrics for the struct at Go path `path`
+// with metric prefix `prefix` and type `t`.
+func (o *GeneratedOptions) NewDerived(prefix, path string, t reflect.Type, labelNames []string) []GeneratedDerived {
+	var derived []GeneratedDerived
+	for _, d := range o.opts.Derived[path] {
+		if d.T != t {
+			panic(fmt.Sprintf("Derived metric `%s` is for type `%s` but `%s` has type `%s`", d.Name, d.T, path, t))
+		}
+		derived = append(derived, GeneratedDerived{
+			Vec: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Namespace:   o.opts.Namespace,
+				Subsystem:   o.opts.Subsystem,
+				Name:        o.opts.MetricName(prefix, d.Name),
+				Help:        d.Help,
+				ConstLabels: o.opts.ConstLabels,
+			}, labelNames),
+			Fun: d.Fun,
+		})
+	}
+	return derived
+}
+
+// ShareDerived returns the derived metrics of the struct at Go path `path`
+// exporting to the Gauges of `shared` but computed by the functions of o.
+// `shared` needs to be created by options Equal to o.
+func (o *GeneratedOptions) ShareDerived(shared []GeneratedDerived, path string) []GeneratedDerived {
+	derived := make([]GeneratedDerived, len(shared))
+	for i, d := range o.opts.Derived[path] {
+		derived[i] = GeneratedDerived{
+			Vec: shared[i].Vec,
+			Fun: d.Fun,
+		}
+	}
+	return derived
+}
+
+// Aggregate reports whether the map at Go path `path` is aggregated via
+// WithAggregation.
+func (o *GeneratedOptions) Aggregate(path string) bool {
+	_, ok := o.opts.Aggregate[path]
+	return ok
+}
+
+// NestedInMapEntries reports whether structs tagged with `kprompnt` inside
+// of map entries are exported via WithNestedInMapEntries.
+func (o *GeneratedOptions) NestedInMapEntries() bool {
+	return o.opts.NestedInMapEntries
+}
+
+// AddToCounter adds the increase from `last` to `current` to a Counter
+// already bound to labels and returns the new last value.
+func AddToCounter(counter prometheus.Counter, last, current int64) int64 {
+	diff := current - last
+	if diff > 0 {
+		counter.Add(float64(diff))
+	}
+	return current
+}
+
+// ContainsLabels reports whether ls contains all of subset
+func ContainsLabels(ls, subset prometheus.Labels) bool {
+	for k, v := range subset {
+		lv, ok := ls[k]
+		if !ok || lv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// EqualIntLabel reports whether label value lv is the decimal of v.
+// Does not allocate.
+func EqualIntLabel(lv string, v int64) bool {
+	var buf [20]byte
+	return lv == string(strconv.AppendInt(buf[:0], v, 10))
+}
+
+// SeriesOwners tracks which of the Collectors sharing metrics last updated
+// the series with a set of root labels. Only the owner deletes them, so
+// closing the Collector of a replaced client keeps the series of the
+// replacement. Safe for concurrent use.
+type SeriesOwners struct {
+	mu     sync.Mutex
+	owners map[string]interface{} // By labels key
+	keys   map[interface{}]string // By owner
+}
+
+// Own makes owner the owner of the series with root labels ls. Releases
+// the previous labels of owner.
+func (o *SeriesOwners) Own(ls prometheus.Labels, owner interface{}) {
+	if ls == nil {
+		return
+	}
+	key := labelsKey(ls)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.owners == nil {
+		o.owners = map[string]interface{}{}
+		o.keys = map[interface{}]string{}
+	}
+	previous, ok := o.keys[owner]
+	if ok && previous != key && o.owners[previous] == owner {
+		delete(o.owners, previous)
+	}
+	o.owners[key] = owner
+	o.keys[owner] = key
+}
+
+// Release forgets owner and reports whether it still owned its series
+func (o *SeriesOwners) Release(owner interface{}) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	key, ok := o.keys[owner]
+	if !ok {
+		return false
+	}
+	delete(o.keys, owner)
+	if o.owners[key] != owner {
+		return false
+	}
+	delete(o.owners, key)
+	return true
+}
+
+func labelsKey(ls prometheus.Labels) string {
+	names := make([]string, 0, len(ls))
+	for name := range ls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(0)
+		b.WriteString(ls[name])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
@@ -3,6 +3,7 @@ package gen
 import (
 	"reflect"
 
+	"github.com/abergmeier/kafka_stats_exporter/internal/collector"
 	"github.com/abergmeier/kafka_stats_exporter/internal/label"
 	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/types"
 )
@@ -86,6 +87,7 @@ func fillLabels(t reflect.Type, rlr *label.RecursiveReflector, parent string, pa
 	for _, ft := range reflect.VisibleFields(t) {
 		tag := ft.Tag.Get("kprommap")
 		if tag != "" {
+			tag, _ = collector.ParseMapTag(tag)
 			switch ft.Type.Kind() {
 			case reflect.Map:
 			default:
@@ -172,11 +172,12 @@ func init() {
 func TestMakeLabelReflector(t *testing.T) {
 	tpe := reflect.TypeOf(typed.Stats{})
 	lg, lns := MakeLabelReflector(tpe, "", types.LabelNames{})
-	ls := lg.LabelsForValue(typed.Stats{
+	ls := prometheus.Labels{}
+	lg.FillLabelsForValue(reflect.ValueOf(typed.Stats{
 		Name:     "MyName",
 		ClientId: "MyClientId",
 		Type:     "MyType",
-	})
+	}), ls, func(value string) (labelName string) { return value })
 	if !reflect.DeepEqual(ls, expectedLabels) {
 		t.Fatal("Invalid labels generated:", ls)
 	}
@@ -0,0 +1,63 @@
+package gen
+
+import (
+	"reflect"
+
+	"github.com/abergmeier/kafka_stats_exporter/internal/collector"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricSink receives the values of the metrics exported for a tagged type.
+// Allows for exporting metrics other than via Prometheus Collectors (e.g.
+// via OpenTelemetry).
+type MetricSink interface {
+	// Record is called for every metric and combination of labels (by
+	// label name). desc and labels must neither be modified nor retained.
+	Record(desc *MetricDesc, labels map[string]string, value float64)
+}
+
+// SeriesSink is a MetricSink, which keeps state per series independent of
+// their labels (e.g. the last values of counters, whose labels like the
+// state of a broker change).
+type SeriesSink interface {
+	MetricSink
+	// RecordSeries is called instead of Record. series identifies the
+	// struct holding the value by the keys of the map entries containing it
+	// (e.g. `/"test"/0` for partition 0 of topic `test`).
+	RecordSeries(desc *MetricDesc, series string, labels map[string]string, value float64)
+}
+
+// Recorder records values of the tagged type in MetricSinks. Keeps the
+// totals of aggregated counters, so these only increase. Collectors of
+// NewRecursiveMetricsFromTags are updated by a Recorder as well.
+// Must not be used concurrently.
+type Recorder struct {
+	o     *GeneratedOptions
+	state collector.RecordState
+}
+
+// NewRecorder creates a Recorder for values of the tagged type
+func (o *GeneratedOptions) NewRecorder() *Recorder {
+	return &Recorder{
+		o: o,
+	}
+}
+
+// Record walks v (a value of the tagged type) and records the current
+// value of every metric in sink. Applies the filters, aggregations and
+// derived metrics of the options.
+func (r *Recorder) Record(v interface{}, sink MetricSink) {
+	r.record(v, nil, sink)
+}
+
+// record is Record with additional labels of all metrics
+func (r *Recorder) record(v interface{}, labels prometheus.Labels, sink MetricSink) {
+	ss, _ := sink.(SeriesSink)
+	r.o.recorder.Record(reflect.ValueOf(v), labels, &r.state, func(desc int, series string, labels prometheus.Labels, value float64) {
+		if ss != nil {
+			ss.RecordSeries(&r.o.descs[desc], series, labels, value)
+			return
+		}
+		sink.Record(&r.o.descs[desc], labels, value)
+	})
+}
@@ -0,0 +1,117 @@
+package gen
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type mapSink map[string]float64
+
+func (s mapSink) Record(desc *MetricDesc, labels map[string]string, value float64) {
+	if desc.Type == "counter" {
+		// Counters only count increases from 0
+		value = math.Max(value, 0)
+	}
+	s[seriesKey(desc.Name, labels)] = value
+}
+
+func seriesKey(name string, labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+func TestRecordSameAsCollect(t *testing.T) {
+	for name, opts := range map[string][]RecursiveMetricsOption{
+		"default":     nil,
+		"aggregation": {WithAggregation("Stats.Topics[].Partitions"), WithNamespace("kafka")},
+		"constlabels": {WithConstLabels(prometheus.Labels{"service": "test"})},
+	} {
+		t.Run(name, func(t *testing.T) {
+			col, upd := NewRecursiveMetricsFromTags(&full, opts...)
+			upd.Update(&full, prometheus.Labels{})
+			r := prometheus.NewPedanticRegistry()
+			r.MustRegister(col)
+			mfs, err := r.Gather()
+			if err != nil {
+				t.Fatal("Gather failed:", err)
+			}
+			collected := mapSink{}
+			for _, mf := range mfs {
+				for _, m := range mf.Metric {
+					labels := map[string]string{}
+					for _, lp := range m.Label {
+						labels[lp.GetName()] = lp.GetValue()
+					}
+					value := m.GetGauge().GetValue()
+					if m.Counter != nil {
+						value = m.GetCounter().GetValue()
+					}
+					collected[seriesKey(mf.GetName(), labels)] = value
+				}
+			}
+
+			o, err := NewGeneratedOptions(reflect.TypeOf(typed.Stats{}), opts...)
+			if err != nil {
+				t.Fatal("NewGeneratedOptions failed:", err)
+			}
+			recorded := mapSink{}
+			o.NewRecorder().Record(&full, recorded)
+			if len(recorded) == 0 {
+				t.Fatal("Nothing recorded")
+			}
+
+			d := cmp.Diff(collected, recorded)
+			if d != "" {
+				t.Fatal("Diff", d)
+			}
+		})
+	}
+}
+
+func TestRecordAggregatedPartitionReturns(t *testing.T) {
+	o, err := NewGeneratedOptions(reflect.TypeOf(typed.Stats{}), WithAggregation("Stats.Topics[].Partitions"))
+	if err != nil {
+		t.Fatal("NewGeneratedOptions failed:", err)
+	}
+	rec := o.NewRecorder()
+	stats := typed.Stats{
+		Name: "rdkafka#consumer-1",
+		Topics: map[typed.TopicName]typed.TopicStats{
+			"test": {Topic: "test", Partitions: map[typed.PartitionId]typed.PartitionStats{
+				0: {Partition: 0, Msgs: 10},
+				1: {Partition: 1, Msgs: 20},
+			}},
+		},
+	}
+	key := `topics_msgs_total{client_id=,name=rdkafka#consumer-1,topics_topic=test,type=}`
+	recorded := mapSink{}
+	rec.Record(&stats, recorded)
+	if recorded[key] != 30 {
+		t.Fatalf("Expected 30 messages but got %f", recorded[key])
+	}
+	// Partition 1 gets revoked and assigned again with a restarted counter
+	partitions := stats.Topics["test"].Partitions
+	delete(partitions, 1)
+	rec.Record(&stats, recorded)
+	if recorded[key] != 30 {
+		t.Fatalf("Expected 30 messages after revoke but got %f", recorded[key])
+	}
+	partitions[1] = typed.PartitionStats{Partition: 1, Msgs: 5}
+	rec.Record(&stats, recorded)
+	partitions[1] = typed.PartitionStats{Partition: 1, Msgs: 8}
+	rec.Record(&stats, recorded)
+	if recorded[key] != 33 {
+		t.Fatalf("Expected 33 messages after assign but got %f", recorded[key])
+	}
+}
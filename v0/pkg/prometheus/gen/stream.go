@@ -0,0 +1,319 @@
+package gen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// ErrJSONSyntax is wrapped by all errors of decoding malformed JSON
+	ErrJSONSyntax = errors.New("invalid JSON")
+)
+
+// JSONObject iterates the members of a raw JSON object without decoding
+// their values. Used by generated Collectors for streaming updates.
+// An empty string or `null` is treated as an empty object.
+type JSONObject struct {
+	s     string
+	pos   int
+	key   string
+	value string
+	err   error
+	// started is set once the first member was read, after which
+	// members have to be separated by `,`
+	started bool
+}
+
+// NewJSONObject creates an iterator over the members of raw JSON object `s`
+func NewJSONObject(s string) JSONObject {
+	o := JSONObject{s: s}
+	o.pos = skipWhitespace(s, 0)
+	switch {
+	case o.pos == len(s):
+		o.s = ""
+	case strings.TrimRight(s[o.pos:], " \t\r\n") == "null":
+		o.s = ""
+	case s[o.pos] != '{':
+		o.err = fmt.Errorf("%w: expected object", ErrJSONSyntax)
+	default:
+		o.pos++
+	}
+	return o
+}
+
+// Next advances to the next member. Returns false at the end of the object
+// or on error.
+func (o *JSONObject) Next() bool {
+	if o.err != nil || o.s == "" {
+		return false
+	}
+	i := skipWhitespace(o.s, o.pos)
+	if i < len(o.s) && o.s[i] == '}' {
+		o.s = ""
+		return false
+	}
+	if o.started {
+		if i == len(o.s) || o.s[i] != ',' {
+			o.setErr(fmt.Errorf("%w: expected , or }", ErrJSONSyntax))
+			return false
+		}
+		i = skipWhitespace(o.s, i+1)
+	}
+	end, err := scanKey(o.s, i)
+	if err != nil {
+		o.setErr(err)
+		return false
+	}
+	key, err := JSONString(o.s[i:end])
+	if err != nil {
+		o.setErr(err)
+		return false
+	}
+	i = skipWhitespace(o.s, end)
+	if i == len(o.s) || o.s[i] != ':' {
+		o.setErr(fmt.Errorf("%w: expected :", ErrJSONSyntax))
+		return false
+	}
+	i = skipWhitespace(o.s, i+1)
+	end, err = scanValue(o.s, i)
+	if err != nil {
+		o.setErr(err)
+		return false
+	}
+	o.key = key
+	o.value = o.s[i:end]
+	o.pos = end
+	o.started = true
+	return true
+}
+
+func (o *JSONObject) setErr(err error) {
+	o.err = err
+}
+
+// Key returns the key of the current member
+func (o *JSONObject) Key() string {
+	return o.key
+}
+
+// Value returns the raw JSON value of the current member
+func (o *JSONObject) Value() string {
+	return o.value
+}
+
+// Err returns the error, which stopped iteration
+func (o *JSONObject) Err() error {
+	return o.err
+}
+
+// JSONInt decodes a raw JSON number. `null` decodes to 0.
+func JSONInt(s string) (int64, error) {
+	if s == "null" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s is not an integer", ErrJSONSyntax, s)
+	}
+	return v, nil
+}
+
+// JSONBool decodes a raw JSON boolean. `null` decodes to false.
+func JSONBool(s string) (bool, error) {
+	switch s {
+	case "true":
+		return true, nil
+	case "false", "null":
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w: %s is not a boolean", ErrJSONSyntax, s)
+	}
+}
+
+// JSONString decodes a raw JSON string. `null` decodes to "".
+// Without escape sequences the result shares memory with `s`.
+func JSONString(s string) (string, error) {
+	if s == "null" {
+		return "", nil
+	}
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("%w: %s is not a string", ErrJSONSyntax, s)
+	}
+	if !strings.ContainsRune(s, '\\') {
+		return s[1 : len(s)-1], nil
+	}
+	var v string
+	err := DecodeJSON(s, &v)
+	return v, err
+}
+
+// DecodeJSON unmarshals the raw JSON value `s` into v. Errors wrap
+// ErrJSONSyntax.
+func DecodeJSON(s string, v interface{}) error {
+	err := json.Unmarshal([]byte(s), v)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrJSONSyntax, err)
+	}
+	return nil
+}
+
+// ValidJSON reports whether `s` consists of exactly one structurally
+// valid JSON value. Members and elements have to be separated by `,` and
+// keys by `:`, but scalars are only checked when decoding them.
+func ValidJSON(s string) bool {
+	i := skipWhitespace(s, 0)
+	end, err := scanValue(s, i)
+	if err != nil {
+		return false
+	}
+	return skipWhitespace(s, end) == len(s)
+}
+
+// JSONFieldError adds the JSON key of the value to a decoding error
+func JSONFieldError(key string, err error) error {
+	return fmt.Errorf("%s: %w", key, err)
+}
+
+// CloneLabels copies labels so they do not share memory with a
+// (potentially huge) JSON document.
+func CloneLabels(ls prometheus.Labels) prometheus.Labels {
+	cloned := make(prometheus.Labels, len(ls))
+	for k, v := range ls {
+		cloned[strings.Clone(k)] = strings.Clone(v)
+	}
+	return cloned
+}
+
+func skipWhitespace(s string, i int) int {
+	for i < len(s) {
+		switch s[i] {
+		case ' ', '\t', '\r', '\n':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// scanValue returns the end of the JSON value starting at `i`
+func scanValue(s string, i int) (int, error) {
+	if i >= len(s) {
+		return i, fmt.Errorf("%w: unexpected end", ErrJSONSyntax)
+	}
+	switch s[i] {
+	case '"':
+		return scanString(s, i)
+	case '{':
+		return scanObject(s, i)
+	case '[':
+		return scanArray(s, i)
+	default:
+		start := i
+		for i < len(s) && isScalarByte(s[i]) {
+			i++
+		}
+		if i == start {
+			return i, fmt.Errorf("%w: expected value", ErrJSONSyntax)
+		}
+		return i, nil
+	}
+}
+
+// isScalarByte reports whether `b` may be part of a number or a
+// true/false/null literal
+func isScalarByte(b byte) bool {
+	switch {
+	case b >= '0' && b <= '9', b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z':
+		return true
+	}
+	switch b {
+	case '-', '+', '.':
+		return true
+	}
+	return false
+}
+
+func scanObject(s string, i int) (int, error) {
+	i = skipWhitespace(s, i+1)
+	if i < len(s) && s[i] == '}' {
+		return i + 1, nil
+	}
+	for {
+		end, err := scanKey(s, i)
+		if err != nil {
+			return end, err
+		}
+		i = skipWhitespace(s, end)
+		if i == len(s) || s[i] != ':' {
+			return i, fmt.Errorf("%w: expected :", ErrJSONSyntax)
+		}
+		end, err = scanValue(s, skipWhitespace(s, i+1))
+		if err != nil {
+			return end, err
+		}
+		var closed bool
+		i, closed, err = scanSeparator(s, end, '}')
+		if err != nil || closed {
+			return i, err
+		}
+	}
+}
+
+func scanArray(s string, i int) (int, error) {
+	i = skipWhitespace(s, i+1)
+	if i < len(s) && s[i] == ']' {
+		return i + 1, nil
+	}
+	for {
+		end, err := scanValue(s, i)
+		if err != nil {
+			return end, err
+		}
+		var closed bool
+		i, closed, err = scanSeparator(s, end, ']')
+		if err != nil || closed {
+			return i, err
+		}
+	}
+}
+
+// scanSeparator skips the `,` before the next member or element or the
+// `closing` bracket, in which case `closed` is set.
+func scanSeparator(s string, i int, closing byte) (next int, closed bool, err error) {
+	i = skipWhitespace(s, i)
+	if i == len(s) {
+		return i, false, fmt.Errorf("%w: unexpected end", ErrJSONSyntax)
+	}
+	switch s[i] {
+	case closing:
+		return i + 1, true, nil
+	case ',':
+		return skipWhitespace(s, i+1), false, nil
+	}
+	return i, false, fmt.Errorf("%w: expected , or %c", ErrJSONSyntax, closing)
+}
+
+func scanKey(s string, i int) (int, error) {
+	if i == len(s) || s[i] != '"' {
+		return i, fmt.Errorf("%w: expected key", ErrJSONSyntax)
+	}
+	return scanString(s, i)
+}
+
+func scanString(s string, i int) (int, error) {
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return i, fmt.Errorf("%w: unterminated string", ErrJSONSyntax)
+}
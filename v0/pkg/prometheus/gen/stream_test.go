@@ -0,0 +1,103 @@
+package gen
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJSONObject(t *testing.T) {
+	o := NewJSONObject(` { "a" : 1, "b\"c": {"d": [1, "}"]}, "e": "f" } `)
+	got := map[string]string{}
+	for o.Next() {
+		got[o.Key()] = o.Value()
+	}
+	if o.Err() != nil {
+		t.Fatal("Iterating failed:", o.Err())
+	}
+	expected := map[string]string{
+		"a":   "1",
+		`b"c`: `{"d": [1, "}"]}`,
+		"e":   `"f"`,
+	}
+	d := cmp.Diff(expected, got)
+	if d != "" {
+		t.Fatal("Diff", d)
+	}
+
+	o = NewJSONObject(`{"": 1, "b": 2}`)
+	var keys []string
+	for o.Next() {
+		keys = append(keys, o.Key())
+	}
+	if o.Err() != nil {
+		t.Fatal("Iterating empty key failed:", o.Err())
+	}
+	d = cmp.Diff([]string{"", "b"}, keys)
+	if d != "" {
+		t.Fatal("Diff", d)
+	}
+
+	for _, malformed := range []string{`{"a": 1 "b": 2}`, `{"a": 1,}`, `{"a" 1}`, `{"a": 1`, `{,"a": 1}`, `nullx`, `null {}`} {
+		o = NewJSONObject(malformed)
+		for o.Next() {
+		}
+		if !errors.Is(o.Err(), ErrJSONSyntax) {
+			t.Errorf("Expected syntax error for %q. Got: %v", malformed, o.Err())
+		}
+	}
+
+	for _, empty := range []string{"", "null", " null\n", "{}"} {
+		o = NewJSONObject(empty)
+		if o.Next() || o.Err() != nil {
+			t.Errorf("Expected %q to be empty", empty)
+		}
+	}
+}
+
+func TestValidJSON(t *testing.T) {
+	for data, valid := range map[string]bool{
+		`{"a": {"b": [1, 2]}}`: true,
+		` {} `:                 true,
+		`{"a": {"b": [1, 2]}`:  false,
+		`{"a": "b}`:            false,
+		`{} {}`:                false,
+		``:                     false,
+		`{"a": 1 "b": 2}`:      false,
+		`{"a" 1}`:              false,
+		`{"a": 1,}`:            false,
+		`{"a": [1 2]}`:         false,
+		`[1, 2,]`:              false,
+		`{"a": [1, 2}]`:        false,
+		`{1: 2}`:               false,
+		`{"": 1, "b": 2}`:      true,
+	} {
+		if ValidJSON(data) != valid {
+			t.Errorf("Expected ValidJSON(%q) to be %t", data, valid)
+		}
+	}
+}
+
+func TestJSONScalars(t *testing.T) {
+	i, err := JSONInt("-42")
+	if err != nil || i != -42 {
+		t.Errorf("Unexpected JSONInt result %d, %v", i, err)
+	}
+	_, err = JSONInt(`"42"`)
+	if !errors.Is(err, ErrJSONSyntax) {
+		t.Errorf("Expected syntax error for string. Got: %v", err)
+	}
+	s, err := JSONString(`"aä\n"`)
+	if err != nil || s != "aä\n" {
+		t.Errorf("Unexpected JSONString result %q, %v", s, err)
+	}
+	_, err = JSONString(`"a\x"`)
+	if !errors.Is(err, ErrJSONSyntax) {
+		t.Errorf("Expected syntax error for invalid escape. Got: %v", err)
+	}
+	b, err := JSONBool("null")
+	if err != nil || b {
+		t.Errorf("Unexpected JSONBool result %t, %v", b, err)
+	}
+}
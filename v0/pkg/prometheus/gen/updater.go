@@ -1,12 +1,8 @@
 package gen
 
 import (
-	"reflect"
+	"fmt"
 
-	"github.com/abergmeier/kafka_stats_exporter/internal/assert"
-	"github.com/abergmeier/kafka_stats_exporter/internal/collector"
-	"github.com/abergmeier/kafka_stats_exporter/internal/label"
-	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/types"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -15,90 +11,140 @@ type Updater interface {
 	Update(v interface{}, labels prometheus.Labels)
 }
 
+// updater records values in the Prometheus metrics of a prometheusSink
 type updater struct {
-	c                   *collector.Collectors
-	labelNameTransform  types.LabelNameTransformer
-	metricNameTransform types.MetricNameTransformer
+	rec  *Recorder
+	sink *prometheusSink
 }
 
 func (u *updater) Update(v interface{}, labels prometheus.Labels) {
-	newLabels := make(prometheus.Labels, len(labels))
-	for k, v := range labels {
-		newLabels[k] = v
+	u.sink.epoch++
+	u.rec.record(v, labels, u.sink)
+	u.sink.deleteStale()
+}
+
+// prometheusSink is a MetricSink setting Prometheus metrics. Series, which
+// are not recorded by an update (e.g. of map entries, which are gone or
+// changed their labels), are deleted afterwards.
+type prometheusSink struct {
+	metrics []*prometheusMetric          // By index of MetricDesc
+	byName  map[string]*prometheusMetric // By metric name
+	series  map[string]*prometheusSeries // By metric name and label values
+	epoch   uint64                       // Incremented on every update
+	values  []string                     // Reused for label values
+	key     []byte                       // Reused for series keys
+}
+
+type prometheusMetric struct {
+	collector  prometheus.Collector
+	counterVec *prometheus.CounterVec
+	gaugeVec   *prometheus.GaugeVec
+}
+
+type prometheusSeries struct {
+	epoch  uint64 // Of the sink at the last update
+	metric *prometheusMetric
+	values []string // Label values
+	last   float64  // Of counters
+	// Children bound to the label values, so steady state updates do not
+	// hash labels
+	counter prometheus.Counter
+	gauge   prometheus.Gauge
+}
+
+// newPrometheusSink creates the metrics described by o
+func newPrometheusSink(o *GeneratedOptions) *prometheusSink {
+	s := &prometheusSink{
+		byName: map[string]*prometheusMetric{},
+		series: map[string]*prometheusSeries{},
 	}
-	for k, v := range u.c.Rlr.Lr.LabelsForValue(v) {
-		newLabels[u.labelNameTransform(k)] = v
+	for _, d := range o.descs {
+		m := &prometheusMetric{}
+		switch d.Type {
+		case "counter":
+			m.counterVec = prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name:        d.Name,
+				Help:        d.Help,
+				ConstLabels: o.opts.ConstLabels,
+			}, d.LabelNames)
+			m.collector = m.counterVec
+		case "gauge":
+			m.gaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        d.Name,
+				Help:        d.Help,
+				ConstLabels: o.opts.ConstLabels,
+			}, d.LabelNames)
+			m.collector = m.gaugeVec
+		default:
+			panic(fmt.Sprintf("Unsupported metric type: %s", d.Type))
+		}
+		s.metrics = append(s.metrics, m)
+		s.byName[d.Name] = m
 	}
-
-	u.update(v, newLabels)
+	return s
 }
 
-func (u *updater) update(v interface{}, labels prometheus.Labels) {
-	rv := reflect.ValueOf(v)
-	switch rv.Kind() {
-	case reflect.Pointer:
-		rv = rv.Elem()
+// Record implements MetricSink. Counters add the increase since the last
+// update of the series.
+func (s *prometheusSink) Record(desc *MetricDesc, labels map[string]string, value float64) {
+	s.values = s.values[:0]
+	s.key = append(s.key[:0], desc.Name...)
+	for _, name := range desc.LabelNames {
+		s.values = append(s.values, labels[name])
+		s.key = append(s.key, 0)
+		s.key = append(s.key, labels[name]...)
 	}
-	assert.AssertType(rv, u.c.T)
-	for _, c := range u.c.StaticCollectors {
-		fv := rv.FieldByIndex([]int{c.Index})
-		if !fv.CanInt() {
-			panic("Only in update implemented yet")
+	se, ok := s.series[string(s.key)]
+	if !ok {
+		m := s.byName[desc.Name]
+		se = &prometheusSeries{
+			metric: m,
+			values: append([]string(nil), s.values...),
 		}
-		current := fv.Int()
-		c.Update(c.Last, current, labels)
-		c.Last = current
+		switch {
+		case m.counterVec != nil:
+			se.counter = m.counterVec.WithLabelValues(se.values...)
+		case m.gaugeVec != nil:
+			se.gauge = m.gaugeVec.WithLabelValues(se.values...)
+		}
+		s.series[string(s.key)] = se
 	}
-	// Up until here we could do statically initialize
-	// all data. Here map keys can change while runtime
-	// thus we need to handle
-	for _, m := range u.c.Maps {
-		fv := rv.FieldByIndex([]int{m.IndexInStruct})
-		assert.AssertMap(fv)
-		updateMapped(&m, u.c.Rlr.Fields[m.IndexInStruct], fv, u.metricNameTransform)
-		for mk, mc := range m.Mapped {
-			// While we only support strings and ints we usually use an alias to
-			// make semantics clear. Thus we need to convert from string or int
-			// to alias here.
-			aliased := mk.Convert(fv.Type().Key())
-			mv := fv.MapIndex(aliased)
-			(&updater{
-				c:                   mc,
-				labelNameTransform:  u.labelNameTransform,
-				metricNameTransform: u.metricNameTransform,
-			}).Update(mv.Interface(), labels)
+	se.epoch = s.epoch
+	switch {
+	case se.counter != nil:
+		if diff := value - se.last; diff > 0 {
+			se.counter.Add(diff)
 		}
+		se.last = value
+	case se.gauge != nil:
+		se.gauge.Set(value)
 	}
 }
 
-func updateMapped(d *collector.DynamicMap, rlr *label.RecursiveReflector, fv reflect.Value, metricNameTransform types.MetricNameTransformer) {
-
-	keysToDelete := make(map[reflect.Value]struct{}, len(d.Mapped))
-	for k := range d.Mapped {
-		keysToDelete[k] = struct{}{}
-	}
-
-	iter := fv.MapRange()
-	for iter.Next() {
-		rk := iter.Key()
-		_, ok := d.Mapped[rk]
-		if ok {
-			// We can reuse map entry
-			delete(keysToDelete, rk)
+// deleteStale deletes the series not recorded in the current epoch
+func (s *prometheusSink) deleteStale() {
+	for key, se := range s.series {
+		if se.epoch == s.epoch {
 			continue
 		}
-		vt := iter.Value().Type()
-		cu := &collector.Collectors{}
-		if d.StructParent == "" {
-			cu.Fill(vt, rlr, d.FieldName, metricNameTransform)
-		} else {
-			cu.Fill(vt, rlr, d.StructParent+"_"+d.FieldName, metricNameTransform)
+		switch {
+		case se.metric.counterVec != nil:
+			se.metric.counterVec.DeleteLabelValues(se.values...)
+		case se.metric.gaugeVec != nil:
+			se.metric.gaugeVec.DeleteLabelValues(se.values...)
 		}
-		// We need new map entry
-		d.Mapped[rk] = cu
+		delete(s.series, key)
 	}
-	// Delete superfluous keys
-	for k := range keysToDelete {
-		delete(d.Mapped, k)
+}
+
+func (s *prometheusSink) Describe(c chan<- *prometheus.Desc) {
+	for _, m := range s.metrics {
+		m.collector.Describe(c)
+	}
+}
+
+func (s *prometheusSink) Collect(c chan<- prometheus.Metric) {
+	for _, m := range s.metrics {
+		m.collector.Collect(c)
 	}
 }
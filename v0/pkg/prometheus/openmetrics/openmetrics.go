@@ -0,0 +1,269 @@
+// Package openmetrics exposes gathered metrics in the OpenMetrics text
+// format. Unlike the generic encoder of package expfmt it knows the metrics
+// exported from Stats: counters get `_created` timestamps derived from the
+// `time` and `age` of the Stats of their client and metrics get their unit.
+package openmetrics
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// StatsSource provides the last Stats of a client (e.g. `v0.Exporter`)
+type StatsSource interface {
+	Stats() *typed.Stats
+}
+
+// NewHandler creates a http.Handler serving the metrics of g. OpenMetrics
+// is served when negotiated via the Accept header, otherwise requests are
+// handled like by promhttp.HandlerFor. Metrics are described by naming (see
+// `v0.MetricNaming`). Counters of the clients of sources get `_created`
+// timestamps.
+func NewHandler(g prometheus.Gatherer, naming *gen.GeneratedOptions, sources ...StatsSource) http.Handler {
+	fallback := promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if expfmt.NegotiateIncludingOpenMetrics(r.Header) != expfmt.FmtOpenMetrics {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		mfs, err := g.Gather()
+		if err != nil {
+			http.Error(w, "An error has occurred while serving metrics:\n\n"+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		stats := make([]*typed.Stats, len(sources))
+		for i, src := range sources {
+			stats[i] = src.Stats()
+		}
+		b := &bytes.Buffer{}
+		err = Write(b, mfs, naming, stats...)
+		if err != nil {
+			http.Error(w, "An error has occurred while serving metrics:\n\n"+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", string(expfmt.FmtOpenMetrics))
+		// Failing to write means the client is gone
+		b.WriteTo(w)
+	})
+}
+
+// Write writes mfs in the OpenMetrics text format terminated by `# EOF`.
+// Counters and gauges described by naming get their unit if their name
+// ends with it as required by OpenMetrics. Counters of the clients of stats
+// get a `_created` timestamp. Other metric families are written like by
+// expfmt.
+func Write(w io.Writer, mfs []*dto.MetricFamily, naming *gen.GeneratedOptions, stats ...*typed.Stats) error {
+	ew := &errWriter{
+		bw: bufio.NewWriter(w),
+	}
+	c := newCreated(stats, naming)
+	units := map[string]string{}
+	for _, d := range naming.Describe() {
+		units[d.Name] = d.Unit
+	}
+	for _, mf := range mfs {
+		var err error
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER, dto.MetricType_GAUGE:
+			err = writeFamily(ew, mf, units[mf.GetName()], c)
+		default:
+			_, err = expfmt.MetricFamilyToOpenMetrics(ew.bw, mf)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	ew.writeString("# EOF\n")
+	if ew.err != nil {
+		return ew.err
+	}
+	return ew.bw.Flush()
+}
+
+// errWriter keeps the first error of writing, after which writes are
+// skipped
+type errWriter struct {
+	bw  *bufio.Writer
+	err error
+}
+
+func (ew *errWriter) writeString(s string) {
+	if ew.err == nil {
+		_, ew.err = ew.bw.WriteString(s)
+	}
+}
+
+func (ew *errWriter) writeByte(c byte) {
+	if ew.err == nil {
+		ew.err = ew.bw.WriteByte(c)
+	}
+}
+
+func (ew *errWriter) writeEscaped(s string) {
+	if ew.err == nil {
+		_, ew.err = escaper.WriteString(ew.bw, s)
+	}
+}
+
+// writeFamily returns the first error of writing mf
+func writeFamily(ew *errWriter, mf *dto.MetricFamily, unit string, c *created) error {
+	name := mf.GetName()
+	counter := mf.GetType() == dto.MetricType_COUNTER && strings.HasSuffix(name, "_total")
+	family := name
+	typ := "gauge"
+	if counter {
+		family = strings.TrimSuffix(name, "_total")
+		typ = "counter"
+	} else if mf.GetType() == dto.MetricType_COUNTER {
+		// OpenMetrics counters have to end with `_total`
+		typ = "unknown"
+	}
+
+	if mf.Help != nil {
+		ew.writeString("# HELP " + family + " ")
+		ew.writeEscaped(mf.GetHelp())
+		ew.writeByte('\n')
+	}
+	ew.writeString("# TYPE " + family + " " + typ + "\n")
+	if unit != "" && strings.HasSuffix(family, "_"+unit) {
+		ew.writeString("# UNIT " + family + " " + unit + "\n")
+	}
+	for _, m := range mf.GetMetric() {
+		if mf.GetType() == dto.MetricType_COUNTER {
+			writeSample(ew, name, m.GetLabel(), m.GetCounter().GetValue(), m)
+			if ts, ok := c.of(m); ok && counter {
+				writeSample(ew, family+"_created", m.GetLabel(), ts, nil)
+			}
+			continue
+		}
+		writeSample(ew, name, m.GetLabel(), m.GetGauge().GetValue(), m)
+	}
+	return ew.err
+}
+
+// writeSample writes a sample with the timestamp of m (if any)
+func writeSample(ew *errWriter, name string, labels []*dto.LabelPair, value float64, m *dto.Metric) {
+	ew.writeString(name)
+	for i, l := range labels {
+		if i == 0 {
+			ew.writeByte('{')
+		} else {
+			ew.writeByte(',')
+		}
+		ew.writeString(l.GetName() + `="`)
+		ew.writeEscaped(l.GetValue())
+		ew.writeByte('"')
+	}
+	if len(labels) != 0 {
+		ew.writeByte('}')
+	}
+	ew.writeByte(' ')
+	ew.writeString(formatFloat(value))
+	if m != nil && m.TimestampMs != nil {
+		ew.writeByte(' ')
+		ew.writeString(formatFloat(float64(m.GetTimestampMs()) / 1000))
+	}
+	ew.writeByte('\n')
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// formatFloat formats like expfmt. OpenMetrics requires integral values
+// to be distinguishable from integers.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, +1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, "e.") {
+		s += ".0"
+	}
+	return s
+}
+
+// created holds the creation time of clients (in seconds since the epoch)
+// by the values of the labels of the client
+type created struct {
+	labelNames []string
+	byClient   map[string]float64
+}
+
+// newCreated derives the creation time of clients from the `time` (seconds
+// since the epoch) and `age` (microseconds) of their Stats. Unlike the
+// exported counters these reflect restarts of the client.
+func newCreated(stats []*typed.Stats, naming *gen.GeneratedOptions) *created {
+	c := &created{
+		labelNames: []string{
+			naming.LabelName("", "name"),
+			naming.LabelName("", "client_id"),
+			naming.LabelName("", "type"),
+		},
+		byClient: map[string]float64{},
+	}
+	for _, s := range stats {
+		// Clients report neither before their first statistics interval
+		if s == nil || s.Time == 0 {
+			continue
+		}
+		key := clientKey(s.Name, s.ClientId, s.Type)
+		c.byClient[key] = float64(s.Time) - float64(s.Age)/1e6
+	}
+	return c
+}
+
+func clientKey(values ...string) string {
+	var b strings.Builder
+	for _, v := range values {
+		b.WriteString(v)
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// key joins the values of the client labels of m. Fails if m is missing
+// any of them.
+func (c *created) key(m *dto.Metric) (string, bool) {
+	var b strings.Builder
+	for _, ln := range c.labelNames {
+		found := false
+		for _, l := range m.GetLabel() {
+			if l.GetName() == ln {
+				b.WriteString(l.GetValue())
+				b.WriteByte(0)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// of returns the creation time of the client of m
+func (c *created) of(m *dto.Metric) (float64, bool) {
+	key, ok := c.key(m)
+	if !ok {
+		return 0, false
+	}
+	ts, ok := c.byClient[key]
+	return ts, ok
+}
@@ -0,0 +1,243 @@
+package openmetrics
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	v0 "github.com/abergmeier/kafka_stats_exporter/v0"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// gatherStats updates an Exporter with stats and returns the metrics
+// exported from them
+func gatherStats(t *testing.T, stats ...string) (*prometheus.Registry, v0.Exporter, []*dto.MetricFamily) {
+	r := prometheus.NewRegistry()
+	e := v0.NewExporter(r)
+	for _, s := range stats {
+		err := e.UpdateWithStatString(s)
+		if err != nil {
+			t.Fatal("UpdateWithStatString failed:", err)
+		}
+	}
+	mfs, err := r.Gather()
+	if err != nil {
+		t.Fatal("Gather failed:", err)
+	}
+	filtered := mfs[:0]
+	for _, mf := range mfs {
+		if !strings.HasPrefix(mf.GetName(), "kafka_stats_exporter_") {
+			filtered = append(filtered, mf)
+		}
+	}
+	return r, e, filtered
+}
+
+func TestWriteFull(t *testing.T) {
+	stats, err := os.ReadFile("../gen/testdata/full.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, e, mfs := gatherStats(t, string(stats))
+	naming, err := v0.MetricNaming()
+	if err != nil {
+		t.Fatal("MetricNaming failed:", err)
+	}
+	b := &bytes.Buffer{}
+	err = Write(b, mfs, naming, e.Stats())
+	if err != nil {
+		t.Fatal("Write failed:", err)
+	}
+	expected, err := os.ReadFile("testdata/full_expected.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(expected), b.String()); diff != "" {
+		t.Fatal("Unexpected OpenMetrics (-want +got):\n", diff)
+	}
+}
+
+func TestWriteCreated(t *testing.T) {
+	tests := map[string]struct {
+		stats   []string
+		created string
+	}{
+		"first": {
+			stats:   []string{`{"name": "rdkafka#producer-1", "client_id": "rdkafka", "type": "producer", "time": 1700000060, "age": 60500000, "tx_bytes": 1024}`},
+			created: "1.6999999995e+09",
+		},
+		// Counters of a restarted client start anew
+
+		"restarted": {
+			stats: []string{
+				`{"name": "rdkafka#producer-1", "client_id": "rdkafka", "type": "producer", "time": 1700000060, "age": 60500000, "tx_bytes": 512}`,
+				`{"name": "rdkafka#producer-1", "client_id": "rdkafka", "type": "producer", "time": 1700000120, "age": 10000000, "tx_bytes": 1024}`,
+			},
+			created: "1.70000011e+09",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, e, mfs := gatherStats(t, test.stats...)
+			naming, err := v0.MetricNaming()
+			if err != nil {
+				t.Fatal("MetricNaming failed:", err)
+			}
+			b := &bytes.Buffer{}
+			err = Write(b, mfs, naming, e.Stats())
+			if err != nil {
+				t.Fatal("Write failed:", err)
+			}
+			expected := `# HELP tx_bytes Total number of bytes transmitted to Kafka brokers
+# TYPE tx_bytes counter
+# UNIT tx_bytes bytes
+tx_bytes_total{client_id="rdkafka",name="rdkafka#producer-1",type="producer"} 1024.0
+tx_bytes_created{client_id="rdkafka",name="rdkafka#producer-1",type="producer"} ` + test.created + `
+`
+			if !strings.Contains(b.String(), expected) {
+				t.Fatalf("Expected:\n%s\nGot:\n%s", expected, b.String())
+			}
+			if !strings.HasSuffix(b.String(), "\n# EOF\n") {
+				t.Fatal("Expected OpenMetrics to be terminated by # EOF")
+			}
+		})
+	}
+}
+
+func TestWriteWithoutClient(t *testing.T) {
+	c := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "requests_total",
+		Help: "Requests",
+	})
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "duration_seconds",
+		Help:    "Duration",
+		Buckets: []float64{1},
+	})
+	c.Inc()
+	h.Observe(0.5)
+	r := prometheus.NewRegistry()
+	r.MustRegister(c, h)
+	mfs, err := r.Gather()
+	if err != nil {
+		t.Fatal("Gather failed:", err)
+	}
+	naming, err := v0.MetricNaming()
+	if err != nil {
+		t.Fatal("MetricNaming failed:", err)
+	}
+	b := &bytes.Buffer{}
+	err = Write(b, mfs, naming)
+	if err != nil {
+		t.Fatal("Write failed:", err)
+	}
+	expected := `# HELP duration_seconds Duration
+# TYPE duration_seconds histogram
+duration_seconds_bucket{le="1.0"} 1
+duration_seconds_bucket{le="+Inf"} 1
+duration_seconds_sum 0.5
+duration_seconds_count 1
+# HELP requests Requests
+# TYPE requests counter
+requests_total 1.0
+# EOF
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Fatal("Unexpected OpenMetrics (-want +got):\n", diff)
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	r, e, _ := gatherStats(t, `{"name": "rdkafka#producer-1", "time": 1700000060, "age": 60000000}`)
+	naming, err := v0.MetricNaming()
+	if err != nil {
+		t.Fatal("MetricNaming failed:", err)
+	}
+	s := httptest.NewServer(NewHandler(r, naming, e))
+	defer s.Close()
+
+	tests := map[string]struct {
+		accept      string
+		contentType expfmt.Format
+		eof         bool
+	}{
+		"openmetrics": {
+			accept:      "application/openmetrics-text; version=0.0.1,text/plain;version=0.0.4;q=0.5,*/*;q=0.1",
+			contentType: expfmt.FmtOpenMetrics,
+			eof:         true,
+		},
+		"text": {
+			accept:      "text/plain",
+			contentType: expfmt.FmtText,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", test.accept)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal("Request failed:", err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Header.Get("Content-Type") != string(test.contentType) {
+				t.Fatalf("Expected Content-Type %s. Got: %s", test.contentType, resp.Header.Get("Content-Type"))
+			}
+			if test.eof != strings.HasSuffix(string(body), "# EOF\n") {
+				t.Fatalf("Expected # EOF %t. Got:\n%s", test.eof, body)
+			}
+			if test.eof != strings.Contains(string(body), "\nage_created{") {
+				t.Fatalf("Expected _created %t. Got:\n%s", test.eof, body)
+			}
+		})
+	}
+}
+
+// failingWriter fails once n bytes are written
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errors.New("write failed")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestWriteFails(t *testing.T) {
+	stats, err := os.ReadFile("../gen/testdata/full.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, e, mfs := gatherStats(t, string(stats))
+	naming, err := v0.MetricNaming()
+	if err != nil {
+		t.Fatal("MetricNaming failed:", err)
+	}
+	// Fail in the middle of the metrics and when flushing `# EOF`
+	for _, n := range []int{10000, 0} {
+		err = Write(&failingWriter{n: n}, mfs, naming, e.Stats())
+		if err == nil {
+			t.Fatalf("Expected Write to fail after %d bytes", n)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package remotewrite
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Option represents an opaque option implementation
+// for creating a Client
+type Option interface {
+}
+
+// WithQueueSize creates an Option for limiting the number of requests
+// waiting to be sent. The oldest request is dropped once the queue is full.
+// Defaults to 10.
+func WithQueueSize(size int) Option {
+	return &clientQueueSize{
+		size: size,
+	}
+}
+
+// WithRetries creates an Option for setting how often a request is retried
+// after failing with a network error, 429 or 5xx. Defaults to 3.
+func WithRetries(retries int) Option {
+	return &clientRetries{
+		retries: retries,
+	}
+}
+
+// WithBackoff creates an Option for setting the delay before the first
+// retry. The delay doubles for every further retry. Defaults to 1s.
+func WithBackoff(d time.Duration) Option {
+	return &clientBackoff{
+		d: d,
+	}
+}
+
+// WithHTTPClient creates an Option for sending requests with c (e.g. for
+// timeouts or TLS). Defaults to a http.Client with a timeout of 30s.
+func WithHTTPClient(c *http.Client) Option {
+	return &clientHTTPClient{
+		c: c,
+	}
+}
+
+type clientQueueSize struct {
+	size int
+}
+
+type clientRetries struct {
+	retries int
+}
+
+type clientBackoff struct {
+	d time.Duration
+}
+
+type clientHTTPClient struct {
+	c *http.Client
+}
+
+// ErrClientClosed is returned when sending with a closed Client
+var ErrClientClosed = errors.New("remote-write client is closed")
+
+// Client sends metrics to a remote-write endpoint in the background.
+// Requests are sent in order, one at a time.
+type Client struct {
+	url     string
+	http    *http.Client
+	retries int
+	backoff time.Duration
+
+	queue   chan []byte   // Compressed requests
+	stop    chan struct{} // Closed by Close to stop waiting for retries
+	dropped int64         // Atomic
+	wg      sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+	err    error // Last request, which failed permanently
+}
+
+// NewClient creates a Client sending to the remote-write endpoint url
+// (e.g. `http://localhost:9090/api/v1/write`). The Client must be closed
+// to stop sending.
+func NewClient(url string, opts ...Option) *Client {
+	c := &Client{
+		url:     url,
+		http:    &http.Client{Timeout: 30 * time.Second},
+		retries: 3,
+		backoff: time.Second,
+	}
+	size := 10
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case *clientQueueSize:
+			size = o.size
+		case *clientRetries:
+			c.retries = o.retries
+		case *clientBackoff:
+			c.backoff = o.d
+		case *clientHTTPClient:
+			c.http = o.c
+		default:
+			panic(fmt.Sprintf("Unrecognized option %#v", opt))
+		}
+	}
+	if size < 1 {
+		panic(fmt.Sprintf("Invalid queue size %d", size))
+	}
+	c.queue = make(chan []byte, size)
+	c.stop = make(chan struct{})
+	c.wg.Add(1)
+	go c.run()
+	return c
+}
+
+// Send queues mfs (e.g. from prometheus.Gatherer) to be sent. Samples
+// without a timestamp are timestamped with now. Fails with ErrClientClosed
+// after Close.
+func (c *Client) Send(mfs []*dto.MetricFamily, now time.Time) error {
+	req := snappy.Encode(nil, Encode(mfs, now))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClientClosed
+	}
+	for {
+		select {
+		case c.queue <- req:
+			return nil
+		default:
+		}
+		select {
+		case <-c.queue:
+			atomic.AddInt64(&c.dropped, 1)
+		default:
+		}
+	}
+}
+
+// Dropped returns the number of requests dropped due to a full queue
+func (c *Client) Dropped() int {
+	return int(atomic.LoadInt64(&c.dropped))
+}
+
+// Close sends the queued requests and stops the Client. Does not wait for
+// retries, so requests failing from then on are not retried. Returns the
+// error of the last request, which could not be sent.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClientClosed
+	}
+	c.closed = true
+	close(c.stop)
+	close(c.queue)
+	c.mu.Unlock()
+
+	c.wg.Wait()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Client) run() {
+	defer c.wg.Done()
+	for req := range c.queue {
+		err := c.send(req)
+		if err != nil {
+			c.mu.Lock()
+			c.err = err
+			c.mu.Unlock()
+		}
+	}
+}
+
+// send posts req and retries recoverable failures until the Client is
+// closed
+func (c *Client) send(req []byte) error {
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := c.post(req)
+		if err == nil || !retry || attempt == c.retries {
+			return err
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-c.stop:
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+}
+
+// post sends req once. Reports whether a failure is worth retrying.
+func (c *Client) post(req []byte) (bool, error) {
+	r, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(req))
+	if err != nil {
+		return false, err
+	}
+	r.Header.Set("Content-Encoding", "snappy")
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	r.Header.Set("User-Agent", "kafka_stats_exporter")
+	r.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	resp, err := c.http.Do(r)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("unexpected status code %d while sending to %s: %s", resp.StatusCode, c.url, body)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5, err
+}
@@ -0,0 +1,348 @@
+package remotewrite
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// receiver is a remote-write endpoint recording the decoded series
+type receiver struct {
+	mu       sync.Mutex
+	requests int
+	series   [][]string
+	status   []int // Of the requests, 204 afterwards
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests++
+	if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+		http.Error(w, "unexpected headers", http.StatusBadRequest)
+		return
+	}
+	if len(rc.status) != 0 {
+		status := rc.status[0]
+		rc.status = rc.status[1:]
+		if status/100 != 2 {
+			http.Error(w, "failed", status)
+			return
+		}
+	}
+	compressed, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	series, err := decodeWriteRequest(b)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rc.series = append(rc.series, series)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// waitRequests waits until rc received n requests
+func (rc *receiver) waitRequests(t *testing.T, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rc.mu.Lock()
+		requests := rc.requests
+		rc.mu.Unlock()
+		if requests >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d requests. Got: %d", n, requests)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// decodeWriteRequest returns the series of a WriteRequest in the form
+// `{__name__="a", b="c"} 1 @1000`
+func decodeWriteRequest(b []byte) ([]string, error) {
+	var series []string
+	err := decodeMessage(b, func(num protowire.Number, v []byte) error {
+		if num != writeRequestTimeseries {
+			return fmt.Errorf("unexpected field %d of WriteRequest", num)
+		}
+		var labels, samples []string
+		err := decodeMessage(v, func(num protowire.Number, v []byte) error {
+			switch num {
+			case timeSeriesLabels:
+				var l label
+				err := decodeMessage(v, func(num protowire.Number, v []byte) error {
+					if num == labelName {
+						l.name = string(v)
+					} else {
+						l.value = string(v)
+					}
+					return nil
+				})
+				labels = append(labels, fmt.Sprintf("%s=%q", l.name, l.value))
+				return err
+			case timeSeriesSamples:
+				value, _, n := protowire.ConsumeTag(v)
+				bits, m := protowire.ConsumeFixed64(v[n:])
+				if value != sampleValue || m < 0 {
+					return fmt.Errorf("unexpected sample value")
+				}
+				v = v[n+m:]
+				_, _, n = protowire.ConsumeTag(v)
+				ts, m := protowire.ConsumeVarint(v[n:])
+				if m < 0 {
+					return fmt.Errorf("unexpected sample timestamp")
+				}
+				samples = append(samples, fmt.Sprintf("%g @%d", math.Float64frombits(bits), int64(ts)))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		series = append(series, "{"+strings.Join(labels, ", ")+"} "+strings.Join(samples, " "))
+		return nil
+	})
+	return series, err
+}
+
+// decodeMessage calls fun for every length delimited field of b
+func decodeMessage(b []byte, fun func(num protowire.Number, v []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 || typ != protowire.BytesType {
+			return fmt.Errorf("unexpected field %d of type %d", num, typ)
+		}
+		b = b[n:]
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		err := fun(num, v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestSend(t *testing.T) {
+	rc := &receiver{}
+	s := httptest.NewServer(rc)
+	defer s.Close()
+
+	r := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rx_total",
+		Help: "Responses",
+	}, []string{"name", "broker"})
+	counter.WithLabelValues("rdkafka#consumer-1", "localhost:9092/2").Add(7)
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "duration_seconds",
+		Help:    "Duration",
+		Buckets: []float64{0.5},
+	})
+	histogram.Observe(0.25)
+	r.MustRegister(counter, histogram)
+	mfs, err := r.Gather()
+	if err != nil {
+		t.Fatal("Gather failed:", err)
+	}
+
+	c := NewClient(s.URL)
+	err = c.Send(mfs, time.UnixMilli(1700000000000))
+	if err != nil {
+		t.Fatal("Send failed:", err)
+	}
+	err = c.Close()
+	if err != nil {
+		t.Fatal("Close failed:", err)
+	}
+
+	expected := [][]string{{
+		`{__name__="duration_seconds_bucket", le="0.5"} 1 @1700000000000`,
+		`{__name__="duration_seconds_bucket", le="+Inf"} 1 @1700000000000`,
+		`{__name__="duration_seconds_sum"} 0.25 @1700000000000`,
+		`{__name__="duration_seconds_count"} 1 @1700000000000`,
+		`{__name__="rx_total", broker="localhost:9092/2", name="rdkafka#consumer-1"} 7 @1700000000000`,
+	}}
+	if diff := cmp.Diff(expected, rc.series); diff != "" {
+		t.Fatal("Unexpected series (-want +got):\n", diff)
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	tests := map[string]struct {
+		status   []int
+		requests int
+		fails    bool
+	}{
+		"recovering": {
+			status:   []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+			requests: 3,
+		},
+		"exhausted": {
+			status:   []int{500, 500, 500},
+			requests: 3,
+			fails:    true,
+		},
+		"bad request": {
+			status:   []int{http.StatusBadRequest},
+			requests: 1,
+			fails:    true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rc := &receiver{
+				status: test.status,
+			}
+			s := httptest.NewServer(rc)
+			defer s.Close()
+
+			c := NewClient(s.URL, WithRetries(2), WithBackoff(time.Millisecond))
+			err := c.Send(nil, time.Now())
+			if err != nil {
+				t.Fatal("Send failed:", err)
+			}
+			// Close does not wait for retries
+			rc.waitRequests(t, test.requests)
+			err = c.Close()
+			if test.fails != (err != nil) {
+				t.Fatalf("Expected failure %t. Got: %v", test.fails, err)
+			}
+			if rc.requests != test.requests {
+				t.Fatalf("Expected %d requests. Got: %d", test.requests, rc.requests)
+			}
+		})
+	}
+}
+
+func TestSendQueueFull(t *testing.T) {
+	received := make(chan struct{})
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var values []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		compressed, _ := io.ReadAll(r.Body)
+		b, _ := snappy.Decode(nil, compressed)
+		series, _ := decodeWriteRequest(b)
+		mu.Lock()
+		values = append(values, series...)
+		mu.Unlock()
+		received <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer s.Close()
+
+	g := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "g",
+		Help: "Gauge",
+	})
+	r := prometheus.NewRegistry()
+	r.MustRegister(g)
+	send := func(c *Client, v float64) {
+		g.Set(v)
+		mfs, err := r.Gather()
+		if err != nil {
+			t.Fatal("Gather failed:", err)
+		}
+		err = c.Send(mfs, time.UnixMilli(0))
+		if err != nil {
+			t.Fatal("Send failed:", err)
+		}
+	}
+
+	c := NewClient(s.URL, WithQueueSize(1))
+	send(c, 1)
+	// First request is in flight
+	<-received
+	send(c, 2)
+	send(c, 3)
+	close(release)
+	<-received
+	err := c.Close()
+	if err != nil {
+		t.Fatal("Close failed:", err)
+	}
+	if c.Dropped() != 1 {
+		t.Fatalf("Expected 1 dropped request. Got: %d", c.Dropped())
+	}
+	expected := []string{
+		`{__name__="g"} 1 @0`,
+		`{__name__="g"} 3 @0`,
+	}
+	if diff := cmp.Diff(expected, values); diff != "" {
+		t.Fatal("Unexpected series (-want +got):\n", diff)
+	}
+}
+
+func TestCloseInterruptsBackoff(t *testing.T) {
+	rc := &receiver{
+		status: []int{http.StatusServiceUnavailable},
+	}
+	s := httptest.NewServer(rc)
+	defer s.Close()
+
+	c := NewClient(s.URL, WithRetries(1), WithBackoff(time.Hour))
+	err := c.Send(nil, time.Now())
+	if err != nil {
+		t.Fatal("Send failed:", err)
+	}
+	rc.waitRequests(t, 1)
+	closed := make(chan error)
+	go func() {
+		closed <- c.Close()
+	}()
+	select {
+	case err = <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close waited for the backoff")
+	}
+	if err == nil {
+		t.Fatal("Expected the failed request as error")
+	}
+	if rc.requests != 1 {
+		t.Fatalf("Expected 1 request. Got: %d", rc.requests)
+	}
+}
+
+func TestSendAfterClose(t *testing.T) {
+	s := httptest.NewServer(&receiver{})
+	defer s.Close()
+
+	c := NewClient(s.URL)
+	err := c.Close()
+	if err != nil {
+		t.Fatal("Close failed:", err)
+	}
+	err = c.Send(nil, time.Now())
+	if err != ErrClientClosed {
+		t.Fatalf("Expected %v. Got: %v", ErrClientClosed, err)
+	}
+	err = c.Close()
+	if err != ErrClientClosed {
+		t.Fatalf("Expected %v on second Close. Got: %v", ErrClientClosed, err)
+	}
+}
@@ -0,0 +1,123 @@
+// Package remotewrite sends gathered metrics to a Prometheus remote-write
+// endpoint (e.g. Prometheus, Mimir or VictoriaMetrics) for deployments
+// without a scraping Prometheus.
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the remote-write protobuf messages
+const (
+	writeRequestTimeseries = 1
+	timeSeriesLabels       = 1
+	timeSeriesSamples      = 2
+	labelName              = 1
+	labelValue             = 2
+	sampleValue            = 1
+	sampleTimestamp        = 2
+)
+
+type label struct {
+	name  string
+	value string
+}
+
+// Encode encodes mfs as uncompressed remote-write WriteRequest. Samples
+// without a timestamp are timestamped with now. Histograms and summaries
+// are split into their series as in the text format.
+func Encode(mfs []*dto.MetricFamily, now time.Time) []byte {
+	var b []byte
+	var series []byte
+	for _, mf := range mfs {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			ts := now.UnixMilli()
+			if m.TimestampMs != nil {
+				ts = m.GetTimestampMs()
+			}
+			labels := make([]label, 0, len(m.GetLabel())+2)
+			for _, l := range m.GetLabel() {
+				labels = append(labels, label{name: l.GetName(), value: l.GetValue()})
+			}
+			add := func(suffix string, value float64, extra ...label) {
+				series = appendSeries(series[:0], name+suffix, labels, extra, value, ts)
+				b = protowire.AppendTag(b, writeRequestTimeseries, protowire.BytesType)
+				b = protowire.AppendBytes(b, series)
+			}
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				add("", m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				add("", m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				add("", m.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					add("", q.GetValue(), label{name: "quantile", value: formatFloat(q.GetQuantile())})
+				}
+				add("_sum", s.GetSampleSum())
+				add("_count", float64(s.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				inf := false
+				for _, bucket := range h.GetBucket() {
+					inf = inf || math.IsInf(bucket.GetUpperBound(), +1)
+					add("_bucket", float64(bucket.GetCumulativeCount()), label{name: "le", value: formatFloat(bucket.GetUpperBound())})
+				}
+				if !inf {
+					add("_bucket", float64(h.GetSampleCount()), label{name: "le", value: "+Inf"})
+				}
+				add("_sum", h.GetSampleSum())
+				add("_count", float64(h.GetSampleCount()))
+			}
+		}
+	}
+	return b
+}
+
+// appendSeries appends a TimeSeries with a single sample. Labels are sorted
+// by name as required by the protocol.
+func appendSeries(b []byte, name string, labels, extra []label, value float64, ts int64) []byte {
+	all := make([]label, 0, len(labels)+len(extra)+1)
+	all = append(all, label{name: "__name__", value: name})
+	all = append(all, labels...)
+	all = append(all, extra...)
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].name < all[j].name
+	})
+	for _, l := range all {
+		var lb []byte
+		lb = protowire.AppendTag(lb, labelName, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.name)
+		lb = protowire.AppendTag(lb, labelValue, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.value)
+		b = protowire.AppendTag(b, timeSeriesLabels, protowire.BytesType)
+		b = protowire.AppendBytes(b, lb)
+	}
+	var sb []byte
+	sb = protowire.AppendTag(sb, sampleValue, protowire.Fixed64Type)
+	sb = protowire.AppendFixed64(sb, math.Float64bits(value))
+	sb = protowire.AppendTag(sb, sampleTimestamp, protowire.VarintType)
+	sb = protowire.AppendVarint(sb, uint64(ts))
+	b = protowire.AppendTag(b, timeSeriesSamples, protowire.BytesType)
+	return protowire.AppendBytes(b, sb)
+}
+
+// formatFloat formats `le` and `quantile` like the text format
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, +1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
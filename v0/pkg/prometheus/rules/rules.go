@@ -0,0 +1,328 @@
+// Package rules generates Prometheus recording and alerting rules for the
+// metrics exported from Stats. Metric and label names are looked up from
+// the naming of the exported metrics, so rules cannot drift from them.
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+	"github.com/prometheus/common/model"
+)
+
+// Group is a named list of rules as in a Prometheus rules file
+type Group struct {
+	Name  string
+	Rules []Rule
+}
+
+// Rule is either a recording rule (Record is set) or an alerting rule
+// (Alert is set)
+type Rule struct {
+	Record      string
+	Alert       string
+	Expr        string
+	For         time.Duration
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// Option represents an opaque option implementation
+// for building rules
+type Option interface {
+}
+
+// WithConsumerLagThreshold creates an Option for alerting when the consumer
+// lag of a topic exceeds messages. Defaults to 1000.
+func WithConsumerLagThreshold(messages int) Option {
+	return &rulesConsumerLagThreshold{
+		messages: messages,
+	}
+}
+
+// WithBrokerDownThreshold creates an Option for alerting when a broker is
+// DOWN for longer than d. Defaults to 1m.
+func WithBrokerDownThreshold(d time.Duration) Option {
+	return &rulesBrokerDownThreshold{
+		d: d,
+	}
+}
+
+// WithBrokerDisconnectThreshold creates an Option for alerting when a
+// broker disconnects more than count times within the rate interval.
+// Defaults to 5.
+func WithBrokerDisconnectThreshold(count int) Option {
+	return &rulesBrokerDisconnectThreshold{
+		count: count,
+	}
+}
+
+// WithProducerQueueThreshold creates an Option for alerting when the
+// producer queue is filled by more than ratio (0-1). Defaults to 0.8.
+func WithProducerQueueThreshold(ratio float64) Option {
+	return &rulesProducerQueueThreshold{
+		ratio: ratio,
+	}
+}
+
+// WithRateInterval creates an Option for setting the range of rates.
+// Defaults to 5m.
+func WithRateInterval(d time.Duration) Option {
+	return &rulesRateInterval{
+		d: d,
+	}
+}
+
+// WithFor creates an Option for setting how long alert conditions have to
+// hold before firing. Defaults to 5m.
+func WithFor(d time.Duration) Option {
+	return &rulesFor{
+		d: d,
+	}
+}
+
+// WithAlertLabels creates an Option for adding labels (e.g. `severity`) to
+// all alerts. Defaults to `severity: warning`.
+func WithAlertLabels(labels map[string]string) Option {
+	return &rulesAlertLabels{
+		labels: labels,
+	}
+}
+
+type rulesConsumerLagThreshold struct {
+	messages int
+}
+
+type rulesBrokerDownThreshold struct {
+	d time.Duration
+}
+
+type rulesBrokerDisconnectThreshold struct {
+	count int
+}
+
+type rulesProducerQueueThreshold struct {
+	ratio float64
+}
+
+type rulesRateInterval struct {
+	d time.Duration
+}
+
+type rulesFor struct {
+	d time.Duration
+}
+
+type rulesAlertLabels struct {
+	labels map[string]string
+}
+
+type config struct {
+	consumerLag        int
+	brokerDown         time.Duration
+	brokerDisconnects  int
+	producerQueueRatio float64
+	rateInterval       time.Duration
+	forDuration        time.Duration
+	alertLabels        map[string]string
+}
+
+func resolveOptions(opts []Option) *config {
+	c := &config{
+		consumerLag:        1000,
+		brokerDown:         time.Minute,
+		brokerDisconnects:  5,
+		producerQueueRatio: 0.8,
+		rateInterval:       5 * time.Minute,
+		forDuration:        5 * time.Minute,
+		alertLabels:        map[string]string{"severity": "warning"},
+	}
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case *rulesConsumerLagThreshold:
+			c.consumerLag = o.messages
+		case *rulesBrokerDownThreshold:
+			c.brokerDown = o.d
+		case *rulesBrokerDisconnectThreshold:
+			c.brokerDisconnects = o.count
+		case *rulesProducerQueueThreshold:
+			c.producerQueueRatio = o.ratio
+		case *rulesRateInterval:
+			c.rateInterval = o.d
+		case *rulesFor:
+			c.forDuration = o.d
+		case *rulesAlertLabels:
+			c.alertLabels = o.labels
+		default:
+			panic(fmt.Sprintf("Unrecognized option %#v", opt))
+		}
+	}
+	return c
+}
+
+// Build creates the rules for metrics named by naming (see
+// `v0.MetricNaming`). Fails if a metric or label used by the rules is not
+// exported (e.g. due to aggregation).
+func Build(naming *gen.GeneratedOptions, opts ...Option) ([]Group, error) {
+	c := resolveOptions(opts)
+	n := newNames(naming)
+
+	lag, err := n.metric("Stats.Topics[].Partitions[].ConsumerLag", "topics", "topic")
+	if err != nil {
+		return nil, err
+	}
+	// Clients are identified by `name`, as several may share a `client_id`
+	topic := lag.labels[2]
+	byTopic := strings.Join(lag.labels, ", ")
+	rateInterval := model.Duration(c.rateInterval).String()
+
+	recording := []Rule{{
+		Record: recordName("topic", lag.name, "sum"),
+		Expr:   fmt.Sprintf("sum by (%s) (%s >= 0)", byTopic, lag.name),
+	}}
+	for _, path := range []string{
+		"Stats.Topics[].Partitions[].Rxmsgs",
+		"Stats.Topics[].Partitions[].Rxbytes",
+		"Stats.Topics[].Partitions[].Txmsgs",
+		"Stats.Topics[].Partitions[].Txbytes",
+	} {
+		m, err := n.metric(path, "topics", "topic")
+		if err != nil {
+			return nil, err
+		}
+		recording = append(recording, Rule{
+			Record: recordName("topic", strings.TrimSuffix(m.name, "_total"), "rate"+rateInterval),
+			Expr:   fmt.Sprintf("sum by (%s) (rate(%s[%s]))", byTopic, m.name, rateInterval),
+		})
+	}
+
+	stateage, err := n.metric("Stats.Brokers[].Stateage", "brokers", "name", "brokers", "state", "brokers", "nodeid")
+	if err != nil {
+		return nil, err
+	}
+	brokerName, brokerState, brokerNodeID := stateage.labels[2], stateage.labels[3], stateage.labels[4]
+	disconnects, err := n.metric("Stats.Brokers[].Disconnects", "brokers", "name")
+	if err != nil {
+		return nil, err
+	}
+	msgCnt, err := n.metric("Stats.MsgCnt")
+	if err != nil {
+		return nil, err
+	}
+	msgMax, err := n.metric("Stats.MsgMax")
+	if err != nil {
+		return nil, err
+	}
+	txnStateage, err := n.metric("Stats.Eos.TxnStateage", "eos", "txn_state")
+	if err != nil {
+		return nil, err
+	}
+	client := lag.labels[0]
+	txnState := txnStateage.labels[2]
+
+	alerting := []Rule{{
+		Alert: "KafkaConsumerLagHigh",
+		Expr:  fmt.Sprintf("%s > %d", recording[0].Record, c.consumerLag),
+		Annotations: map[string]string{
+			"summary": fmt.Sprintf("Consumer lag of topic {{ $labels.%s }} is {{ $value }} messages", topic),
+		},
+	}, {
+		// Bootstrap brokers (nodeid -1) are DOWN once metadata is known
+		Alert: "KafkaBrokerDown",
+		Expr:  fmt.Sprintf("%s{%s=\"DOWN\", %s!=\"-1\"} > %d", stateage.name, brokerState, brokerNodeID, c.brokerDown.Microseconds()),
+		Annotations: map[string]string{
+			"summary": fmt.Sprintf("Broker {{ $labels.%s }} is DOWN for client {{ $labels.%s }}", brokerName, client),
+		},
+	}, {
+		Alert: "KafkaBrokerDisconnects",
+		Expr:  fmt.Sprintf("increase(%s[%s]) > %d", disconnects.name, rateInterval, c.brokerDisconnects),
+		Annotations: map[string]string{
+			"summary": fmt.Sprintf("Broker {{ $labels.%s }} disconnected {{ $value }} times in %s", brokerName, rateInterval),
+		},
+	}, {
+		Alert: "KafkaProducerQueueSaturated",
+		Expr:  fmt.Sprintf("%s / (%s > 0) > %s", msgCnt.name, msgMax.name, model.SampleValue(c.producerQueueRatio)),
+		Annotations: map[string]string{
+			"summary": fmt.Sprintf("Producer queue of client {{ $labels.%s }} is {{ $value | humanizePercentage }} full", client),
+		},
+	}, {
+		Alert: "KafkaTransactionAbortable",
+		Expr:  fmt.Sprintf("%s{%s=~\"AbortableError|FatalError\"}", txnStateage.name, txnState),
+		Annotations: map[string]string{
+			"summary": fmt.Sprintf("Transaction of client {{ $labels.%s }} is in state {{ $labels.%s }}", client, txnState),
+		},
+	}}
+	for i := range alerting {
+		alerting[i].For = c.forDuration
+		alerting[i].Labels = c.alertLabels
+	}
+
+	return []Group{{
+		Name:  "kafka_stats_exporter",
+		Rules: recording,
+	}, {
+		Name:  "kafka_stats_exporter_alerts",
+		Rules: alerting,
+	}}, nil
+}
+
+// recordName follows the `level:metric:operations` convention
+func recordName(level, metric, operations string) string {
+	return level + ":" + metric + ":" + operations
+}
+
+// names looks up metrics by the Go path of their source field
+type names struct {
+	naming *gen.GeneratedOptions
+	byPath map[string]gen.MetricDesc
+}
+
+// resolvedMetric is the name of a metric and the requested label names.
+// The first labels are always the client labels `client_id` and `name`.
+type resolvedMetric struct {
+	name   string
+	labels []string
+}
+
+func newNames(naming *gen.GeneratedOptions) *names {
+	n := &names{
+		naming: naming,
+		byPath: map[string]gen.MetricDesc{},
+	}
+	for _, d := range naming.Describe() {
+		n.byPath[d.Path] = d
+	}
+	return n
+}
+
+// metric resolves the metric for path and the label names for the
+// `kpromlbl` tags given as pairs of metric prefix and tag
+func (n *names) metric(path string, prefixTags ...string) (*resolvedMetric, error) {
+	d, ok := n.byPath[path]
+	if !ok {
+		return nil, fmt.Errorf("no metric exported for `%s`", path)
+	}
+	m := &resolvedMetric{
+		name: d.Name,
+	}
+	prefixTags = append([]string{"", "client_id", "", "name"}, prefixTags...)
+	for i := 0; i+1 < len(prefixTags); i += 2 {
+		ln := n.naming.LabelName(prefixTags[i], prefixTags[i+1])
+		if !contains(d.LabelNames, ln) {
+			return nil, fmt.Errorf("metric `%s` has no label `%s`", d.Name, ln)
+		}
+		m.labels = append(m.labels, ln)
+	}
+	return m, nil
+}
+
+func contains(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
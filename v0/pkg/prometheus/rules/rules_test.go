@@ -0,0 +1,57 @@
+package rules
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	v0 "github.com/abergmeier/kafka_stats_exporter/v0"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteYAML(t *testing.T) {
+	naming, err := v0.MetricNaming()
+	if err != nil {
+		t.Fatal("MetricNaming failed:", err)
+	}
+	groups, err := Build(naming)
+	if err != nil {
+		t.Fatal("Build failed:", err)
+	}
+	b := &bytes.Buffer{}
+	err = WriteYAML(b, groups)
+	if err != nil {
+		t.Fatal("WriteYAML failed:", err)
+	}
+	expected, err := os.ReadFile("testdata/rules.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := cmp.Diff(string(expected), b.String())
+	if d != "" {
+		t.Fatal("Diff", d)
+	}
+}
+
+func TestBuildUsesNaming(t *testing.T) {
+	naming, err := v0.MetricNaming(v0.WithNamespace("kafka"))
+	if err != nil {
+		t.Fatal("MetricNaming failed:", err)
+	}
+	groups, err := Build(naming, WithConsumerLagThreshold(10))
+	if err != nil {
+		t.Fatal("Build failed:", err)
+	}
+	expected := "topic:kafka_topics_partitions_consumer_lag:sum > 10"
+	if groups[1].Rules[0].Expr != expected {
+		t.Errorf("Expected `%s` but got `%s`", expected, groups[1].Rules[0].Expr)
+	}
+	for _, g := range groups {
+		for _, r := range g.Rules {
+			if r.Record != "" && !strings.Contains(r.Record, ":kafka_") {
+				t.Error("Recording rule not using namespace:", r.Record)
+			}
+		}
+	}
+}
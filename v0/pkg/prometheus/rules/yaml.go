@@ -0,0 +1,63 @@
+package rules
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// WriteYAML writes groups in the format of Prometheus rules files
+func WriteYAML(w io.Writer, groups []Group) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("groups:\n")
+	for _, g := range groups {
+		bw.WriteString("  - name: " + quote(g.Name) + "\n")
+		bw.WriteString("    rules:\n")
+		for _, r := range g.Rules {
+			if r.Record != "" {
+				bw.WriteString("      - record: " + quote(r.Record) + "\n")
+			} else {
+				bw.WriteString("      - alert: " + quote(r.Alert) + "\n")
+			}
+			bw.WriteString("        expr: " + quote(r.Expr) + "\n")
+			if r.For != 0 {
+				bw.WriteString("        for: " + model.Duration(r.For).String() + "\n")
+			}
+			writeMap(bw, "labels", r.Labels)
+			writeMap(bw, "annotations", r.Annotations)
+		}
+	}
+	return bw.Flush()
+}
+
+func writeMap(bw *bufio.Writer, name string, m map[string]string) {
+	if len(m) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	bw.WriteString("        " + name + ":\n")
+	for _, k := range keys {
+		bw.WriteString("          " + quote(k) + ": " + quote(m[k]) + "\n")
+	}
+}
+
+// quote returns a double quoted YAML scalar. JSON strings are valid
+// YAML scalars.
+func quote(s string) string {
+	b := &strings.Builder{}
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(s)
+	if err != nil {
+		panic(err)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,6 @@
+// Package typedcollector contains a Collector for typed.Stats generated by
+// package codegen. It exports the same metrics as
+// gen.NewRecursiveMetricsFromTags(typed.Stats{}) without reflection.
+package typedcollector
+
+//go:generate go run ./internal/generate -o stats_collector.go
@@ -0,0 +1,28 @@
+// Command generate writes the Collector for typed.Stats
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"reflect"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/codegen"
+)
+
+func main() {
+	out := flag.String("o", "stats_collector.go", "File to write the generated Collector to")
+	flag.Parse()
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	err = codegen.Generate(f, "typedcollector", reflect.TypeOf(typed.Stats{}))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,5017 @@
+// Code generated by codegen. DO NOT EDIT.
+
+package typedcollector
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StatsCollector is a prometheus.Collector for typed.Stats
+type StatsCollector struct {
+	o      *gen.GeneratedOptions
+	m      *statsMetrics
+	s      statsState
+	owners *gen.SeriesOwners // Shared by all Collectors sharing m
+}
+
+// NewStatsCollector creates the metrics for typed.Stats.
+// Fails if metrics cannot be built with the given options.
+func NewStatsCollector(opts ...gen.RecursiveMetricsOption) (*StatsCollector, error) {
+	o, err := gen.NewGeneratedOptions(reflect.TypeOf(typed.Stats{}), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsCollector{
+		o:      o,
+		m:      newStatsMetrics(o, nil),
+		owners: &gen.SeriesOwners{},
+	}, nil
+}
+
+// Share creates a StatsCollector updating the metrics of c with the state, filters
+// and derived metrics of other (e.g. for another client exported via the
+// same Registerer). Both can be updated concurrently as long as the labels
+// of their values differ.
+// Fails with gen.ErrIncompatibleOptions if other was created with
+// options creating different metrics (see gen.GeneratedOptions.Equal).
+func (c *StatsCollector) Share(other *StatsCollector) (*StatsCollector, error) {
+	if !c.o.Equal(other.o) {
+		return nil, gen.ErrIncompatibleOptions
+	}
+	return &StatsCollector{
+		o:      other.o,
+		m:      c.m.share(other.o),
+		owners: c.owners,
+	}, nil
+}
+
+// Delete removes all series updated by c and forgets their values.
+// Series, which got updated by another sharing StatsCollector since (e.g. of a
+// recreated client), are kept.
+// Must not be called concurrently with updates.
+func (c *StatsCollector) Delete() {
+	if c.owners.Release(c) {
+		c.s.delete(c.m)
+	}
+	c.s = statsState{}
+}
+
+// Describe implements prometheus.Collector
+func (c *StatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.m.describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *StatsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.m.collect(ch)
+}
+
+// Update updates all metrics with the values of v.
+// Must not be called concurrently.
+func (c *StatsCollector) Update(v *typed.Stats) {
+	c.s.update(c.m, v, nil)
+	c.owners.Own(c.s.labels, c)
+}
+
+// UpdateJSON updates all metrics with the JSON encoded statistics in data
+// without decoding them into a typed.Stats. Values are looked up by the JSON
+// names of the tagged fields and all of them get decoded before writing
+// any into the metrics. Entries of maps with filters are decoded completely
+// for passing them to the filters. Derived metrics are not exported, as
+// they need complete values.
+// Fails without updating if data is not valid JSON or a value does not
+// match the type of its field.
+// Must not be called concurrently.
+func (c *StatsCollector) UpdateJSON(data string) error {
+	if !gen.ValidJSON(data) {
+		return gen.ErrJSONSyntax
+	}
+	var r statsRaw
+	err := r.decode(data)
+	if err != nil {
+		return err
+	}
+	err = r.filter(c.m)
+	if err != nil {
+		return err
+	}
+	c.s.stream(c.m, &r, nil)
+	c.owners.Own(c.s.labels, c)
+	return nil
+}
+
+// Count returns the number of exported series. Adds the number of tracked
+// entries of each map to entries (keyed by metric prefix, e.g.
+// "topics_partitions"). Aggregated maps do not track entries.
+// Must not be called concurrently with updates.
+func (c *StatsCollector) Count(entries map[string]int) int {
+	return c.s.count(c.m, entries)
+}
+
+type statsMetrics struct {
+	labelNames             []string
+	lName                  string
+	lClientId              string
+	lType                  string
+	mTs                    *prometheus.CounterVec
+	mTime                  *prometheus.CounterVec
+	mAge                   *prometheus.CounterVec
+	mReplyq                *prometheus.GaugeVec
+	mMsgCnt                *prometheus.GaugeVec
+	mMsgSize               *prometheus.GaugeVec
+	mMsgMax                *prometheus.CounterVec
+	mMsgSizeMax            *prometheus.CounterVec
+	mTx                    *prometheus.CounterVec
+	mTxBytes               *prometheus.CounterVec
+	mRx                    *prometheus.CounterVec
+	mRxBytes               *prometheus.CounterVec
+	mTxmsgs                *prometheus.CounterVec
+	mTxmsgBytes            *prometheus.CounterVec
+	mRxmsgs                *prometheus.CounterVec
+	mRxmsgBytes            *prometheus.CounterVec
+	mSimpleCnt             *prometheus.GaugeVec
+	mMetadataCacheCnt      *prometheus.GaugeVec
+	derived                []gen.GeneratedDerived
+	nCgrp                  *statsMetricsCgrp
+	nEos                   *statsMetricsEos
+	eBrokers               *statsMetricsBrokers // nil if aggregated
+	fBrokers               func(key typed.BrokerName, value typed.BrokerStats) bool
+	aBrokersStateage       *prometheus.GaugeVec
+	aBrokersOutbufCnt      *prometheus.GaugeVec
+	aBrokersOutbufMsgCnt   *prometheus.GaugeVec
+	aBrokersWaitrespCnt    *prometheus.GaugeVec
+	aBrokersWaitrespMsgCnt *prometheus.GaugeVec
+	aBrokersTx             *prometheus.CounterVec
+	aBrokersTxbytes        *prometheus.CounterVec
+	aBrokersTxerrs         *prometheus.CounterVec
+	aBrokersTxretries      *prometheus.CounterVec
+	aBrokersTxidle         *prometheus.GaugeVec
+	aBrokersReqTimeouts    *prometheus.CounterVec
+	aBrokersRx             *prometheus.CounterVec
+	aBrokersRxbytes        *prometheus.CounterVec
+	aBrokersRxerrs         *prometheus.CounterVec
+	aBrokersRxcorriderrs   *prometheus.CounterVec
+	aBrokersRxpartial      *prometheus.CounterVec
+	aBrokersRxidle         *prometheus.GaugeVec
+	aBrokersZbufGrow       *prometheus.CounterVec
+	aBrokersWakeups        *prometheus.CounterVec
+	aBrokersConnects       *prometheus.CounterVec
+	aBrokersDisconnects    *prometheus.CounterVec
+	eTopics                *statsMetricsTopics // nil if aggregated
+	fTopics                func(key typed.TopicName, value typed.TopicStats) bool
+	aTopicsAge             *prometheus.GaugeVec
+	aTopicsMetadataAge     *prometheus.GaugeVec
+}
+
+func newStatsMetrics(o *gen.GeneratedOptions, parentLabelNames []string) *statsMetrics {
+	m := &statsMetrics{}
+	m.labelNames = append(m.labelNames, parentLabelNames...)
+	m.lName = o.LabelName("", "name")
+	m.labelNames = append(m.labelNames, m.lName)
+	m.lClientId = o.LabelName("", "client_id")
+	m.labelNames = append(m.labelNames, m.lClientId)
+	m.lType = o.LabelName("", "type")
+	m.labelNames = append(m.labelNames, m.lType)
+	m.mTs = o.NewCounterVec("", "Ts", "internal monotonic clock (microseconds)", m.labelNames)
+	m.mTime = o.NewCounterVec("", "Time", "Wall clock time in seconds since the epoch", m.labelNames)
+	m.mAge = o.NewCounterVec("", "Age", "Time since this client instance was created (microseconds)", m.labelNames)
+	m.mReplyq = o.NewGaugeVec("", "Replyq", "Number of ops (callbacks, events, etc) waiting in queue for application to serve with Poll()", m.labelNames)
+	m.mMsgCnt = o.NewGaugeVec("", "MsgCnt", "Current number of messages in producer queues", m.labelNames)
+	m.mMsgSize = o.NewGaugeVec("", "MsgSize", "Current total size of messages in producer queues", m.labelNames)
+	m.mMsgMax = o.NewCounterVec("", "MsgMax", "Threshold: maximum number of messages allowed allowed on the producer queues", m.labelNames)
+	m.mMsgSizeMax = o.NewCounterVec("", "MsgSizeMax", "Threshold: maximum total size of messages allowed on the producer queues", m.labelNames)
+	m.mTx = o.NewCounterVec("", "Tx", "Total number of requests sent to Kafka brokers", m.labelNames)
+	m.mTxBytes = o.NewCounterVec("", "TxBytes", "Total number of bytes transmitted to Kafka brokers", m.labelNames)
+	m.mRx = o.NewCounterVec("", "Rx", "Total number of responses received from Kafka brokers", m.labelNames)
+	m.mRxBytes = o.NewCounterVec("", "RxBytes", "Total number of bytes received from Kafka brokers", m.labelNames)
+	m.mTxmsgs = o.NewCounterVec("", "Txmsgs", "Total number of messages transmitted (produced) to Kafka brokers", m.labelNames)
+	m.mTxmsgBytes = o.NewCounterVec("", "TxmsgBytes", "Total number of message bytes (including framing, such as per-Message framing and MessageSet/batch framing) transmitted to Kafka brokers", m.labelNames)
+	m.mRxmsgs = o.NewCounterVec("", "Rxmsgs", "Total number of messages consumed, not including ignored messages (due to offset, etc), from Kafka brokers.", m.labelNames)
+	m.mRxmsgBytes = o.NewCounterVec("", "RxmsgBytes", "Total number of message bytes (including framing) received from Kafka brokers", m.labelNames)
+	m.mSimpleCnt = o.NewGaugeVec("", "SimpleCnt", "Internal tracking of legacy vs new consumer API state", m.labelNames)
+	m.mMetadataCacheCnt = o.NewGaugeVec("", "MetadataCacheCnt", "Number of topics in the metadata cache.", m.labelNames)
+	m.derived = o.NewDerived("", "Stats", reflect.TypeOf(typed.Stats{}), m.labelNames)
+	m.nCgrp = newStatsMetricsCgrp(o, m.labelNames)
+	m.nEos = newStatsMetricsEos(o, m.labelNames)
+	m.fBrokers = gen.MapEntryFilterFunc[typed.BrokerName, typed.BrokerStats](o, "Stats.Brokers")
+	if o.Aggregate("Stats.Brokers") {
+		m.aBrokersStateage = o.NewGaugeVec("", "Stateage", "Time since last broker state change (microseconds)", m.labelNames)
+		m.aBrokersOutbufCnt = o.NewGaugeVec("", "OutbufCnt", "Number of requests awaiting transmission to broker", m.labelNames)
+		m.aBrokersOutbufMsgCnt = o.NewGaugeVec("", "OutbufMsgCnt", "Number of messages awaiting transmission to broker", m.labelNames)
+		m.aBrokersWaitrespCnt = o.NewGaugeVec("", "WaitrespCnt", "Number of requests in-flight to broker awaiting response", m.labelNames)
+		m.aBrokersWaitrespMsgCnt = o.NewGaugeVec("", "WaitrespMsgCnt", "Number of messages in-flight to broker awaiting response", m.labelNames)
+		m.aBrokersTx = o.NewCounterVec("", "Tx", "Total number of requests sent", m.labelNames)
+		m.aBrokersTxbytes = o.NewCounterVec("", "Txbytes", "Total number of bytes sent", m.labelNames)
+		m.aBrokersTxerrs = o.NewCounterVec("", "Txerrs", "Total number of transmission errors", m.labelNames)
+		m.aBrokersTxretries = o.NewCounterVec("", "Txretries", "Total number of request retries", m.labelNames)
+		m.aBrokersTxidle = o.NewGaugeVec("", "Txidle", "Microseconds since last socket send (or -1 if no sends yet for current connection).", m.labelNames)
+		m.aBrokersReqTimeouts = o.NewCounterVec("", "ReqTimeouts", "Total number of requests timed out", m.labelNames)
+		m.aBrokersRx = o.NewCounterVec("", "Rx", "Total number of responses received", m.labelNames)
+		m.aBrokersRxbytes = o.NewCounterVec("", "Rxbytes", "Total number of bytes received", m.labelNames)
+		m.aBrokersRxerrs = o.NewCounterVec("", "Rxerrs", "Total number of receive errors", m.labelNames)
+		m.aBrokersRxcorriderrs = o.NewCounterVec("", "Rxcorriderrs", "Total number of unmatched correlation ids in response (typically for timed out requests)", m.labelNames)
+		m.aBrokersRxpartial = o.NewCounterVec("", "Rxpartial", "Total number of partial MessageSets received. The broker may return partial responses if the full MessageSet could not fit in the remaining Fetch response size.", m.labelNames)
+		m.aBrokersRxidle = o.NewGaugeVec("", "Rxidle", "Microseconds since last socket receive (or -1 if no receives yet for current connection).", m.labelNames)
+		m.aBrokersZbufGrow = o.NewCounterVec("", "ZbufGrow", "Total number of decompression buffer size increases", m.labelNames)
+		m.aBrokersWakeups = o.NewCounterVec("", "Wakeups", "Broker thread poll loop wakeups", m.labelNames)
+		m.aBrokersConnects = o.NewCounterVec("", "Connects", "Number of connection attempts, including successful and failed, and name resolution failures.", m.labelNames)
+		m.aBrokersDisconnects = o.NewCounterVec("", "Disconnects", "Number of disconnects (triggered by broker, network, load-balancer, etc.).", m.labelNames)
+	} else {
+		m.eBrokers = newStatsMetricsBrokers(o, m.labelNames)
+	}
+	m.fTopics = gen.MapEntryFilterFunc[typed.TopicName, typed.TopicStats](o, "Stats.Topics")
+	if o.Aggregate("Stats.Topics") {
+		m.aTopicsAge = o.NewGaugeVec("", "Age", "Age of client's topic object (milliseconds)", m.labelNames)
+		m.aTopicsMetadataAge = o.NewGaugeVec("", "MetadataAge", "Age of metadata from broker for this topic (milliseconds)", m.labelNames)
+	} else {
+		m.eTopics = newStatsMetricsTopics(o, m.labelNames)
+	}
+	return m
+}
+
+// share returns a copy of m computing filters and derived metrics with
+// the functions of o
+func (m *statsMetrics) share(o *gen.GeneratedOptions) *statsMetrics {
+	c := *m
+	c.derived = o.ShareDerived(m.derived, "Stats")
+	c.nCgrp = m.nCgrp.share(o)
+	c.nEos = m.nEos.share(o)
+	c.fBrokers = gen.MapEntryFilterFunc[typed.BrokerName, typed.BrokerStats](o, "Stats.Brokers")
+	if m.eBrokers != nil {
+		c.eBrokers = m.eBrokers.share(o)
+	}
+	c.fTopics = gen.MapEntryFilterFunc[typed.TopicName, typed.TopicStats](o, "Stats.Topics")
+	if m.eTopics != nil {
+		c.eTopics = m.eTopics.share(o)
+	}
+	return &c
+}
+
+func (m *statsMetrics) describe(ch chan<- *prometheus.Desc) {
+	m.mTs.Describe(ch)
+	m.mTime.Describe(ch)
+	m.mAge.Describe(ch)
+	m.mReplyq.Describe(ch)
+	m.mMsgCnt.Describe(ch)
+	m.mMsgSize.Describe(ch)
+	m.mMsgMax.Describe(ch)
+	m.mMsgSizeMax.Describe(ch)
+	m.mTx.Describe(ch)
+	m.mTxBytes.Describe(ch)
+	m.mRx.Describe(ch)
+	m.mRxBytes.Describe(ch)
+	m.mTxmsgs.Describe(ch)
+	m.mTxmsgBytes.Describe(ch)
+	m.mRxmsgs.Describe(ch)
+	m.mRxmsgBytes.Describe(ch)
+	m.mSimpleCnt.Describe(ch)
+	m.mMetadataCacheCnt.Describe(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Describe(ch)
+	}
+	m.nCgrp.describe(ch)
+	m.nEos.describe(ch)
+	if m.eBrokers != nil {
+		m.eBrokers.describe(ch)
+	} else {
+		m.aBrokersStateage.Describe(ch)
+		m.aBrokersOutbufCnt.Describe(ch)
+		m.aBrokersOutbufMsgCnt.Describe(ch)
+		m.aBrokersWaitrespCnt.Describe(ch)
+		m.aBrokersWaitrespMsgCnt.Describe(ch)
+		m.aBrokersTx.Describe(ch)
+		m.aBrokersTxbytes.Describe(ch)
+		m.aBrokersTxerrs.Describe(ch)
+		m.aBrokersTxretries.Describe(ch)
+		m.aBrokersTxidle.Describe(ch)
+		m.aBrokersReqTimeouts.Describe(ch)
+		m.aBrokersRx.Describe(ch)
+		m.aBrokersRxbytes.Describe(ch)
+		m.aBrokersRxerrs.Describe(ch)
+		m.aBrokersRxcorriderrs.Describe(ch)
+		m.aBrokersRxpartial.Describe(ch)
+		m.aBrokersRxidle.Describe(ch)
+		m.aBrokersZbufGrow.Describe(ch)
+		m.aBrokersWakeups.Describe(ch)
+		m.aBrokersConnects.Describe(ch)
+		m.aBrokersDisconnects.Describe(ch)
+	}
+	if m.eTopics != nil {
+		m.eTopics.describe(ch)
+	} else {
+		m.aTopicsAge.Describe(ch)
+		m.aTopicsMetadataAge.Describe(ch)
+	}
+}
+
+func (m *statsMetrics) collect(ch chan<- prometheus.Metric) {
+	m.mTs.Collect(ch)
+	m.mTime.Collect(ch)
+	m.mAge.Collect(ch)
+	m.mReplyq.Collect(ch)
+	m.mMsgCnt.Collect(ch)
+	m.mMsgSize.Collect(ch)
+	m.mMsgMax.Collect(ch)
+	m.mMsgSizeMax.Collect(ch)
+	m.mTx.Collect(ch)
+	m.mTxBytes.Collect(ch)
+	m.mRx.Collect(ch)
+	m.mRxBytes.Collect(ch)
+	m.mTxmsgs.Collect(ch)
+	m.mTxmsgBytes.Collect(ch)
+	m.mRxmsgs.Collect(ch)
+	m.mRxmsgBytes.Collect(ch)
+	m.mSimpleCnt.Collect(ch)
+	m.mMetadataCacheCnt.Collect(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Collect(ch)
+	}
+	m.nCgrp.collect(ch)
+	m.nEos.collect(ch)
+	if m.eBrokers != nil {
+		m.eBrokers.collect(ch)
+	} else {
+		m.aBrokersStateage.Collect(ch)
+		m.aBrokersOutbufCnt.Collect(ch)
+		m.aBrokersOutbufMsgCnt.Collect(ch)
+		m.aBrokersWaitrespCnt.Collect(ch)
+		m.aBrokersWaitrespMsgCnt.Collect(ch)
+		m.aBrokersTx.Collect(ch)
+		m.aBrokersTxbytes.Collect(ch)
+		m.aBrokersTxerrs.Collect(ch)
+		m.aBrokersTxretries.Collect(ch)
+		m.aBrokersTxidle.Collect(ch)
+		m.aBrokersReqTimeouts.Collect(ch)
+		m.aBrokersRx.Collect(ch)
+		m.aBrokersRxbytes.Collect(ch)
+		m.aBrokersRxerrs.Collect(ch)
+		m.aBrokersRxcorriderrs.Collect(ch)
+		m.aBrokersRxpartial.Collect(ch)
+		m.aBrokersRxidle.Collect(ch)
+		m.aBrokersZbufGrow.Collect(ch)
+		m.aBrokersWakeups.Collect(ch)
+		m.aBrokersConnects.Collect(ch)
+		m.aBrokersDisconnects.Collect(ch)
+	}
+	if m.eTopics != nil {
+		m.eTopics.collect(ch)
+	} else {
+		m.aTopicsAge.Collect(ch)
+		m.aTopicsMetadataAge.Collect(ch)
+	}
+}
+
+// delete removes the series with labels ls of this prefix
+func (m *statsMetrics) delete(ls prometheus.Labels) {
+	m.mTs.Delete(ls)
+	m.mTime.Delete(ls)
+	m.mAge.Delete(ls)
+	m.mReplyq.Delete(ls)
+	m.mMsgCnt.Delete(ls)
+	m.mMsgSize.Delete(ls)
+	m.mMsgMax.Delete(ls)
+	m.mMsgSizeMax.Delete(ls)
+	m.mTx.Delete(ls)
+	m.mTxBytes.Delete(ls)
+	m.mRx.Delete(ls)
+	m.mRxBytes.Delete(ls)
+	m.mTxmsgs.Delete(ls)
+	m.mTxmsgBytes.Delete(ls)
+	m.mRxmsgs.Delete(ls)
+	m.mRxmsgBytes.Delete(ls)
+	m.mSimpleCnt.Delete(ls)
+	m.mMetadataCacheCnt.Delete(ls)
+	for i := range m.derived {
+		m.derived[i].Vec.Delete(ls)
+	}
+	if m.eBrokers == nil {
+		m.aBrokersStateage.Delete(ls)
+		m.aBrokersOutbufCnt.Delete(ls)
+		m.aBrokersOutbufMsgCnt.Delete(ls)
+		m.aBrokersWaitrespCnt.Delete(ls)
+		m.aBrokersWaitrespMsgCnt.Delete(ls)
+		m.aBrokersTx.Delete(ls)
+		m.aBrokersTxbytes.Delete(ls)
+		m.aBrokersTxerrs.Delete(ls)
+		m.aBrokersTxretries.Delete(ls)
+		m.aBrokersTxidle.Delete(ls)
+		m.aBrokersReqTimeouts.Delete(ls)
+		m.aBrokersRx.Delete(ls)
+		m.aBrokersRxbytes.Delete(ls)
+		m.aBrokersRxerrs.Delete(ls)
+		m.aBrokersRxcorriderrs.Delete(ls)
+		m.aBrokersRxpartial.Delete(ls)
+		m.aBrokersRxidle.Delete(ls)
+		m.aBrokersZbufGrow.Delete(ls)
+		m.aBrokersWakeups.Delete(ls)
+		m.aBrokersConnects.Delete(ls)
+		m.aBrokersDisconnects.Delete(ls)
+	}
+	if m.eTopics == nil {
+		m.aTopicsAge.Delete(ls)
+		m.aTopicsMetadataAge.Delete(ls)
+	}
+}
+
+type statsState struct {
+	labels prometheus.Labels
+	last   [13]int64
+	// Children of the metrics bound to labels
+	counters  [13]prometheus.Counter
+	gauges    [5]prometheus.Gauge
+	derived   []prometheus.Gauge
+	nCgrp     statsStateCgrp
+	nEos      statsStateEos
+	eBrokers  map[typed.BrokerName]*statsStateBrokers
+	gBrokers  uint64                                     // Epoch of streaming updates
+	vBrokers  typed.BrokerStats                          // Entry being updated, held here to not allocate per entry
+	laBrokers map[typed.BrokerName]statsStateBrokersLast // Last values of aggregated counters by entry
+	acBrokers [14]prometheus.Counter
+	agBrokers [7]prometheus.Gauge
+	eTopics   map[typed.TopicName]*statsStateTopics
+	gTopics   uint64           // Epoch of streaming updates
+	vTopics   typed.TopicStats // Entry being updated, held here to not allocate per entry
+	agTopics  [2]prometheus.Gauge
+}
+
+type statsStateBrokersLast struct {
+	epoch  uint64 // Epoch of the last update of the entry
+	values [14]int64
+}
+
+// equalLabels reports whether the labels of v and parent did not change
+func (s *statsState) equalLabels(m *statsMetrics, v *typed.Stats, parent prometheus.Labels) bool {
+	if s.labels == nil || len(s.labels) != len(parent)+3 || !gen.ContainsLabels(s.labels, parent) {
+		return false
+	}
+	return true &&
+		s.labels[m.lName] == v.Name &&
+		s.labels[m.lClientId] == v.ClientId &&
+		s.labels[m.lType] == v.Type
+}
+
+// values updates the metrics of this prefix and returns the labels of v
+func (s *statsState) values(m *statsMetrics, v *typed.Stats, parent prometheus.Labels) prometheus.Labels {
+	if !s.equalLabels(m, v, parent) {
+		ls := make(prometheus.Labels, len(parent)+3)
+		for k, lv := range parent {
+			ls[k] = lv
+		}
+		ls[m.lName] = v.Name
+		ls[m.lClientId] = v.ClientId
+		ls[m.lType] = v.Type
+		// Series of outdated labels are dropped
+		if s.labels != nil {
+			m.delete(s.labels)
+		}
+		s.labels = gen.CloneLabels(ls)
+		s.last = [13]int64{}
+		s.counters[0] = m.mTs.With(s.labels)
+		s.counters[1] = m.mTime.With(s.labels)
+		s.counters[2] = m.mAge.With(s.labels)
+		s.gauges[0] = m.mReplyq.With(s.labels)
+		s.gauges[1] = m.mMsgCnt.With(s.labels)
+		s.gauges[2] = m.mMsgSize.With(s.labels)
+		s.counters[3] = m.mMsgMax.With(s.labels)
+		s.counters[4] = m.mMsgSizeMax.With(s.labels)
+		s.counters[5] = m.mTx.With(s.labels)
+		s.counters[6] = m.mTxBytes.With(s.labels)
+		s.counters[7] = m.mRx.With(s.labels)
+		s.counters[8] = m.mRxBytes.With(s.labels)
+		s.counters[9] = m.mTxmsgs.With(s.labels)
+		s.counters[10] = m.mTxmsgBytes.With(s.labels)
+		s.counters[11] = m.mRxmsgs.With(s.labels)
+		s.counters[12] = m.mRxmsgBytes.With(s.labels)
+		s.gauges[3] = m.mSimpleCnt.With(s.labels)
+		s.gauges[4] = m.mMetadataCacheCnt.With(s.labels)
+		s.derived = nil
+		s.laBrokers = nil
+		if m.eBrokers == nil {
+			s.agBrokers[0] = m.aBrokersStateage.With(s.labels)
+			s.agBrokers[1] = m.aBrokersOutbufCnt.With(s.labels)
+			s.agBrokers[2] = m.aBrokersOutbufMsgCnt.With(s.labels)
+			s.agBrokers[3] = m.aBrokersWaitrespCnt.With(s.labels)
+			s.agBrokers[4] = m.aBrokersWaitrespMsgCnt.With(s.labels)
+			s.acBrokers[0] = m.aBrokersTx.With(s.labels)
+			s.acBrokers[1] = m.aBrokersTxbytes.With(s.labels)
+			s.acBrokers[2] = m.aBrokersTxerrs.With(s.labels)
+			s.acBrokers[3] = m.aBrokersTxretries.With(s.labels)
+			s.agBrokers[5] = m.aBrokersTxidle.With(s.labels)
+			s.acBrokers[4] = m.aBrokersReqTimeouts.With(s.labels)
+			s.acBrokers[5] = m.aBrokersRx.With(s.labels)
+			s.acBrokers[6] = m.aBrokersRxbytes.With(s.labels)
+			s.acBrokers[7] = m.aBrokersRxerrs.With(s.labels)
+			s.acBrokers[8] = m.aBrokersRxcorriderrs.With(s.labels)
+			s.acBrokers[9] = m.aBrokersRxpartial.With(s.labels)
+			s.agBrokers[6] = m.aBrokersRxidle.With(s.labels)
+			s.acBrokers[10] = m.aBrokersZbufGrow.With(s.labels)
+			s.acBrokers[11] = m.aBrokersWakeups.With(s.labels)
+			s.acBrokers[12] = m.aBrokersConnects.With(s.labels)
+			s.acBrokers[13] = m.aBrokersDisconnects.With(s.labels)
+		}
+		if m.eTopics == nil {
+			s.agTopics[0] = m.aTopicsAge.With(s.labels)
+			s.agTopics[1] = m.aTopicsMetadataAge.With(s.labels)
+		}
+	}
+	s.last[0] = gen.AddToCounter(s.counters[0], s.last[0], int64(v.Ts))
+	s.last[1] = gen.AddToCounter(s.counters[1], s.last[1], int64(v.Time))
+	s.last[2] = gen.AddToCounter(s.counters[2], s.last[2], int64(v.Age))
+	s.gauges[0].Set(float64(v.Replyq))
+	s.gauges[1].Set(float64(v.MsgCnt))
+	s.gauges[2].Set(float64(v.MsgSize))
+	s.last[3] = gen.AddToCounter(s.counters[3], s.last[3], int64(v.MsgMax))
+	s.last[4] = gen.AddToCounter(s.counters[4], s.last[4], int64(v.MsgSizeMax))
+	s.last[5] = gen.AddToCounter(s.counters[5], s.last[5], int64(v.Tx))
+	s.last[6] = gen.AddToCounter(s.counters[6], s.last[6], int64(v.TxBytes))
+	s.last[7] = gen.AddToCounter(s.counters[7], s.last[7], int64(v.Rx))
+	s.last[8] = gen.AddToCounter(s.counters[8], s.last[8], int64(v.RxBytes))
+	s.last[9] = gen.AddToCounter(s.counters[9], s.last[9], int64(v.Txmsgs))
+	s.last[10] = gen.AddToCounter(s.counters[10], s.last[10], int64(v.TxmsgBytes))
+	s.last[11] = gen.AddToCounter(s.counters[11], s.last[11], int64(v.Rxmsgs))
+	s.last[12] = gen.AddToCounter(s.counters[12], s.last[12], int64(v.RxmsgBytes))
+	s.gauges[3].Set(float64(v.SimpleCnt))
+	s.gauges[4].Set(float64(v.MetadataCacheCnt))
+	return s.labels
+}
+
+// derive updates the derived metrics with the complete value v. Their
+// series are created on the first call after a change of labels.
+func (s *statsState) derive(m *statsMetrics, v *typed.Stats) {
+	if s.derived == nil && len(m.derived) != 0 {
+		s.derived = make([]prometheus.Gauge, len(m.derived))
+		for i := range m.derived {
+			s.derived[i] = m.derived[i].Vec.With(s.labels)
+		}
+	}
+	for i, d := range s.derived {
+		d.Set(m.derived[i].Fun(v))
+	}
+}
+
+func (s *statsState) update(m *statsMetrics, v *typed.Stats, parent prometheus.Labels) {
+	ls := s.values(m, v, parent)
+	s.derive(m, v)
+	s.nCgrp.update(m.nCgrp, &v.Cgrp, ls)
+	s.nEos.update(m.nEos, &v.Eos, ls)
+	if m.eBrokers == nil {
+		var agg [21]int64
+		first := true
+		initial := s.laBrokers == nil
+		if initial {
+			s.laBrokers = map[typed.BrokerName]statsStateBrokersLast{}
+		}
+		s.gBrokers++
+		for k, ev := range v.Brokers {
+			if m.fBrokers != nil && !m.fBrokers(k, ev) {
+				continue
+			}
+			last, known := s.laBrokers[k]
+			last.epoch = s.gBrokers
+			if first || int64(ev.Stateage) > agg[0] {
+				agg[0] = int64(ev.Stateage)
+			}
+			if x := int64(ev.OutbufCnt); x > 0 {
+				agg[1] += x
+			}
+			if x := int64(ev.OutbufMsgCnt); x > 0 {
+				agg[2] += x
+			}
+			if x := int64(ev.WaitrespCnt); x > 0 {
+				agg[3] += x
+			}
+			if x := int64(ev.WaitrespMsgCnt); x > 0 {
+				agg[4] += x
+			}
+			if x := int64(ev.Tx); x >= 0 {
+				if (known || initial) && x > last.values[0] {
+					agg[5] += x - last.values[0]
+				}
+				last.values[0] = x
+			}
+			if x := int64(ev.Txbytes); x >= 0 {
+				if (known || initial) && x > last.values[1] {
+					agg[6] += x - last.values[1]
+				}
+				last.values[1] = x
+			}
+			if x := int64(ev.Txerrs); x >= 0 {
+				if (known || initial) && x > last.values[2] {
+					agg[7] += x - last.values[2]
+				}
+				last.values[2] = x
+			}
+			if x := int64(ev.Txretries); x >= 0 {
+				if (known || initial) && x > last.values[3] {
+					agg[8] += x - last.values[3]
+				}
+				last.values[3] = x
+			}
+			if first || int64(ev.Txidle) > agg[9] {
+				agg[9] = int64(ev.Txidle)
+			}
+			if x := int64(ev.ReqTimeouts); x >= 0 {
+				if (known || initial) && x > last.values[4] {
+					agg[10] += x - last.values[4]
+				}
+				last.values[4] = x
+			}
+			if x := int64(ev.Rx); x >= 0 {
+				if (known || initial) && x > last.values[5] {
+					agg[11] += x - last.values[5]
+				}
+				last.values[5] = x
+			}
+			if x := int64(ev.Rxbytes); x >= 0 {
+				if (known || initial) && x > last.values[6] {
+					agg[12] += x - last.values[6]
+				}
+				last.values[6] = x
+			}
+			if x := int64(ev.Rxerrs); x >= 0 {
+				if (known || initial) && x > last.values[7] {
+					agg[13] += x - last.values[7]
+				}
+				last.values[7] = x
+			}
+			if x := int64(ev.Rxcorriderrs); x >= 0 {
+				if (known || initial) && x > last.values[8] {
+					agg[14] += x - last.values[8]
+				}
+				last.values[8] = x
+			}
+			if x := int64(ev.Rxpartial); x >= 0 {
+				if (known || initial) && x > last.values[9] {
+					agg[15] += x - last.values[9]
+				}
+				last.values[9] = x
+			}
+			if first || int64(ev.Rxidle) > agg[16] {
+				agg[16] = int64(ev.Rxidle)
+			}
+			if x := int64(ev.ZbufGrow); x >= 0 {
+				if (known || initial) && x > last.values[10] {
+					agg[17] += x - last.values[10]
+				}
+				last.values[10] = x
+			}
+			if x := int64(ev.Wakeups); x >= 0 {
+				if (known || initial) && x > last.values[11] {
+					agg[18] += x - last.values[11]
+				}
+				last.values[11] = x
+			}
+			if x := int64(ev.Connects); x >= 0 {
+				if (known || initial) && x > last.values[12] {
+					agg[19] += x - last.values[12]
+				}
+				last.values[12] = x
+			}
+			if x := int64(ev.Disconnects); x >= 0 {
+				if (known || initial) && x > last.values[13] {
+					agg[20] += x - last.values[13]
+				}
+				last.values[13] = x
+			}
+			s.laBrokers[k] = last
+			first = false
+		}
+		for k, last := range s.laBrokers {
+			if last.epoch != s.gBrokers {
+				delete(s.laBrokers, k)
+			}
+		}
+		s.agBrokers[0].Set(float64(agg[0]))
+		s.agBrokers[1].Set(float64(agg[1]))
+		s.agBrokers[2].Set(float64(agg[2]))
+		s.agBrokers[3].Set(float64(agg[3]))
+		s.agBrokers[4].Set(float64(agg[4]))
+		s.acBrokers[0].Add(float64(agg[5]))
+		s.acBrokers[1].Add(float64(agg[6]))
+		s.acBrokers[2].Add(float64(agg[7]))
+		s.acBrokers[3].Add(float64(agg[8]))
+		s.agBrokers[5].Set(float64(agg[9]))
+		s.acBrokers[4].Add(float64(agg[10]))
+		s.acBrokers[5].Add(float64(agg[11]))
+		s.acBrokers[6].Add(float64(agg[12]))
+		s.acBrokers[7].Add(float64(agg[13]))
+		s.acBrokers[8].Add(float64(agg[14]))
+		s.acBrokers[9].Add(float64(agg[15]))
+		s.agBrokers[6].Set(float64(agg[16]))
+		s.acBrokers[10].Add(float64(agg[17]))
+		s.acBrokers[11].Add(float64(agg[18]))
+		s.acBrokers[12].Add(float64(agg[19]))
+		s.acBrokers[13].Add(float64(agg[20]))
+	} else {
+		if s.eBrokers == nil {
+			s.eBrokers = map[typed.BrokerName]*statsStateBrokers{}
+		}
+		for k, es := range s.eBrokers {
+			ev, ok := v.Brokers[k]
+			if !ok || (m.fBrokers != nil && !m.fBrokers(k, ev)) {
+				es.delete(m.eBrokers)
+				delete(s.eBrokers, k)
+			}
+		}
+		for k, ev := range v.Brokers {
+			if m.fBrokers != nil && !m.fBrokers(k, ev) {
+				continue
+			}
+			es, ok := s.eBrokers[k]
+			if !ok {
+				es = &statsStateBrokers{}
+				s.eBrokers[k] = es
+			}
+			s.vBrokers = ev
+			es.update(m.eBrokers, &s.vBrokers, ls)
+		}
+	}
+	if m.eTopics == nil {
+		var agg [2]int64
+		first := true
+		for k, ev := range v.Topics {
+			if m.fTopics != nil && !m.fTopics(k, ev) {
+				continue
+			}
+			if first || int64(ev.Age) > agg[0] {
+				agg[0] = int64(ev.Age)
+			}
+			if first || int64(ev.MetadataAge) > agg[1] {
+				agg[1] = int64(ev.MetadataAge)
+			}
+			first = false
+		}
+		s.agTopics[0].Set(float64(agg[0]))
+		s.agTopics[1].Set(float64(agg[1]))
+	} else {
+		if s.eTopics == nil {
+			s.eTopics = map[typed.TopicName]*statsStateTopics{}
+		}
+		for k, es := range s.eTopics {
+			ev, ok := v.Topics[k]
+			if !ok || (m.fTopics != nil && !m.fTopics(k, ev)) {
+				es.delete(m.eTopics)
+				delete(s.eTopics, k)
+			}
+		}
+		for k, ev := range v.Topics {
+			if m.fTopics != nil && !m.fTopics(k, ev) {
+				continue
+			}
+			es, ok := s.eTopics[k]
+			if !ok {
+				es = &statsStateTopics{}
+				s.eTopics[k] = es
+			}
+			s.vTopics = ev
+			es.update(m.eTopics, &s.vTopics, ls)
+		}
+	}
+}
+
+// statsRaw holds the decoded fields of a JSON value. Maps and nested
+// structs are held by the raw values they contain.
+type statsRaw struct {
+	v        typed.Stats
+	nCgrp    statsRawCgrp
+	nEos     statsRawEos
+	eBrokers []statsRawBrokersEntry
+	eTopics  []statsRawTopicsEntry
+}
+
+type statsRawBrokersEntry struct {
+	k    typed.BrokerName
+	r    statsRawBrokers
+	data string // Raw JSON of the entry
+	skip bool   // Whether the entry got filtered
+}
+
+type statsRawTopicsEntry struct {
+	k    typed.TopicName
+	r    statsRawTopics
+	data string // Raw JSON of the entry
+	skip bool   // Whether the entry got filtered
+}
+
+func (r *statsRaw) decode(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		var err error
+		switch o.Key() {
+		case "name":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.Name = string(x)
+		case "client_id":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.ClientId = string(x)
+		case "type":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.Type = string(x)
+		case "ts":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Ts = int(x)
+		case "time":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Time = int(x)
+		case "age":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Age = int(x)
+		case "replyq":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Replyq = int(x)
+		case "msg_cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.MsgCnt = int(x)
+		case "msg_size":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.MsgSize = int(x)
+		case "msg_max":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.MsgMax = int(x)
+		case "msg_size_max":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.MsgSizeMax = int(x)
+		case "tx":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Tx = int(x)
+		case "tx_bytes":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.TxBytes = int(x)
+		case "rx":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Rx = int(x)
+		case "rx_bytes":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.RxBytes = int(x)
+		case "txmsgs":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Txmsgs = int(x)
+		case "txmsg_bytes":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.TxmsgBytes = int(x)
+		case "rxmsgs":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Rxmsgs = int(x)
+		case "rxmsg_bytes":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.RxmsgBytes = int(x)
+		case "simple_cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.SimpleCnt = int(x)
+		case "metadata_cache_cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.MetadataCacheCnt = int(x)
+		case "cgrp":
+			err = r.nCgrp.decode(o.Value())
+		case "eos":
+			err = r.nEos.decode(o.Value())
+		case "brokers":
+			err = r.decodeBrokers(o.Value())
+		case "topics":
+			err = r.decodeTopics(o.Value())
+		}
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+func (r *statsRaw) decodeBrokers(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		r.eBrokers = append(r.eBrokers, statsRawBrokersEntry{k: typed.BrokerName(o.Key()), data: o.Value()})
+		err := r.eBrokers[len(r.eBrokers)-1].r.decode(o.Value())
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+func (r *statsRaw) decodeTopics(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		r.eTopics = append(r.eTopics, statsRawTopicsEntry{k: typed.TopicName(o.Key()), data: o.Value()})
+		err := r.eTopics[len(r.eTopics)-1].r.decode(o.Value())
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+// filter marks the entries of maps skipped by the filters of m. Fails
+// if an entry to filter cannot be decoded completely.
+func (r *statsRaw) filter(m *statsMetrics) error {
+	var err error
+	for i := range r.eBrokers {
+		e := &r.eBrokers[i]
+		if m.fBrokers != nil {
+			var v typed.BrokerStats
+			err = gen.DecodeJSON(e.data, &v)
+			if err != nil {
+				return gen.JSONFieldError("brokers", err)
+			}
+			e.skip = !m.fBrokers(e.k, v)
+		}
+	}
+	for i := range r.eTopics {
+		e := &r.eTopics[i]
+		if m.fTopics != nil {
+			var v typed.TopicStats
+			err = gen.DecodeJSON(e.data, &v)
+			if err != nil {
+				return gen.JSONFieldError("topics", err)
+			}
+			e.skip = !m.fTopics(e.k, v)
+		}
+		if !e.skip && m.eTopics != nil {
+			err = e.r.filter(m.eTopics)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *statsState) stream(m *statsMetrics, r *statsRaw, parent prometheus.Labels) {
+	ls := s.values(m, &r.v, parent)
+	s.nCgrp.stream(m.nCgrp, &r.nCgrp, ls)
+	s.nEos.stream(m.nEos, &r.nEos, ls)
+	if m.eBrokers == nil {
+		var agg [21]int64
+		first := true
+		initial := s.laBrokers == nil
+		if initial {
+			s.laBrokers = map[typed.BrokerName]statsStateBrokersLast{}
+		}
+		s.gBrokers++
+		for i := range r.eBrokers {
+			if r.eBrokers[i].skip {
+				continue
+			}
+			k, er := r.eBrokers[i].k, &r.eBrokers[i].r
+			last, known := s.laBrokers[k]
+			last.epoch = s.gBrokers
+			if first || int64(er.v.Stateage) > agg[0] {
+				agg[0] = int64(er.v.Stateage)
+			}
+			if x := int64(er.v.OutbufCnt); x > 0 {
+				agg[1] += x
+			}
+			if x := int64(er.v.OutbufMsgCnt); x > 0 {
+				agg[2] += x
+			}
+			if x := int64(er.v.WaitrespCnt); x > 0 {
+				agg[3] += x
+			}
+			if x := int64(er.v.WaitrespMsgCnt); x > 0 {
+				agg[4] += x
+			}
+			if x := int64(er.v.Tx); x >= 0 {
+				if (known || initial) && x > last.values[0] {
+					agg[5] += x - last.values[0]
+				}
+				last.values[0] = x
+			}
+			if x := int64(er.v.Txbytes); x >= 0 {
+				if (known || initial) && x > last.values[1] {
+					agg[6] += x - last.values[1]
+				}
+				last.values[1] = x
+			}
+			if x := int64(er.v.Txerrs); x >= 0 {
+				if (known || initial) && x > last.values[2] {
+					agg[7] += x - last.values[2]
+				}
+				last.values[2] = x
+			}
+			if x := int64(er.v.Txretries); x >= 0 {
+				if (known || initial) && x > last.values[3] {
+					agg[8] += x - last.values[3]
+				}
+				last.values[3] = x
+			}
+			if first || int64(er.v.Txidle) > agg[9] {
+				agg[9] = int64(er.v.Txidle)
+			}
+			if x := int64(er.v.ReqTimeouts); x >= 0 {
+				if (known || initial) && x > last.values[4] {
+					agg[10] += x - last.values[4]
+				}
+				last.values[4] = x
+			}
+			if x := int64(er.v.Rx); x >= 0 {
+				if (known || initial) && x > last.values[5] {
+					agg[11] += x - last.values[5]
+				}
+				last.values[5] = x
+			}
+			if x := int64(er.v.Rxbytes); x >= 0 {
+				if (known || initial) && x > last.values[6] {
+					agg[12] += x - last.values[6]
+				}
+				last.values[6] = x
+			}
+			if x := int64(er.v.Rxerrs); x >= 0 {
+				if (known || initial) && x > last.values[7] {
+					agg[13] += x - last.values[7]
+				}
+				last.values[7] = x
+			}
+			if x := int64(er.v.Rxcorriderrs); x >= 0 {
+				if (known || initial) && x > last.values[8] {
+					agg[14] += x - last.values[8]
+				}
+				last.values[8] = x
+			}
+			if x := int64(er.v.Rxpartial); x >= 0 {
+				if (known || initial) && x > last.values[9] {
+					agg[15] += x - last.values[9]
+				}
+				last.values[9] = x
+			}
+			if first || int64(er.v.Rxidle) > agg[16] {
+				agg[16] = int64(er.v.Rxidle)
+			}
+			if x := int64(er.v.ZbufGrow); x >= 0 {
+				if (known || initial) && x > last.values[10] {
+					agg[17] += x - last.values[10]
+				}
+				last.values[10] = x
+			}
+			if x := int64(er.v.Wakeups); x >= 0 {
+				if (known || initial) && x > last.values[11] {
+					agg[18] += x - last.values[11]
+				}
+				last.values[11] = x
+			}
+			if x := int64(er.v.Connects); x >= 0 {
+				if (known || initial) && x > last.values[12] {
+					agg[19] += x - last.values[12]
+				}
+				last.values[12] = x
+			}
+			if x := int64(er.v.Disconnects); x >= 0 {
+				if (known || initial) && x > last.values[13] {
+					agg[20] += x - last.values[13]
+				}
+				last.values[13] = x
+			}
+			s.laBrokers[k] = last
+			first = false
+		}
+		for k, last := range s.laBrokers {
+			if last.epoch != s.gBrokers {
+				delete(s.laBrokers, k)
+			}
+		}
+		s.agBrokers[0].Set(float64(agg[0]))
+		s.agBrokers[1].Set(float64(agg[1]))
+		s.agBrokers[2].Set(float64(agg[2]))
+		s.agBrokers[3].Set(float64(agg[3]))
+		s.agBrokers[4].Set(float64(agg[4]))
+		s.acBrokers[0].Add(float64(agg[5]))
+		s.acBrokers[1].Add(float64(agg[6]))
+		s.acBrokers[2].Add(float64(agg[7]))
+		s.acBrokers[3].Add(float64(agg[8]))
+		s.agBrokers[5].Set(float64(agg[9]))
+		s.acBrokers[4].Add(float64(agg[10]))
+		s.acBrokers[5].Add(float64(agg[11]))
+		s.acBrokers[6].Add(float64(agg[12]))
+		s.acBrokers[7].Add(float64(agg[13]))
+		s.acBrokers[8].Add(float64(agg[14]))
+		s.acBrokers[9].Add(float64(agg[15]))
+		s.agBrokers[6].Set(float64(agg[16]))
+		s.acBrokers[10].Add(float64(agg[17]))
+		s.acBrokers[11].Add(float64(agg[18]))
+		s.acBrokers[12].Add(float64(agg[19]))
+		s.acBrokers[13].Add(float64(agg[20]))
+	} else {
+		if s.eBrokers == nil {
+			s.eBrokers = map[typed.BrokerName]*statsStateBrokers{}
+		}
+		// Entries not updated in this epoch are gone
+		s.gBrokers++
+		for i := range r.eBrokers {
+			if r.eBrokers[i].skip {
+				continue
+			}
+			k, er := r.eBrokers[i].k, &r.eBrokers[i].r
+			es, ok := s.eBrokers[k]
+			if !ok {
+				es = &statsStateBrokers{}
+				s.eBrokers[k] = es
+			}
+			es.epoch = s.gBrokers
+			es.stream(m.eBrokers, er, ls)
+		}
+		for k, es := range s.eBrokers {
+			if es.epoch != s.gBrokers {
+				es.delete(m.eBrokers)
+				delete(s.eBrokers, k)
+			}
+		}
+	}
+	if m.eTopics == nil {
+		var agg [2]int64
+		first := true
+		for i := range r.eTopics {
+			if r.eTopics[i].skip {
+				continue
+			}
+			er := &r.eTopics[i].r
+			if first || int64(er.v.Age) > agg[0] {
+				agg[0] = int64(er.v.Age)
+			}
+			if first || int64(er.v.MetadataAge) > agg[1] {
+				agg[1] = int64(er.v.MetadataAge)
+			}
+			first = false
+		}
+		s.agTopics[0].Set(float64(agg[0]))
+		s.agTopics[1].Set(float64(agg[1]))
+	} else {
+		if s.eTopics == nil {
+			s.eTopics = map[typed.TopicName]*statsStateTopics{}
+		}
+		// Entries not updated in this epoch are gone
+		s.gTopics++
+		for i := range r.eTopics {
+			if r.eTopics[i].skip {
+				continue
+			}
+			k, er := r.eTopics[i].k, &r.eTopics[i].r
+			es, ok := s.eTopics[k]
+			if !ok {
+				es = &statsStateTopics{}
+				s.eTopics[k] = es
+			}
+			es.epoch = s.gTopics
+			es.stream(m.eTopics, er, ls)
+		}
+		for k, es := range s.eTopics {
+			if es.epoch != s.gTopics {
+				es.delete(m.eTopics)
+				delete(s.eTopics, k)
+			}
+		}
+	}
+}
+
+func (s *statsState) count(m *statsMetrics, entries map[string]int) int {
+	if s.labels == nil {
+		return 0
+	}
+	series := 18 + len(s.derived)
+	series += s.nCgrp.count(m.nCgrp, entries)
+	series += s.nEos.count(m.nEos, entries)
+	if m.eBrokers == nil {
+		series += 21
+	} else {
+		entries["brokers"] += len(s.eBrokers)
+		for _, es := range s.eBrokers {
+			series += es.count(m.eBrokers, entries)
+		}
+	}
+	if m.eTopics == nil {
+		series += 2
+	} else {
+		entries["topics"] += len(s.eTopics)
+		for _, es := range s.eTopics {
+			series += es.count(m.eTopics, entries)
+		}
+	}
+	return series
+}
+
+// delete removes all series of this value and the values it contains
+func (s *statsState) delete(m *statsMetrics) {
+	if s.labels != nil {
+		m.delete(s.labels)
+	}
+	s.nCgrp.delete(m.nCgrp)
+	s.nEos.delete(m.nEos)
+	for _, es := range s.eBrokers {
+		es.delete(m.eBrokers)
+	}
+	for _, es := range s.eTopics {
+		es.delete(m.eTopics)
+	}
+}
+
+type statsMetricsCgrp struct {
+	labelNames       []string
+	lState           string
+	lJoinState       string
+	lRebalanceReason string
+	mStateage        *prometheus.GaugeVec
+	mRebalanceAge    *prometheus.GaugeVec
+	mRebalanceCnt    *prometheus.CounterVec
+	mAssignmentSize  *prometheus.GaugeVec
+	derived          []gen.GeneratedDerived
+}
+
+func newStatsMetricsCgrp(o *gen.GeneratedOptions, parentLabelNames []string) *statsMetricsCgrp {
+	m := &statsMetricsCgrp{}
+	m.labelNames = append(m.labelNames, parentLabelNames...)
+	m.lState = o.LabelName("cgrp", "state")
+	m.labelNames = append(m.labelNames, m.lState)
+	m.lJoinState = o.LabelName("cgrp", "join_state")
+	m.labelNames = append(m.labelNames, m.lJoinState)
+	m.lRebalanceReason = o.LabelName("cgrp", "rebalance_reason")
+	m.labelNames = append(m.labelNames, m.lRebalanceReason)
+	m.mStateage = o.NewGaugeVec("cgrp", "Stateage", "Time elapsed since last state change (milliseconds).", m.labelNames)
+	m.mRebalanceAge = o.NewGaugeVec("cgrp", "RebalanceAge", "Time elapsed since last rebalance (assign or revoke) (milliseconds).", m.labelNames)
+	m.mRebalanceCnt = o.NewCounterVec("cgrp", "RebalanceCnt", "Total number of rebalances (assign or revoke).", m.labelNames)
+	m.mAssignmentSize = o.NewGaugeVec("cgrp", "AssignmentSize", "Current assignment's partition count.", m.labelNames)
+	m.derived = o.NewDerived("cgrp", "Stats.Cgrp", reflect.TypeOf(typed.CgrpStats{}), m.labelNames)
+	return m
+}
+
+// share returns a copy of m computing filters and derived metrics with
+// the functions of o
+func (m *statsMetricsCgrp) share(o *gen.GeneratedOptions) *statsMetricsCgrp {
+	c := *m
+	c.derived = o.ShareDerived(m.derived, "Stats.Cgrp")
+	return &c
+}
+
+func (m *statsMetricsCgrp) describe(ch chan<- *prometheus.Desc) {
+	m.mStateage.Describe(ch)
+	m.mRebalanceAge.Describe(ch)
+	m.mRebalanceCnt.Describe(ch)
+	m.mAssignmentSize.Describe(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Describe(ch)
+	}
+}
+
+func (m *statsMetricsCgrp) collect(ch chan<- prometheus.Metric) {
+	m.mStateage.Collect(ch)
+	m.mRebalanceAge.Collect(ch)
+	m.mRebalanceCnt.Collect(ch)
+	m.mAssignmentSize.Collect(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Collect(ch)
+	}
+}
+
+// delete removes the series with labels ls of this prefix
+func (m *statsMetricsCgrp) delete(ls prometheus.Labels) {
+	m.mStateage.Delete(ls)
+	m.mRebalanceAge.Delete(ls)
+	m.mRebalanceCnt.Delete(ls)
+	m.mAssignmentSize.Delete(ls)
+	for i := range m.derived {
+		m.derived[i].Vec.Delete(ls)
+	}
+}
+
+type statsStateCgrp struct {
+	labels prometheus.Labels
+	last   [1]int64
+	// Children of the metrics bound to labels
+	counters [1]prometheus.Counter
+	gauges   [3]prometheus.Gauge
+	derived  []prometheus.Gauge
+}
+
+// equalLabels reports whether the labels of v and parent did not change
+func (s *statsStateCgrp) equalLabels(m *statsMetricsCgrp, v *typed.CgrpStats, parent prometheus.Labels) bool {
+	if s.labels == nil || len(s.labels) != len(parent)+3 || !gen.ContainsLabels(s.labels, parent) {
+		return false
+	}
+	return true &&
+		s.labels[m.lState] == v.State &&
+		s.labels[m.lJoinState] == v.JoinState &&
+		s.labels[m.lRebalanceReason] == v.RebalanceReason
+}
+
+// values updates the metrics of this prefix and returns the labels of v
+func (s *statsStateCgrp) values(m *statsMetricsCgrp, v *typed.CgrpStats, parent prometheus.Labels) prometheus.Labels {
+	if !s.equalLabels(m, v, parent) {
+		ls := make(prometheus.Labels, len(parent)+3)
+		for k, lv := range parent {
+			ls[k] = lv
+		}
+		ls[m.lState] = v.State
+		ls[m.lJoinState] = v.JoinState
+		ls[m.lRebalanceReason] = v.RebalanceReason
+		// Series of outdated labels are dropped
+		if s.labels != nil {
+			m.delete(s.labels)
+		}
+		s.labels = gen.CloneLabels(ls)
+		s.last = [1]int64{}
+		s.gauges[0] = m.mStateage.With(s.labels)
+		s.gauges[1] = m.mRebalanceAge.With(s.labels)
+		s.counters[0] = m.mRebalanceCnt.With(s.labels)
+		s.gauges[2] = m.mAssignmentSize.With(s.labels)
+		s.derived = nil
+	}
+	s.gauges[0].Set(float64(v.Stateage))
+	s.gauges[1].Set(float64(v.RebalanceAge))
+	s.last[0] = gen.AddToCounter(s.counters[0], s.last[0], int64(v.RebalanceCnt))
+	s.gauges[2].Set(float64(v.AssignmentSize))
+	return s.labels
+}
+
+// derive updates the derived metrics with the complete value v. Their
+// series are created on the first call after a change of labels.
+func (s *statsStateCgrp) derive(m *statsMetricsCgrp, v *typed.CgrpStats) {
+	if s.derived == nil && len(m.derived) != 0 {
+		s.derived = make([]prometheus.Gauge, len(m.derived))
+		for i := range m.derived {
+			s.derived[i] = m.derived[i].Vec.With(s.labels)
+		}
+	}
+	for i, d := range s.derived {
+		d.Set(m.derived[i].Fun(v))
+	}
+}
+
+func (s *statsStateCgrp) update(m *statsMetricsCgrp, v *typed.CgrpStats, parent prometheus.Labels) {
+	s.values(m, v, parent)
+	s.derive(m, v)
+}
+
+// statsRawCgrp holds the decoded fields of a JSON value. Maps and nested
+// structs are held by the raw values they contain.
+type statsRawCgrp struct {
+	v typed.CgrpStats
+}
+
+func (r *statsRawCgrp) decode(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		var err error
+		switch o.Key() {
+		case "state":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.State = string(x)
+		case "stateage":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Stateage = int(x)
+		case "join_state":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.JoinState = string(x)
+		case "rebalance_age":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.RebalanceAge = int(x)
+		case "rebalance_cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.RebalanceCnt = int(x)
+		case "rebalance_reason":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.RebalanceReason = string(x)
+		case "assignment_size":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.AssignmentSize = int(x)
+		}
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+// filter marks the entries of maps skipped by the filters of m. Fails
+// if an entry to filter cannot be decoded completely.
+func (r *statsRawCgrp) filter(m *statsMetricsCgrp) error {
+	return nil
+}
+
+func (s *statsStateCgrp) stream(m *statsMetricsCgrp, r *statsRawCgrp, parent prometheus.Labels) {
+	s.values(m, &r.v, parent)
+}
+
+func (s *statsStateCgrp) count(m *statsMetricsCgrp, entries map[string]int) int {
+	if s.labels == nil {
+		return 0
+	}
+	series := 4 + len(s.derived)
+	return series
+}
+
+// delete removes all series of this value and the values it contains
+func (s *statsStateCgrp) delete(m *statsMetricsCgrp) {
+	if s.labels != nil {
+		m.delete(s.labels)
+	}
+}
+
+type statsMetricsEos struct {
+	labelNames     []string
+	lIdempState    string
+	lTxnState      string
+	lProducerId    string
+	mIdempStateage *prometheus.GaugeVec
+	mTxnStateage   *prometheus.GaugeVec
+	mEpochCnt      *prometheus.GaugeVec
+	derived        []gen.GeneratedDerived
+}
+
+func newStatsMetricsEos(o *gen.GeneratedOptions, parentLabelNames []string) *statsMetricsEos {
+	m := &statsMetricsEos{}
+	m.labelNames = append(m.labelNames, parentLabelNames...)
+	m.lIdempState = o.LabelName("eos", "idemp_state")
+	m.labelNames = append(m.labelNames, m.lIdempState)
+	m.lTxnState = o.LabelName("eos", "txn_state")
+	m.labelNames = append(m.labelNames, m.lTxnState)
+	m.lProducerId = o.LabelName("eos", "producer_id")
+	m.labelNames = append(m.labelNames, m.lProducerId)
+	m.mIdempStateage = o.NewGaugeVec("eos", "IdempStateage", "Time elapsed since last idemp_state change (milliseconds).", m.labelNames)
+	m.mTxnStateage = o.NewGaugeVec("eos", "TxnStateage", "Time elapsed since last txn_state change (milliseconds).", m.labelNames)
+	m.mEpochCnt = o.NewGaugeVec("eos", "EpochCnt", "The number of Producer ID assignments since start.", m.labelNames)
+	m.derived = o.NewDerived("eos", "Stats.Eos", reflect.TypeOf(typed.EosStats{}), m.labelNames)
+	return m
+}
+
+// share returns a copy of m computing filters and derived metrics with
+// the functions of o
+func (m *statsMetricsEos) share(o *gen.GeneratedOptions) *statsMetricsEos {
+	c := *m
+	c.derived = o.ShareDerived(m.derived, "Stats.Eos")
+	return &c
+}
+
+func (m *statsMetricsEos) describe(ch chan<- *prometheus.Desc) {
+	m.mIdempStateage.Describe(ch)
+	m.mTxnStateage.Describe(ch)
+	m.mEpochCnt.Describe(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Describe(ch)
+	}
+}
+
+func (m *statsMetricsEos) collect(ch chan<- prometheus.Metric) {
+	m.mIdempStateage.Collect(ch)
+	m.mTxnStateage.Collect(ch)
+	m.mEpochCnt.Collect(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Collect(ch)
+	}
+}
+
+// delete removes the series with labels ls of this prefix
+func (m *statsMetricsEos) delete(ls prometheus.Labels) {
+	m.mIdempStateage.Delete(ls)
+	m.mTxnStateage.Delete(ls)
+	m.mEpochCnt.Delete(ls)
+	for i := range m.derived {
+		m.derived[i].Vec.Delete(ls)
+	}
+}
+
+type statsStateEos struct {
+	labels prometheus.Labels
+	// Children of the metrics bound to labels
+	gauges  [3]prometheus.Gauge
+	derived []prometheus.Gauge
+}
+
+// equalLabels reports whether the labels of v and parent did not change
+func (s *statsStateEos) equalLabels(m *statsMetricsEos, v *typed.EosStats, parent prometheus.Labels) bool {
+	if s.labels == nil || len(s.labels) != len(parent)+3 || !gen.ContainsLabels(s.labels, parent) {
+		return false
+	}
+	return true &&
+		s.labels[m.lIdempState] == v.IdempState &&
+		s.labels[m.lTxnState] == v.TxnState &&
+		gen.EqualIntLabel(s.labels[m.lProducerId], int64(v.ProducerId))
+}
+
+// values updates the metrics of this prefix and returns the labels of v
+func (s *statsStateEos) values(m *statsMetricsEos, v *typed.EosStats, parent prometheus.Labels) prometheus.Labels {
+	if !s.equalLabels(m, v, parent) {
+		ls := make(prometheus.Labels, len(parent)+3)
+		for k, lv := range parent {
+			ls[k] = lv
+		}
+		ls[m.lIdempState] = v.IdempState
+		ls[m.lTxnState] = v.TxnState
+		ls[m.lProducerId] = strconv.FormatInt(int64(v.ProducerId), 10)
+		// Series of outdated labels are dropped
+		if s.labels != nil {
+			m.delete(s.labels)
+		}
+		s.labels = gen.CloneLabels(ls)
+		s.gauges[0] = m.mIdempStateage.With(s.labels)
+		s.gauges[1] = m.mTxnStateage.With(s.labels)
+		s.gauges[2] = m.mEpochCnt.With(s.labels)
+		s.derived = nil
+	}
+	s.gauges[0].Set(float64(v.IdempStateage))
+	s.gauges[1].Set(float64(v.TxnStateage))
+	s.gauges[2].Set(float64(v.EpochCnt))
+	return s.labels
+}
+
+// derive updates the derived metrics with the complete value v. Their
+// series are created on the first call after a change of labels.
+func (s *statsStateEos) derive(m *statsMetricsEos, v *typed.EosStats) {
+	if s.derived == nil && len(m.derived) != 0 {
+		s.derived = make([]prometheus.Gauge, len(m.derived))
+		for i := range m.derived {
+			s.derived[i] = m.derived[i].Vec.With(s.labels)
+		}
+	}
+	for i, d := range s.derived {
+		d.Set(m.derived[i].Fun(v))
+	}
+}
+
+func (s *statsStateEos) update(m *statsMetricsEos, v *typed.EosStats, parent prometheus.Labels) {
+	s.values(m, v, parent)
+	s.derive(m, v)
+}
+
+// statsRawEos holds the decoded fields of a JSON value. Maps and nested
+// structs are held by the raw values they contain.
+type statsRawEos struct {
+	v typed.EosStats
+}
+
+func (r *statsRawEos) decode(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		var err error
+		switch o.Key() {
+		case "idemp_state":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.IdempState = string(x)
+		case "idemp_stateage":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.IdempStateage = int(x)
+		case "txn_state":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.TxnState = string(x)
+		case "txn_stateage":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.TxnStateage = int(x)
+		case "txn_may_enq":
+			var x bool
+			x, err = gen.JSONBool(o.Value())
+			r.v.TxnMayEnq = bool(x)
+		case "producer_id":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.ProducerId = int(x)
+		case "producer_epoch":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.ProducerEpoch = int(x)
+		case "epoch_cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.EpochCnt = int(x)
+		}
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+// filter marks the entries of maps skipped by the filters of m. Fails
+// if an entry to filter cannot be decoded completely.
+func (r *statsRawEos) filter(m *statsMetricsEos) error {
+	return nil
+}
+
+func (s *statsStateEos) stream(m *statsMetricsEos, r *statsRawEos, parent prometheus.Labels) {
+	s.values(m, &r.v, parent)
+}
+
+func (s *statsStateEos) count(m *statsMetricsEos, entries map[string]int) int {
+	if s.labels == nil {
+		return 0
+	}
+	series := 3 + len(s.derived)
+	return series
+}
+
+// delete removes all series of this value and the values it contains
+func (s *statsStateEos) delete(m *statsMetricsEos) {
+	if s.labels != nil {
+		m.delete(s.labels)
+	}
+}
+
+type statsMetricsBrokers struct {
+	labelNames      []string
+	lName           string
+	lNodeid         string
+	lNodename       string
+	lSource         string
+	lState          string
+	mStateage       *prometheus.GaugeVec
+	mOutbufCnt      *prometheus.GaugeVec
+	mOutbufMsgCnt   *prometheus.GaugeVec
+	mWaitrespCnt    *prometheus.GaugeVec
+	mWaitrespMsgCnt *prometheus.GaugeVec
+	mTx             *prometheus.CounterVec
+	mTxbytes        *prometheus.CounterVec
+	mTxerrs         *prometheus.CounterVec
+	mTxretries      *prometheus.CounterVec
+	mTxidle         *prometheus.CounterVec
+	mReqTimeouts    *prometheus.CounterVec
+	mRx             *prometheus.CounterVec
+	mRxbytes        *prometheus.CounterVec
+	mRxerrs         *prometheus.CounterVec
+	mRxcorriderrs   *prometheus.CounterVec
+	mRxpartial      *prometheus.CounterVec
+	mRxidle         *prometheus.CounterVec
+	mZbufGrow       *prometheus.CounterVec
+	mWakeups        *prometheus.CounterVec
+	mConnects       *prometheus.CounterVec
+	mDisconnects    *prometheus.CounterVec
+	derived         []gen.GeneratedDerived
+	nIntLatency     *statsMetricsBrokersIntLatency
+	nOutbufLatency  *statsMetricsBrokersOutbufLatency
+	nRtt            *statsMetricsBrokersRtt
+	nThrottle       *statsMetricsBrokersThrottle
+}
+
+func newStatsMetricsBrokers(o *gen.GeneratedOptions, parentLabelNames []string) *statsMetricsBrokers {
+	m := &statsMetricsBrokers{}
+	m.labelNames = append(m.labelNames, parentLabelNames...)
+	m.lName = o.LabelName("brokers", "name")
+	m.labelNames = append(m.labelNames, m.lName)
+	m.lNodeid = o.LabelName("brokers", "nodeid")
+	m.labelNames = append(m.labelNames, m.lNodeid)
+	m.lNodename = o.LabelName("brokers", "nodename")
+	m.labelNames = append(m.labelNames, m.lNodename)
+	m.lSource = o.LabelName("brokers", "source")
+	m.labelNames = append(m.labelNames, m.lSource)
+	m.lState = o.LabelName("brokers", "state")
+	m.labelNames = append(m.labelNames, m.lState)
+	m.mStateage = o.NewGaugeVec("brokers", "Stateage", "Time since last broker state change (microseconds)", m.labelNames)
+	m.mOutbufCnt = o.NewGaugeVec("brokers", "OutbufCnt", "Number of requests awaiting transmission to broker", m.labelNames)
+	m.mOutbufMsgCnt = o.NewGaugeVec("brokers", "OutbufMsgCnt", "Number of messages awaiting transmission to broker", m.labelNames)
+	m.mWaitrespCnt = o.NewGaugeVec("brokers", "WaitrespCnt", "Number of requests in-flight to broker awaiting response", m.labelNames)
+	m.mWaitrespMsgCnt = o.NewGaugeVec("brokers", "WaitrespMsgCnt", "Number of messages in-flight to broker awaiting response", m.labelNames)
+	m.mTx = o.NewCounterVec("brokers", "Tx", "Total number of requests sent", m.labelNames)
+	m.mTxbytes = o.NewCounterVec("brokers", "Txbytes", "Total number of bytes sent", m.labelNames)
+	m.mTxerrs = o.NewCounterVec("brokers", "Txerrs", "Total number of transmission errors", m.labelNames)
+	m.mTxretries = o.NewCounterVec("brokers", "Txretries", "Total number of request retries", m.labelNames)
+	m.mTxidle = o.NewCounterVec("brokers", "Txidle", "Microseconds since last socket send (or -1 if no sends yet for current connection).", m.labelNames)
+	m.mReqTimeouts = o.NewCounterVec("brokers", "ReqTimeouts", "Total number of requests timed out", m.labelNames)
+	m.mRx = o.NewCounterVec("brokers", "Rx", "Total number of responses received", m.labelNames)
+	m.mRxbytes = o.NewCounterVec("brokers", "Rxbytes", "Total number of bytes received", m.labelNames)
+	m.mRxerrs = o.NewCounterVec("brokers", "Rxerrs", "Total number of receive errors", m.labelNames)
+	m.mRxcorriderrs = o.NewCounterVec("brokers", "Rxcorriderrs", "Total number of unmatched correlation ids in response (typically for timed out requests)", m.labelNames)
+	m.mRxpartial = o.NewCounterVec("brokers", "Rxpartial", "Total number of partial MessageSets received. The broker may return partial responses if the full MessageSet could not fit in the remaining Fetch response size.", m.labelNames)
+	m.mRxidle = o.NewCounterVec("brokers", "Rxidle", "Microseconds since last socket receive (or -1 if no receives yet for current connection).", m.labelNames)
+	m.mZbufGrow = o.NewCounterVec("brokers", "ZbufGrow", "Total number of decompression buffer size increases", m.labelNames)
+	m.mWakeups = o.NewCounterVec("brokers", "Wakeups", "Broker thread poll loop wakeups", m.labelNames)
+	m.mConnects = o.NewCounterVec("brokers", "Connects", "Number of connection attempts, including successful and failed, and name resolution failures.", m.labelNames)
+	m.mDisconnects = o.NewCounterVec("brokers", "Disconnects", "Number of disconnects (triggered by broker, network, load-balancer, etc.).", m.labelNames)
+	m.derived = o.NewDerived("brokers", "Stats.Brokers[]", reflect.TypeOf(typed.BrokerStats{}), m.labelNames)
+	if o.NestedInMapEntries() {
+		m.nIntLatency = newStatsMetricsBrokersIntLatency(o, m.labelNames)
+	}
+	if o.NestedInMapEntries() {
+		m.nOutbufLatency = newStatsMetricsBrokersOutbufLatency(o, m.labelNames)
+	}
+	if o.NestedInMapEntries() {
+		m.nRtt = newStatsMetricsBrokersRtt(o, m.labelNames)
+	}
+	if o.NestedInMapEntries() {
+		m.nThrottle = newStatsMetricsBrokersThrottle(o, m.labelNames)
+	}
+	return m
+}
+
+// share returns a copy of m computing filters and derived metrics with
+// the functions of o
+func (m *statsMetricsBrokers) share(o *gen.GeneratedOptions) *statsMetricsBrokers {
+	c := *m
+	c.derived = o.ShareDerived(m.derived, "Stats.Brokers[]")
+	if m.nIntLatency != nil {
+		c.nIntLatency = m.nIntLatency.share(o)
+	}
+	if m.nOutbufLatency != nil {
+		c.nOutbufLatency = m.nOutbufLatency.share(o)
+	}
+	if m.nRtt != nil {
+		c.nRtt = m.nRtt.share(o)
+	}
+	if m.nThrottle != nil {
+		c.nThrottle = m.nThrottle.share(o)
+	}
+	return &c
+}
+
+func (m *statsMetricsBrokers) describe(ch chan<- *prometheus.Desc) {
+	m.mStateage.Describe(ch)
+	m.mOutbufCnt.Describe(ch)
+	m.mOutbufMsgCnt.Describe(ch)
+	m.mWaitrespCnt.Describe(ch)
+	m.mWaitrespMsgCnt.Describe(ch)
+	m.mTx.Describe(ch)
+	m.mTxbytes.Describe(ch)
+	m.mTxerrs.Describe(ch)
+	m.mTxretries.Describe(ch)
+	m.mTxidle.Describe(ch)
+	m.mReqTimeouts.Describe(ch)
+	m.mRx.Describe(ch)
+	m.mRxbytes.Describe(ch)
+	m.mRxerrs.Describe(ch)
+	m.mRxcorriderrs.Describe(ch)
+	m.mRxpartial.Describe(ch)
+	m.mRxidle.Describe(ch)
+	m.mZbufGrow.Describe(ch)
+	m.mWakeups.Describe(ch)
+	m.mConnects.Describe(ch)
+	m.mDisconnects.Describe(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Describe(ch)
+	}
+	if m.nIntLatency != nil {
+		m.nIntLatency.describe(ch)
+	}
+	if m.nOutbufLatency != nil {
+		m.nOutbufLatency.describe(ch)
+	}
+	if m.nRtt != nil {
+		m.nRtt.describe(ch)
+	}
+	if m.nThrottle != nil {
+		m.nThrottle.describe(ch)
+	}
+}
+
+func (m *statsMetricsBrokers) collect(ch chan<- prometheus.Metric) {
+	m.mStateage.Collect(ch)
+	m.mOutbufCnt.Collect(ch)
+	m.mOutbufMsgCnt.Collect(ch)
+	m.mWaitrespCnt.Collect(ch)
+	m.mWaitrespMsgCnt.Collect(ch)
+	m.mTx.Collect(ch)
+	m.mTxbytes.Collect(ch)
+	m.mTxerrs.Collect(ch)
+	m.mTxretries.Collect(ch)
+	m.mTxidle.Collect(ch)
+	m.mReqTimeouts.Collect(ch)
+	m.mRx.Collect(ch)
+	m.mRxbytes.Collect(ch)
+	m.mRxerrs.Collect(ch)
+	m.mRxcorriderrs.Collect(ch)
+	m.mRxpartial.Collect(ch)
+	m.mRxidle.Collect(ch)
+	m.mZbufGrow.Collect(ch)
+	m.mWakeups.Collect(ch)
+	m.mConnects.Collect(ch)
+	m.mDisconnects.Collect(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Collect(ch)
+	}
+	if m.nIntLatency != nil {
+		m.nIntLatency.collect(ch)
+	}
+	if m.nOutbufLatency != nil {
+		m.nOutbufLatency.collect(ch)
+	}
+	if m.nRtt != nil {
+		m.nRtt.collect(ch)
+	}
+	if m.nThrottle != nil {
+		m.nThrottle.collect(ch)
+	}
+}
+
+// delete removes the series with labels ls of this prefix
+func (m *statsMetricsBrokers) delete(ls prometheus.Labels) {
+	m.mStateage.Delete(ls)
+	m.mOutbufCnt.Delete(ls)
+	m.mOutbufMsgCnt.Delete(ls)
+	m.mWaitrespCnt.Delete(ls)
+	m.mWaitrespMsgCnt.Delete(ls)
+	m.mTx.Delete(ls)
+	m.mTxbytes.Delete(ls)
+	m.mTxerrs.Delete(ls)
+	m.mTxretries.Delete(ls)
+	m.mTxidle.Delete(ls)
+	m.mReqTimeouts.Delete(ls)
+	m.mRx.Delete(ls)
+	m.mRxbytes.Delete(ls)
+	m.mRxerrs.Delete(ls)
+	m.mRxcorriderrs.Delete(ls)
+	m.mRxpartial.Delete(ls)
+	m.mRxidle.Delete(ls)
+	m.mZbufGrow.Delete(ls)
+	m.mWakeups.Delete(ls)
+	m.mConnects.Delete(ls)
+	m.mDisconnects.Delete(ls)
+	for i := range m.derived {
+		m.derived[i].Vec.Delete(ls)
+	}
+}
+
+type statsStateBrokers struct {
+	labels prometheus.Labels
+	epoch  uint64 // Of last streaming update
+	last   [16]int64
+	// Children of the metrics bound to labels
+	counters       [16]prometheus.Counter
+	gauges         [5]prometheus.Gauge
+	derived        []prometheus.Gauge
+	nIntLatency    statsStateBrokersIntLatency
+	nOutbufLatency statsStateBrokersOutbufLatency
+	nRtt           statsStateBrokersRtt
+	nThrottle      statsStateBrokersThrottle
+}
+
+// equalLabels reports whether the labels of v and parent did not change
+func (s *statsStateBrokers) equalLabels(m *statsMetricsBrokers, v *typed.BrokerStats, parent prometheus.Labels) bool {
+	if s.labels == nil || len(s.labels) != len(parent)+5 || !gen.ContainsLabels(s.labels, parent) {
+		return false
+	}
+	return true &&
+		s.labels[m.lName] == v.Name &&
+		gen.EqualIntLabel(s.labels[m.lNodeid], int64(v.Nodeid)) &&
+		s.labels[m.lNodename] == v.Nodename &&
+		s.labels[m.lSource] == v.Source &&
+		s.labels[m.lState] == v.State
+}
+
+// values updates the metrics of this prefix and returns the labels of v
+func (s *statsStateBrokers) values(m *statsMetricsBrokers, v *typed.BrokerStats, parent prometheus.Labels) prometheus.Labels {
+	if !s.equalLabels(m, v, parent) {
+		ls := make(prometheus.Labels, len(parent)+5)
+		for k, lv := range parent {
+			ls[k] = lv
+		}
+		ls[m.lName] = v.Name
+		ls[m.lNodeid] = strconv.FormatInt(int64(v.Nodeid), 10)
+		ls[m.lNodename] = v.Nodename
+		ls[m.lSource] = v.Source
+		ls[m.lState] = v.State
+		// Series of outdated labels are dropped
+		if s.labels != nil {
+			m.delete(s.labels)
+		}
+		s.labels = gen.CloneLabels(ls)
+		s.last = [16]int64{}
+		s.gauges[0] = m.mStateage.With(s.labels)
+		s.gauges[1] = m.mOutbufCnt.With(s.labels)
+		s.gauges[2] = m.mOutbufMsgCnt.With(s.labels)
+		s.gauges[3] = m.mWaitrespCnt.With(s.labels)
+		s.gauges[4] = m.mWaitrespMsgCnt.With(s.labels)
+		s.counters[0] = m.mTx.With(s.labels)
+		s.counters[1] = m.mTxbytes.With(s.labels)
+		s.counters[2] = m.mTxerrs.With(s.labels)
+		s.counters[3] = m.mTxretries.With(s.labels)
+		s.counters[4] = m.mTxidle.With(s.labels)
+		s.counters[5] = m.mReqTimeouts.With(s.labels)
+		s.counters[6] = m.mRx.With(s.labels)
+		s.counters[7] = m.mRxbytes.With(s.labels)
+		s.counters[8] = m.mRxerrs.With(s.labels)
+		s.counters[9] = m.mRxcorriderrs.With(s.labels)
+		s.counters[10] = m.mRxpartial.With(s.labels)
+		s.counters[11] = m.mRxidle.With(s.labels)
+		s.counters[12] = m.mZbufGrow.With(s.labels)
+		s.counters[13] = m.mWakeups.With(s.labels)
+		s.counters[14] = m.mConnects.With(s.labels)
+		s.counters[15] = m.mDisconnects.With(s.labels)
+		s.derived = nil
+	}
+	s.gauges[0].Set(float64(v.Stateage))
+	s.gauges[1].Set(float64(v.OutbufCnt))
+	s.gauges[2].Set(float64(v.OutbufMsgCnt))
+	s.gauges[3].Set(float64(v.WaitrespCnt))
+	s.gauges[4].Set(float64(v.WaitrespMsgCnt))
+	s.last[0] = gen.AddToCounter(s.counters[0], s.last[0], int64(v.Tx))
+	s.last[1] = gen.AddToCounter(s.counters[1], s.last[1], int64(v.Txbytes))
+	s.last[2] = gen.AddToCounter(s.counters[2], s.last[2], int64(v.Txerrs))
+	s.last[3] = gen.AddToCounter(s.counters[3], s.last[3], int64(v.Txretries))
+	s.last[4] = gen.AddToCounter(s.counters[4], s.last[4], int64(v.Txidle))
+	s.last[5] = gen.AddToCounter(s.counters[5], s.last[5], int64(v.ReqTimeouts))
+	s.last[6] = gen.AddToCounter(s.counters[6], s.last[6], int64(v.Rx))
+	s.last[7] = gen.AddToCounter(s.counters[7], s.last[7], int64(v.Rxbytes))
+	s.last[8] = gen.AddToCounter(s.counters[8], s.last[8], int64(v.Rxerrs))
+	s.last[9] = gen.AddToCounter(s.counters[9], s.last[9], int64(v.Rxcorriderrs))
+	s.last[10] = gen.AddToCounter(s.counters[10], s.last[10], int64(v.Rxpartial))
+	s.last[11] = gen.AddToCounter(s.counters[11], s.last[11], int64(v.Rxidle))
+	s.last[12] = gen.AddToCounter(s.counters[12], s.last[12], int64(v.ZbufGrow))
+	s.last[13] = gen.AddToCounter(s.counters[13], s.last[13], int64(v.Wakeups))
+	s.last[14] = gen.AddToCounter(s.counters[14], s.last[14], int64(v.Connects))
+	s.last[15] = gen.AddToCounter(s.counters[15], s.last[15], int64(v.Disconnects))
+	return s.labels
+}
+
+// derive updates the derived metrics with the complete value v. Their
+// series are created on the first call after a change of labels.
+func (s *statsStateBrokers) derive(m *statsMetricsBrokers, v *typed.BrokerStats) {
+	if s.derived == nil && len(m.derived) != 0 {
+		s.derived = make([]prometheus.Gauge, len(m.derived))
+		for i := range m.derived {
+			s.derived[i] = m.derived[i].Vec.With(s.labels)
+		}
+	}
+	for i, d := range s.derived {
+		d.Set(m.derived[i].Fun(v))
+	}
+}
+
+func (s *statsStateBrokers) update(m *statsMetricsBrokers, v *typed.BrokerStats, parent prometheus.Labels) {
+	ls := s.values(m, v, parent)
+	s.derive(m, v)
+	if m.nIntLatency != nil {
+		s.nIntLatency.update(m.nIntLatency, &v.IntLatency, ls)
+	}
+	if m.nOutbufLatency != nil {
+		s.nOutbufLatency.update(m.nOutbufLatency, &v.OutbufLatency, ls)
+	}
+	if m.nRtt != nil {
+		s.nRtt.update(m.nRtt, &v.Rtt, ls)
+	}
+	if m.nThrottle != nil {
+		s.nThrottle.update(m.nThrottle, &v.Throttle, ls)
+	}
+}
+
+// statsRawBrokers holds the decoded fields of a JSON value. Maps and nested
+// structs are held by the raw values they contain.
+type statsRawBrokers struct {
+	v              typed.BrokerStats
+	nIntLatency    statsRawBrokersIntLatency
+	nOutbufLatency statsRawBrokersOutbufLatency
+	nRtt           statsRawBrokersRtt
+	nThrottle      statsRawBrokersThrottle
+}
+
+func (r *statsRawBrokers) decode(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		var err error
+		switch o.Key() {
+		case "name":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.Name = string(x)
+		case "nodeid":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Nodeid = int(x)
+		case "nodename":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.Nodename = string(x)
+		case "source":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.Source = string(x)
+		case "state":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.State = string(x)
+		case "stateage":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Stateage = int(x)
+		case "outbuf_cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.OutbufCnt = int(x)
+		case "outbuf_msg_cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.OutbufMsgCnt = int(x)
+		case "waitresp_cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.WaitrespCnt = int(x)
+		case "waitresp_msg_cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.WaitrespMsgCnt = int(x)
+		case "tx":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Tx = int(x)
+		case "txbytes":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Txbytes = int(x)
+		case "txerrs":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Txerrs = int(x)
+		case "txretries":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Txretries = int(x)
+		case "txidle":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Txidle = int(x)
+		case "req_timeouts":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.ReqTimeouts = int(x)
+		case "rx":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Rx = int(x)
+		case "rxbytes":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Rxbytes = int(x)
+		case "rxerrs":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Rxerrs = int(x)
+		case "rxcorriderrs":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Rxcorriderrs = int(x)
+		case "rxpartial":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Rxpartial = int(x)
+		case "rxidle":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Rxidle = int(x)
+		case "zbuf_grow":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.ZbufGrow = int(x)
+		case "wakeups":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Wakeups = int(x)
+		case "connects":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Connects = int(x)
+		case "disconnects":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Disconnects = int(x)
+		case "int_latency":
+			err = r.nIntLatency.decode(o.Value())
+		case "outbuf_latency":
+			err = r.nOutbufLatency.decode(o.Value())
+		case "rtt":
+			err = r.nRtt.decode(o.Value())
+		case "throttle":
+			err = r.nThrottle.decode(o.Value())
+		}
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+// filter marks the entries of maps skipped by the filters of m. Fails
+// if an entry to filter cannot be decoded completely.
+func (r *statsRawBrokers) filter(m *statsMetricsBrokers) error {
+	return nil
+}
+
+func (s *statsStateBrokers) stream(m *statsMetricsBrokers, r *statsRawBrokers, parent prometheus.Labels) {
+	ls := s.values(m, &r.v, parent)
+	if m.nIntLatency != nil {
+		s.nIntLatency.stream(m.nIntLatency, &r.nIntLatency, ls)
+	}
+	if m.nOutbufLatency != nil {
+		s.nOutbufLatency.stream(m.nOutbufLatency, &r.nOutbufLatency, ls)
+	}
+	if m.nRtt != nil {
+		s.nRtt.stream(m.nRtt, &r.nRtt, ls)
+	}
+	if m.nThrottle != nil {
+		s.nThrottle.stream(m.nThrottle, &r.nThrottle, ls)
+	}
+}
+
+func (s *statsStateBrokers) count(m *statsMetricsBrokers, entries map[string]int) int {
+	if s.labels == nil {
+		return 0
+	}
+	series := 21 + len(s.derived)
+	if m.nIntLatency != nil {
+		series += s.nIntLatency.count(m.nIntLatency, entries)
+	}
+	if m.nOutbufLatency != nil {
+		series += s.nOutbufLatency.count(m.nOutbufLatency, entries)
+	}
+	if m.nRtt != nil {
+		series += s.nRtt.count(m.nRtt, entries)
+	}
+	if m.nThrottle != nil {
+		series += s.nThrottle.count(m.nThrottle, entries)
+	}
+	return series
+}
+
+// delete removes all series of this value and the values it contains
+func (s *statsStateBrokers) delete(m *statsMetricsBrokers) {
+	if s.labels != nil {
+		m.delete(s.labels)
+	}
+	if m.nIntLatency != nil {
+		s.nIntLatency.delete(m.nIntLatency)
+	}
+	if m.nOutbufLatency != nil {
+		s.nOutbufLatency.delete(m.nOutbufLatency)
+	}
+	if m.nRtt != nil {
+		s.nRtt.delete(m.nRtt)
+	}
+	if m.nThrottle != nil {
+		s.nThrottle.delete(m.nThrottle)
+	}
+}
+
+type statsMetricsBrokersIntLatency struct {
+	labelNames  []string
+	mMin        *prometheus.GaugeVec
+	mMax        *prometheus.GaugeVec
+	mAvg        *prometheus.GaugeVec
+	mSum        *prometheus.GaugeVec
+	mCnt        *prometheus.GaugeVec
+	mStddev     *prometheus.GaugeVec
+	mHdrsize    *prometheus.GaugeVec
+	mP50        *prometheus.GaugeVec
+	mP75        *prometheus.GaugeVec
+	mP90        *prometheus.GaugeVec
+	mP95        *prometheus.GaugeVec
+	mP99        *prometheus.GaugeVec
+	mP99_99     *prometheus.GaugeVec
+	mOutofrange *prometheus.GaugeVec
+	derived     []gen.GeneratedDerived
+}
+
+func newStatsMetricsBrokersIntLatency(o *gen.GeneratedOptions, parentLabelNames []string) *statsMetricsBrokersIntLatency {
+	m := &statsMetricsBrokersIntLatency{}
+	m.labelNames = append(m.labelNames, parentLabelNames...)
+	m.mMin = o.NewGaugeVec("brokers_int_latency", "Min", "Smallest value", m.labelNames)
+	m.mMax = o.NewGaugeVec("brokers_int_latency", "Max", "Largest value", m.labelNames)
+	m.mAvg = o.NewGaugeVec("brokers_int_latency", "Avg", "Average value", m.labelNames)
+	m.mSum = o.NewGaugeVec("brokers_int_latency", "Sum", "Sum of values", m.labelNames)
+	m.mCnt = o.NewGaugeVec("brokers_int_latency", "Cnt", "Number of values sampled", m.labelNames)
+	m.mStddev = o.NewGaugeVec("brokers_int_latency", "Stddev", "Standard deviation (based on histogram)", m.labelNames)
+	m.mHdrsize = o.NewGaugeVec("brokers_int_latency", "Hdrsize", "Memory size of Hdr Histogram", m.labelNames)
+	m.mP50 = o.NewGaugeVec("brokers_int_latency", "P50", "50th percentile", m.labelNames)
+	m.mP75 = o.NewGaugeVec("brokers_int_latency", "P75", "75th percentile", m.labelNames)
+	m.mP90 = o.NewGaugeVec("brokers_int_latency", "P90", "90th percentile", m.labelNames)
+	m.mP95 = o.NewGaugeVec("brokers_int_latency", "P95", "95th percentile", m.labelNames)
+	m.mP99 = o.NewGaugeVec("brokers_int_latency", "P99", "99th percentile", m.labelNames)
+	m.mP99_99 = o.NewGaugeVec("brokers_int_latency", "P99_99", "99.99th percentile", m.labelNames)
+	m.mOutofrange = o.NewGaugeVec("brokers_int_latency", "Outofrange", "Values skipped due to out of histogram range", m.labelNames)
+	m.derived = o.NewDerived("brokers_int_latency", "Stats.Brokers[].IntLatency", reflect.TypeOf(typed.WindowStats{}), m.labelNames)
+	return m
+}
+
+// share returns a copy of m computing filters and derived metrics with
+// the functions of o
+func (m *statsMetricsBrokersIntLatency) share(o *gen.GeneratedOptions) *statsMetricsBrokersIntLatency {
+	c := *m
+	c.derived = o.ShareDerived(m.derived, "Stats.Brokers[].IntLatency")
+	return &c
+}
+
+func (m *statsMetricsBrokersIntLatency) describe(ch chan<- *prometheus.Desc) {
+	m.mMin.Describe(ch)
+	m.mMax.Describe(ch)
+	m.mAvg.Describe(ch)
+	m.mSum.Describe(ch)
+	m.mCnt.Describe(ch)
+	m.mStddev.Describe(ch)
+	m.mHdrsize.Describe(ch)
+	m.mP50.Describe(ch)
+	m.mP75.Describe(ch)
+	m.mP90.Describe(ch)
+	m.mP95.Describe(ch)
+	m.mP99.Describe(ch)
+	m.mP99_99.Describe(ch)
+	m.mOutofrange.Describe(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Describe(ch)
+	}
+}
+
+func (m *statsMetricsBrokersIntLatency) collect(ch chan<- prometheus.Metric) {
+	m.mMin.Collect(ch)
+	m.mMax.Collect(ch)
+	m.mAvg.Collect(ch)
+	m.mSum.Collect(ch)
+	m.mCnt.Collect(ch)
+	m.mStddev.Collect(ch)
+	m.mHdrsize.Collect(ch)
+	m.mP50.Collect(ch)
+	m.mP75.Collect(ch)
+	m.mP90.Collect(ch)
+	m.mP95.Collect(ch)
+	m.mP99.Collect(ch)
+	m.mP99_99.Collect(ch)
+	m.mOutofrange.Collect(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Collect(ch)
+	}
+}
+
+// delete removes the series with labels ls of this prefix
+func (m *statsMetricsBrokersIntLatency) delete(ls prometheus.Labels) {
+	m.mMin.Delete(ls)
+	m.mMax.Delete(ls)
+	m.mAvg.Delete(ls)
+	m.mSum.Delete(ls)
+	m.mCnt.Delete(ls)
+	m.mStddev.Delete(ls)
+	m.mHdrsize.Delete(ls)
+	m.mP50.Delete(ls)
+	m.mP75.Delete(ls)
+	m.mP90.Delete(ls)
+	m.mP95.Delete(ls)
+	m.mP99.Delete(ls)
+	m.mP99_99.Delete(ls)
+	m.mOutofrange.Delete(ls)
+	for i := range m.derived {
+		m.derived[i].Vec.Delete(ls)
+	}
+}
+
+type statsStateBrokersIntLatency struct {
+	labels prometheus.Labels
+	// Children of the metrics bound to labels
+	gauges  [14]prometheus.Gauge
+	derived []prometheus.Gauge
+}
+
+// equalLabels reports whether the labels of v and parent did not change
+func (s *statsStateBrokersIntLatency) equalLabels(m *statsMetricsBrokersIntLatency, v *typed.WindowStats, parent prometheus.Labels) bool {
+	if s.labels == nil || len(s.labels) != len(parent)+0 || !gen.ContainsLabels(s.labels, parent) {
+		return false
+	}
+	return true
+}
+
+// values updates the metrics of this prefix and returns the labels of v
+func (s *statsStateBrokersIntLatency) values(m *statsMetricsBrokersIntLatency, v *typed.WindowStats, parent prometheus.Labels) prometheus.Labels {
+	if !s.equalLabels(m, v, parent) {
+		ls := make(prometheus.Labels, len(parent)+0)
+		for k, lv := range parent {
+			ls[k] = lv
+		}
+		// Series of outdated labels are dropped
+		if s.labels != nil {
+			m.delete(s.labels)
+		}
+		s.labels = gen.CloneLabels(ls)
+		s.gauges[0] = m.mMin.With(s.labels)
+		s.gauges[1] = m.mMax.With(s.labels)
+		s.gauges[2] = m.mAvg.With(s.labels)
+		s.gauges[3] = m.mSum.With(s.labels)
+		s.gauges[4] = m.mCnt.With(s.labels)
+		s.gauges[5] = m.mStddev.With(s.labels)
+		s.gauges[6] = m.mHdrsize.With(s.labels)
+		s.gauges[7] = m.mP50.With(s.labels)
+		s.gauges[8] = m.mP75.With(s.labels)
+		s.gauges[9] = m.mP90.With(s.labels)
+		s.gauges[10] = m.mP95.With(s.labels)
+		s.gauges[11] = m.mP99.With(s.labels)
+		s.gauges[12] = m.mP99_99.With(s.labels)
+		s.gauges[13] = m.mOutofrange.With(s.labels)
+		s.derived = nil
+	}
+	s.gauges[0].Set(float64(v.Min))
+	s.gauges[1].Set(float64(v.Max))
+	s.gauges[2].Set(float64(v.Avg))
+	s.gauges[3].Set(float64(v.Sum))
+	s.gauges[4].Set(float64(v.Cnt))
+	s.gauges[5].Set(float64(v.Stddev))
+	s.gauges[6].Set(float64(v.Hdrsize))
+	s.gauges[7].Set(float64(v.P50))
+	s.gauges[8].Set(float64(v.P75))
+	s.gauges[9].Set(float64(v.P90))
+	s.gauges[10].Set(float64(v.P95))
+	s.gauges[11].Set(float64(v.P99))
+	s.gauges[12].Set(float64(v.P99_99))
+	s.gauges[13].Set(float64(v.Outofrange))
+	return s.labels
+}
+
+// derive updates the derived metrics with the complete value v. Their
+// series are created on the first call after a change of labels.
+func (s *statsStateBrokersIntLatency) derive(m *statsMetricsBrokersIntLatency, v *typed.WindowStats) {
+	if s.derived == nil && len(m.derived) != 0 {
+		s.derived = make([]prometheus.Gauge, len(m.derived))
+		for i := range m.derived {
+			s.derived[i] = m.derived[i].Vec.With(s.labels)
+		}
+	}
+	for i, d := range s.derived {
+		d.Set(m.derived[i].Fun(v))
+	}
+}
+
+func (s *statsStateBrokersIntLatency) update(m *statsMetricsBrokersIntLatency, v *typed.WindowStats, parent prometheus.Labels) {
+	s.values(m, v, parent)
+	s.derive(m, v)
+}
+
+// statsRawBrokersIntLatency holds the decoded fields of a JSON value. Maps and nested
+// structs are held by the raw values they contain.
+type statsRawBrokersIntLatency struct {
+	v typed.WindowStats
+}
+
+func (r *statsRawBrokersIntLatency) decode(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		var err error
+		switch o.Key() {
+		case "min":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Min = int(x)
+		case "max":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Max = int(x)
+		case "avg":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Avg = int(x)
+		case "sum":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Sum = int(x)
+		case "cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Cnt = int(x)
+		case "stddev":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Stddev = int(x)
+		case "hdrsize":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Hdrsize = int(x)
+		case "p50":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P50 = int(x)
+		case "p75":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P75 = int(x)
+		case "p90":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P90 = int(x)
+		case "p95":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P95 = int(x)
+		case "p99":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P99 = int(x)
+		case "p99_99":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P99_99 = int(x)
+		case "outofrange":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Outofrange = int(x)
+		}
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+// filter marks the entries of maps skipped by the filters of m. Fails
+// if an entry to filter cannot be decoded completely.
+func (r *statsRawBrokersIntLatency) filter(m *statsMetricsBrokersIntLatency) error {
+	return nil
+}
+
+func (s *statsStateBrokersIntLatency) stream(m *statsMetricsBrokersIntLatency, r *statsRawBrokersIntLatency, parent prometheus.Labels) {
+	s.values(m, &r.v, parent)
+}
+
+func (s *statsStateBrokersIntLatency) count(m *statsMetricsBrokersIntLatency, entries map[string]int) int {
+	if s.labels == nil {
+		return 0
+	}
+	series := 14 + len(s.derived)
+	return series
+}
+
+// delete removes all series of this value and the values it contains
+func (s *statsStateBrokersIntLatency) delete(m *statsMetricsBrokersIntLatency) {
+	if s.labels != nil {
+		m.delete(s.labels)
+	}
+}
+
+type statsMetricsBrokersOutbufLatency struct {
+	labelNames  []string
+	mMin        *prometheus.GaugeVec
+	mMax        *prometheus.GaugeVec
+	mAvg        *prometheus.GaugeVec
+	mSum        *prometheus.GaugeVec
+	mCnt        *prometheus.GaugeVec
+	mStddev     *prometheus.GaugeVec
+	mHdrsize    *prometheus.GaugeVec
+	mP50        *prometheus.GaugeVec
+	mP75        *prometheus.GaugeVec
+	mP90        *prometheus.GaugeVec
+	mP95        *prometheus.GaugeVec
+	mP99        *prometheus.GaugeVec
+	mP99_99     *prometheus.GaugeVec
+	mOutofrange *prometheus.GaugeVec
+	derived     []gen.GeneratedDerived
+}
+
+func newStatsMetricsBrokersOutbufLatency(o *gen.GeneratedOptions, parentLabelNames []string) *statsMetricsBrokersOutbufLatency {
+	m := &statsMetricsBrokersOutbufLatency{}
+	m.labelNames = append(m.labelNames, parentLabelNames...)
+	m.mMin = o.NewGaugeVec("brokers_outbuf_latency", "Min", "Smallest value", m.labelNames)
+	m.mMax = o.NewGaugeVec("brokers_outbuf_latency", "Max", "Largest value", m.labelNames)
+	m.mAvg = o.NewGaugeVec("brokers_outbuf_latency", "Avg", "Average value", m.labelNames)
+	m.mSum = o.NewGaugeVec("brokers_outbuf_latency", "Sum", "Sum of values", m.labelNames)
+	m.mCnt = o.NewGaugeVec("brokers_outbuf_latency", "Cnt", "Number of values sampled", m.labelNames)
+	m.mStddev = o.NewGaugeVec("brokers_outbuf_latency", "Stddev", "Standard deviation (based on histogram)", m.labelNames)
+	m.mHdrsize = o.NewGaugeVec("brokers_outbuf_latency", "Hdrsize", "Memory size of Hdr Histogram", m.labelNames)
+	m.mP50 = o.NewGaugeVec("brokers_outbuf_latency", "P50", "50th percentile", m.labelNames)
+	m.mP75 = o.NewGaugeVec("brokers_outbuf_latency", "P75", "75th percentile", m.labelNames)
+	m.mP90 = o.NewGaugeVec("brokers_outbuf_latency", "P90", "90th percentile", m.labelNames)
+	m.mP95 = o.NewGaugeVec("brokers_outbuf_latency", "P95", "95th percentile", m.labelNames)
+	m.mP99 = o.NewGaugeVec("brokers_outbuf_latency", "P99", "99th percentile", m.labelNames)
+	m.mP99_99 = o.NewGaugeVec("brokers_outbuf_latency", "P99_99", "99.99th percentile", m.labelNames)
+	m.mOutofrange = o.NewGaugeVec("brokers_outbuf_latency", "Outofrange", "Values skipped due to out of histogram range", m.labelNames)
+	m.derived = o.NewDerived("brokers_outbuf_latency", "Stats.Brokers[].OutbufLatency", reflect.TypeOf(typed.WindowStats{}), m.labelNames)
+	return m
+}
+
+// share returns a copy of m computing filters and derived metrics with
+// the functions of o
+func (m *statsMetricsBrokersOutbufLatency) share(o *gen.GeneratedOptions) *statsMetricsBrokersOutbufLatency {
+	c := *m
+	c.derived = o.ShareDerived(m.derived, "Stats.Brokers[].OutbufLatency")
+	return &c
+}
+
+func (m *statsMetricsBrokersOutbufLatency) describe(ch chan<- *prometheus.Desc) {
+	m.mMin.Describe(ch)
+	m.mMax.Describe(ch)
+	m.mAvg.Describe(ch)
+	m.mSum.Describe(ch)
+	m.mCnt.Describe(ch)
+	m.mStddev.Describe(ch)
+	m.mHdrsize.Describe(ch)
+	m.mP50.Describe(ch)
+	m.mP75.Describe(ch)
+	m.mP90.Describe(ch)
+	m.mP95.Describe(ch)
+	m.mP99.Describe(ch)
+	m.mP99_99.Describe(ch)
+	m.mOutofrange.Describe(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Describe(ch)
+	}
+}
+
+func (m *statsMetricsBrokersOutbufLatency) collect(ch chan<- prometheus.Metric) {
+	m.mMin.Collect(ch)
+	m.mMax.Collect(ch)
+	m.mAvg.Collect(ch)
+	m.mSum.Collect(ch)
+	m.mCnt.Collect(ch)
+	m.mStddev.Collect(ch)
+	m.mHdrsize.Collect(ch)
+	m.mP50.Collect(ch)
+	m.mP75.Collect(ch)
+	m.mP90.Collect(ch)
+	m.mP95.Collect(ch)
+	m.mP99.Collect(ch)
+	m.mP99_99.Collect(ch)
+	m.mOutofrange.Collect(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Collect(ch)
+	}
+}
+
+// delete removes the series with labels ls of this prefix
+func (m *statsMetricsBrokersOutbufLatency) delete(ls prometheus.Labels) {
+	m.mMin.Delete(ls)
+	m.mMax.Delete(ls)
+	m.mAvg.Delete(ls)
+	m.mSum.Delete(ls)
+	m.mCnt.Delete(ls)
+	m.mStddev.Delete(ls)
+	m.mHdrsize.Delete(ls)
+	m.mP50.Delete(ls)
+	m.mP75.Delete(ls)
+	m.mP90.Delete(ls)
+	m.mP95.Delete(ls)
+	m.mP99.Delete(ls)
+	m.mP99_99.Delete(ls)
+	m.mOutofrange.Delete(ls)
+	for i := range m.derived {
+		m.derived[i].Vec.Delete(ls)
+	}
+}
+
+type statsStateBrokersOutbufLatency struct {
+	labels prometheus.Labels
+	// Children of the metrics bound to labels
+	gauges  [14]prometheus.Gauge
+	derived []prometheus.Gauge
+}
+
+// equalLabels reports whether the labels of v and parent did not change
+func (s *statsStateBrokersOutbufLatency) equalLabels(m *statsMetricsBrokersOutbufLatency, v *typed.WindowStats, parent prometheus.Labels) bool {
+	if s.labels == nil || len(s.labels) != len(parent)+0 || !gen.ContainsLabels(s.labels, parent) {
+		return false
+	}
+	return true
+}
+
+// values updates the metrics of this prefix and returns the labels of v
+func (s *statsStateBrokersOutbufLatency) values(m *statsMetricsBrokersOutbufLatency, v *typed.WindowStats, parent prometheus.Labels) prometheus.Labels {
+	if !s.equalLabels(m, v, parent) {
+		ls := make(prometheus.Labels, len(parent)+0)
+		for k, lv := range parent {
+			ls[k] = lv
+		}
+		// Series of outdated labels are dropped
+		if s.labels != nil {
+			m.delete(s.labels)
+		}
+		s.labels = gen.CloneLabels(ls)
+		s.gauges[0] = m.mMin.With(s.labels)
+		s.gauges[1] = m.mMax.With(s.labels)
+		s.gauges[2] = m.mAvg.With(s.labels)
+		s.gauges[3] = m.mSum.With(s.labels)
+		s.gauges[4] = m.mCnt.With(s.labels)
+		s.gauges[5] = m.mStddev.With(s.labels)
+		s.gauges[6] = m.mHdrsize.With(s.labels)
+		s.gauges[7] = m.mP50.With(s.labels)
+		s.gauges[8] = m.mP75.With(s.labels)
+		s.gauges[9] = m.mP90.With(s.labels)
+		s.gauges[10] = m.mP95.With(s.labels)
+		s.gauges[11] = m.mP99.With(s.labels)
+		s.gauges[12] = m.mP99_99.With(s.labels)
+		s.gauges[13] = m.mOutofrange.With(s.labels)
+		s.derived = nil
+	}
+	s.gauges[0].Set(float64(v.Min))
+	s.gauges[1].Set(float64(v.Max))
+	s.gauges[2].Set(float64(v.Avg))
+	s.gauges[3].Set(float64(v.Sum))
+	s.gauges[4].Set(float64(v.Cnt))
+	s.gauges[5].Set(float64(v.Stddev))
+	s.gauges[6].Set(float64(v.Hdrsize))
+	s.gauges[7].Set(float64(v.P50))
+	s.gauges[8].Set(float64(v.P75))
+	s.gauges[9].Set(float64(v.P90))
+	s.gauges[10].Set(float64(v.P95))
+	s.gauges[11].Set(float64(v.P99))
+	s.gauges[12].Set(float64(v.P99_99))
+	s.gauges[13].Set(float64(v.Outofrange))
+	return s.labels
+}
+
+// derive updates the derived metrics with the complete value v. Their
+// series are created on the first call after a change of labels.
+func (s *statsStateBrokersOutbufLatency) derive(m *statsMetricsBrokersOutbufLatency, v *typed.WindowStats) {
+	if s.derived == nil && len(m.derived) != 0 {
+		s.derived = make([]prometheus.Gauge, len(m.derived))
+		for i := range m.derived {
+			s.derived[i] = m.derived[i].Vec.With(s.labels)
+		}
+	}
+	for i, d := range s.derived {
+		d.Set(m.derived[i].Fun(v))
+	}
+}
+
+func (s *statsStateBrokersOutbufLatency) update(m *statsMetricsBrokersOutbufLatency, v *typed.WindowStats, parent prometheus.Labels) {
+	s.values(m, v, parent)
+	s.derive(m, v)
+}
+
+// statsRawBrokersOutbufLatency holds the decoded fields of a JSON value. Maps and nested
+// structs are held by the raw values they contain.
+type statsRawBrokersOutbufLatency struct {
+	v typed.WindowStats
+}
+
+func (r *statsRawBrokersOutbufLatency) decode(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		var err error
+		switch o.Key() {
+		case "min":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Min = int(x)
+		case "max":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Max = int(x)
+		case "avg":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Avg = int(x)
+		case "sum":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Sum = int(x)
+		case "cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Cnt = int(x)
+		case "stddev":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Stddev = int(x)
+		case "hdrsize":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Hdrsize = int(x)
+		case "p50":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P50 = int(x)
+		case "p75":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P75 = int(x)
+		case "p90":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P90 = int(x)
+		case "p95":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P95 = int(x)
+		case "p99":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P99 = int(x)
+		case "p99_99":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P99_99 = int(x)
+		case "outofrange":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Outofrange = int(x)
+		}
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+// filter marks the entries of maps skipped by the filters of m. Fails
+// if an entry to filter cannot be decoded completely.
+func (r *statsRawBrokersOutbufLatency) filter(m *statsMetricsBrokersOutbufLatency) error {
+	return nil
+}
+
+func (s *statsStateBrokersOutbufLatency) stream(m *statsMetricsBrokersOutbufLatency, r *statsRawBrokersOutbufLatency, parent prometheus.Labels) {
+	s.values(m, &r.v, parent)
+}
+
+func (s *statsStateBrokersOutbufLatency) count(m *statsMetricsBrokersOutbufLatency, entries map[string]int) int {
+	if s.labels == nil {
+		return 0
+	}
+	series := 14 + len(s.derived)
+	return series
+}
+
+// delete removes all series of this value and the values it contains
+func (s *statsStateBrokersOutbufLatency) delete(m *statsMetricsBrokersOutbufLatency) {
+	if s.labels != nil {
+		m.delete(s.labels)
+	}
+}
+
+type statsMetricsBrokersRtt struct {
+	labelNames  []string
+	mMin        *prometheus.GaugeVec
+	mMax        *prometheus.GaugeVec
+	mAvg        *prometheus.GaugeVec
+	mSum        *prometheus.GaugeVec
+	mCnt        *prometheus.GaugeVec
+	mStddev     *prometheus.GaugeVec
+	mHdrsize    *prometheus.GaugeVec
+	mP50        *prometheus.GaugeVec
+	mP75        *prometheus.GaugeVec
+	mP90        *prometheus.GaugeVec
+	mP95        *prometheus.GaugeVec
+	mP99        *prometheus.GaugeVec
+	mP99_99     *prometheus.GaugeVec
+	mOutofrange *prometheus.GaugeVec
+	derived     []gen.GeneratedDerived
+}
+
+func newStatsMetricsBrokersRtt(o *gen.GeneratedOptions, parentLabelNames []string) *statsMetricsBrokersRtt {
+	m := &statsMetricsBrokersRtt{}
+	m.labelNames = append(m.labelNames, parentLabelNames...)
+	m.mMin = o.NewGaugeVec("brokers_rtt", "Min", "Smallest value", m.labelNames)
+	m.mMax = o.NewGaugeVec("brokers_rtt", "Max", "Largest value", m.labelNames)
+	m.mAvg = o.NewGaugeVec("brokers_rtt", "Avg", "Average value", m.labelNames)
+	m.mSum = o.NewGaugeVec("brokers_rtt", "Sum", "Sum of values", m.labelNames)
+	m.mCnt = o.NewGaugeVec("brokers_rtt", "Cnt", "Number of values sampled", m.labelNames)
+	m.mStddev = o.NewGaugeVec("brokers_rtt", "Stddev", "Standard deviation (based on histogram)", m.labelNames)
+	m.mHdrsize = o.NewGaugeVec("brokers_rtt", "Hdrsize", "Memory size of Hdr Histogram", m.labelNames)
+	m.mP50 = o.NewGaugeVec("brokers_rtt", "P50", "50th percentile", m.labelNames)
+	m.mP75 = o.NewGaugeVec("brokers_rtt", "P75", "75th percentile", m.labelNames)
+	m.mP90 = o.NewGaugeVec("brokers_rtt", "P90", "90th percentile", m.labelNames)
+	m.mP95 = o.NewGaugeVec("brokers_rtt", "P95", "95th percentile", m.labelNames)
+	m.mP99 = o.NewGaugeVec("brokers_rtt", "P99", "99th percentile", m.labelNames)
+	m.mP99_99 = o.NewGaugeVec("brokers_rtt", "P99_99", "99.99th percentile", m.labelNames)
+	m.mOutofrange = o.NewGaugeVec("brokers_rtt", "Outofrange", "Values skipped due to out of histogram range", m.labelNames)
+	m.derived = o.NewDerived("brokers_rtt", "Stats.Brokers[].Rtt", reflect.TypeOf(typed.WindowStats{}), m.labelNames)
+	return m
+}
+
+// share returns a copy of m computing filters and derived metrics with
+// the functions of o
+func (m *statsMetricsBrokersRtt) share(o *gen.GeneratedOptions) *statsMetricsBrokersRtt {
+	c := *m
+	c.derived = o.ShareDerived(m.derived, "Stats.Brokers[].Rtt")
+	return &c
+}
+
+func (m *statsMetricsBrokersRtt) describe(ch chan<- *prometheus.Desc) {
+	m.mMin.Describe(ch)
+	m.mMax.Describe(ch)
+	m.mAvg.Describe(ch)
+	m.mSum.Describe(ch)
+	m.mCnt.Describe(ch)
+	m.mStddev.Describe(ch)
+	m.mHdrsize.Describe(ch)
+	m.mP50.Describe(ch)
+	m.mP75.Describe(ch)
+	m.mP90.Describe(ch)
+	m.mP95.Describe(ch)
+	m.mP99.Describe(ch)
+	m.mP99_99.Describe(ch)
+	m.mOutofrange.Describe(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Describe(ch)
+	}
+}
+
+func (m *statsMetricsBrokersRtt) collect(ch chan<- prometheus.Metric) {
+	m.mMin.Collect(ch)
+	m.mMax.Collect(ch)
+	m.mAvg.Collect(ch)
+	m.mSum.Collect(ch)
+	m.mCnt.Collect(ch)
+	m.mStddev.Collect(ch)
+	m.mHdrsize.Collect(ch)
+	m.mP50.Collect(ch)
+	m.mP75.Collect(ch)
+	m.mP90.Collect(ch)
+	m.mP95.Collect(ch)
+	m.mP99.Collect(ch)
+	m.mP99_99.Collect(ch)
+	m.mOutofrange.Collect(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Collect(ch)
+	}
+}
+
+// delete removes the series with labels ls of this prefix
+func (m *statsMetricsBrokersRtt) delete(ls prometheus.Labels) {
+	m.mMin.Delete(ls)
+	m.mMax.Delete(ls)
+	m.mAvg.Delete(ls)
+	m.mSum.Delete(ls)
+	m.mCnt.Delete(ls)
+	m.mStddev.Delete(ls)
+	m.mHdrsize.Delete(ls)
+	m.mP50.Delete(ls)
+	m.mP75.Delete(ls)
+	m.mP90.Delete(ls)
+	m.mP95.Delete(ls)
+	m.mP99.Delete(ls)
+	m.mP99_99.Delete(ls)
+	m.mOutofrange.Delete(ls)
+	for i := range m.derived {
+		m.derived[i].Vec.Delete(ls)
+	}
+}
+
+type statsStateBrokersRtt struct {
+	labels prometheus.Labels
+	// Children of the metrics bound to labels
+	gauges  [14]prometheus.Gauge
+	derived []prometheus.Gauge
+}
+
+// equalLabels reports whether the labels of v and parent did not change
+func (s *statsStateBrokersRtt) equalLabels(m *statsMetricsBrokersRtt, v *typed.WindowStats, parent prometheus.Labels) bool {
+	if s.labels == nil || len(s.labels) != len(parent)+0 || !gen.ContainsLabels(s.labels, parent) {
+		return false
+	}
+	return true
+}
+
+// values updates the metrics of this prefix and returns the labels of v
+func (s *statsStateBrokersRtt) values(m *statsMetricsBrokersRtt, v *typed.WindowStats, parent prometheus.Labels) prometheus.Labels {
+	if !s.equalLabels(m, v, parent) {
+		ls := make(prometheus.Labels, len(parent)+0)
+		for k, lv := range parent {
+			ls[k] = lv
+		}
+		// Series of outdated labels are dropped
+		if s.labels != nil {
+			m.delete(s.labels)
+		}
+		s.labels = gen.CloneLabels(ls)
+		s.gauges[0] = m.mMin.With(s.labels)
+		s.gauges[1] = m.mMax.With(s.labels)
+		s.gauges[2] = m.mAvg.With(s.labels)
+		s.gauges[3] = m.mSum.With(s.labels)
+		s.gauges[4] = m.mCnt.With(s.labels)
+		s.gauges[5] = m.mStddev.With(s.labels)
+		s.gauges[6] = m.mHdrsize.With(s.labels)
+		s.gauges[7] = m.mP50.With(s.labels)
+		s.gauges[8] = m.mP75.With(s.labels)
+		s.gauges[9] = m.mP90.With(s.labels)
+		s.gauges[10] = m.mP95.With(s.labels)
+		s.gauges[11] = m.mP99.With(s.labels)
+		s.gauges[12] = m.mP99_99.With(s.labels)
+		s.gauges[13] = m.mOutofrange.With(s.labels)
+		s.derived = nil
+	}
+	s.gauges[0].Set(float64(v.Min))
+	s.gauges[1].Set(float64(v.Max))
+	s.gauges[2].Set(float64(v.Avg))
+	s.gauges[3].Set(float64(v.Sum))
+	s.gauges[4].Set(float64(v.Cnt))
+	s.gauges[5].Set(float64(v.Stddev))
+	s.gauges[6].Set(float64(v.Hdrsize))
+	s.gauges[7].Set(float64(v.P50))
+	s.gauges[8].Set(float64(v.P75))
+	s.gauges[9].Set(float64(v.P90))
+	s.gauges[10].Set(float64(v.P95))
+	s.gauges[11].Set(float64(v.P99))
+	s.gauges[12].Set(float64(v.P99_99))
+	s.gauges[13].Set(float64(v.Outofrange))
+	return s.labels
+}
+
+// derive updates the derived metrics with the complete value v. Their
+// series are created on the first call after a change of labels.
+func (s *statsStateBrokersRtt) derive(m *statsMetricsBrokersRtt, v *typed.WindowStats) {
+	if s.derived == nil && len(m.derived) != 0 {
+		s.derived = make([]prometheus.Gauge, len(m.derived))
+		for i := range m.derived {
+			s.derived[i] = m.derived[i].Vec.With(s.labels)
+		}
+	}
+	for i, d := range s.derived {
+		d.Set(m.derived[i].Fun(v))
+	}
+}
+
+func (s *statsStateBrokersRtt) update(m *statsMetricsBrokersRtt, v *typed.WindowStats, parent prometheus.Labels) {
+	s.values(m, v, parent)
+	s.derive(m, v)
+}
+
+// statsRawBrokersRtt holds the decoded fields of a JSON value. Maps and nested
+// structs are held by the raw values they contain.
+type statsRawBrokersRtt struct {
+	v typed.WindowStats
+}
+
+func (r *statsRawBrokersRtt) decode(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		var err error
+		switch o.Key() {
+		case "min":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Min = int(x)
+		case "max":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Max = int(x)
+		case "avg":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Avg = int(x)
+		case "sum":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Sum = int(x)
+		case "cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Cnt = int(x)
+		case "stddev":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Stddev = int(x)
+		case "hdrsize":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Hdrsize = int(x)
+		case "p50":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P50 = int(x)
+		case "p75":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P75 = int(x)
+		case "p90":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P90 = int(x)
+		case "p95":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P95 = int(x)
+		case "p99":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P99 = int(x)
+		case "p99_99":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P99_99 = int(x)
+		case "outofrange":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Outofrange = int(x)
+		}
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+// filter marks the entries of maps skipped by the filters of m. Fails
+// if an entry to filter cannot be decoded completely.
+func (r *statsRawBrokersRtt) filter(m *statsMetricsBrokersRtt) error {
+	return nil
+}
+
+func (s *statsStateBrokersRtt) stream(m *statsMetricsBrokersRtt, r *statsRawBrokersRtt, parent prometheus.Labels) {
+	s.values(m, &r.v, parent)
+}
+
+func (s *statsStateBrokersRtt) count(m *statsMetricsBrokersRtt, entries map[string]int) int {
+	if s.labels == nil {
+		return 0
+	}
+	series := 14 + len(s.derived)
+	return series
+}
+
+// delete removes all series of this value and the values it contains
+func (s *statsStateBrokersRtt) delete(m *statsMetricsBrokersRtt) {
+	if s.labels != nil {
+		m.delete(s.labels)
+	}
+}
+
+type statsMetricsBrokersThrottle struct {
+	labelNames  []string
+	mMin        *prometheus.GaugeVec
+	mMax        *prometheus.GaugeVec
+	mAvg        *prometheus.GaugeVec
+	mSum        *prometheus.GaugeVec
+	mCnt        *prometheus.GaugeVec
+	mStddev     *prometheus.GaugeVec
+	mHdrsize    *prometheus.GaugeVec
+	mP50        *prometheus.GaugeVec
+	mP75        *prometheus.GaugeVec
+	mP90        *prometheus.GaugeVec
+	mP95        *prometheus.GaugeVec
+	mP99        *prometheus.GaugeVec
+	mP99_99     *prometheus.GaugeVec
+	mOutofrange *prometheus.GaugeVec
+	derived     []gen.GeneratedDerived
+}
+
+func newStatsMetricsBrokersThrottle(o *gen.GeneratedOptions, parentLabelNames []string) *statsMetricsBrokersThrottle {
+	m := &statsMetricsBrokersThrottle{}
+	m.labelNames = append(m.labelNames, parentLabelNames...)
+	m.mMin = o.NewGaugeVec("brokers_throttle", "Min", "Smallest value", m.labelNames)
+	m.mMax = o.NewGaugeVec("brokers_throttle", "Max", "Largest value", m.labelNames)
+	m.mAvg = o.NewGaugeVec("brokers_throttle", "Avg", "Average value", m.labelNames)
+	m.mSum = o.NewGaugeVec("brokers_throttle", "Sum", "Sum of values", m.labelNames)
+	m.mCnt = o.NewGaugeVec("brokers_throttle", "Cnt", "Number of values sampled", m.labelNames)
+	m.mStddev = o.NewGaugeVec("brokers_throttle", "Stddev", "Standard deviation (based on histogram)", m.labelNames)
+	m.mHdrsize = o.NewGaugeVec("brokers_throttle", "Hdrsize", "Memory size of Hdr Histogram", m.labelNames)
+	m.mP50 = o.NewGaugeVec("brokers_throttle", "P50", "50th percentile", m.labelNames)
+	m.mP75 = o.NewGaugeVec("brokers_throttle", "P75", "75th percentile", m.labelNames)
+	m.mP90 = o.NewGaugeVec("brokers_throttle", "P90", "90th percentile", m.labelNames)
+	m.mP95 = o.NewGaugeVec("brokers_throttle", "P95", "95th percentile", m.labelNames)
+	m.mP99 = o.NewGaugeVec("brokers_throttle", "P99", "99th percentile", m.labelNames)
+	m.mP99_99 = o.NewGaugeVec("brokers_throttle", "P99_99", "99.99th percentile", m.labelNames)
+	m.mOutofrange = o.NewGaugeVec("brokers_throttle", "Outofrange", "Values skipped due to out of histogram range", m.labelNames)
+	m.derived = o.NewDerived("brokers_throttle", "Stats.Brokers[].Throttle", reflect.TypeOf(typed.WindowStats{}), m.labelNames)
+	return m
+}
+
+// share returns a copy of m computing filters and derived metrics with
+// the functions of o
+func (m *statsMetricsBrokersThrottle) share(o *gen.GeneratedOptions) *statsMetricsBrokersThrottle {
+	c := *m
+	c.derived = o.ShareDerived(m.derived, "Stats.Brokers[].Throttle")
+	return &c
+}
+
+func (m *statsMetricsBrokersThrottle) describe(ch chan<- *prometheus.Desc) {
+	m.mMin.Describe(ch)
+	m.mMax.Describe(ch)
+	m.mAvg.Describe(ch)
+	m.mSum.Describe(ch)
+	m.mCnt.Describe(ch)
+	m.mStddev.Describe(ch)
+	m.mHdrsize.Describe(ch)
+	m.mP50.Describe(ch)
+	m.mP75.Describe(ch)
+	m.mP90.Describe(ch)
+	m.mP95.Describe(ch)
+	m.mP99.Describe(ch)
+	m.mP99_99.Describe(ch)
+	m.mOutofrange.Describe(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Describe(ch)
+	}
+}
+
+func (m *statsMetricsBrokersThrottle) collect(ch chan<- prometheus.Metric) {
+	m.mMin.Collect(ch)
+	m.mMax.Collect(ch)
+	m.mAvg.Collect(ch)
+	m.mSum.Collect(ch)
+	m.mCnt.Collect(ch)
+	m.mStddev.Collect(ch)
+	m.mHdrsize.Collect(ch)
+	m.mP50.Collect(ch)
+	m.mP75.Collect(ch)
+	m.mP90.Collect(ch)
+	m.mP95.Collect(ch)
+	m.mP99.Collect(ch)
+	m.mP99_99.Collect(ch)
+	m.mOutofrange.Collect(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Collect(ch)
+	}
+}
+
+// delete removes the series with labels ls of this prefix
+func (m *statsMetricsBrokersThrottle) delete(ls prometheus.Labels) {
+	m.mMin.Delete(ls)
+	m.mMax.Delete(ls)
+	m.mAvg.Delete(ls)
+	m.mSum.Delete(ls)
+	m.mCnt.Delete(ls)
+	m.mStddev.Delete(ls)
+	m.mHdrsize.Delete(ls)
+	m.mP50.Delete(ls)
+	m.mP75.Delete(ls)
+	m.mP90.Delete(ls)
+	m.mP95.Delete(ls)
+	m.mP99.Delete(ls)
+	m.mP99_99.Delete(ls)
+	m.mOutofrange.Delete(ls)
+	for i := range m.derived {
+		m.derived[i].Vec.Delete(ls)
+	}
+}
+
+type statsStateBrokersThrottle struct {
+	labels prometheus.Labels
+	// Children of the metrics bound to labels
+	gauges  [14]prometheus.Gauge
+	derived []prometheus.Gauge
+}
+
+// equalLabels reports whether the labels of v and parent did not change
+func (s *statsStateBrokersThrottle) equalLabels(m *statsMetricsBrokersThrottle, v *typed.WindowStats, parent prometheus.Labels) bool {
+	if s.labels == nil || len(s.labels) != len(parent)+0 || !gen.ContainsLabels(s.labels, parent) {
+		return false
+	}
+	return true
+}
+
+// values updates the metrics of this prefix and returns the labels of v
+func (s *statsStateBrokersThrottle) values(m *statsMetricsBrokersThrottle, v *typed.WindowStats, parent prometheus.Labels) prometheus.Labels {
+	if !s.equalLabels(m, v, parent) {
+		ls := make(prometheus.Labels, len(parent)+0)
+		for k, lv := range parent {
+			ls[k] = lv
+		}
+		// Series of outdated labels are dropped
+		if s.labels != nil {
+			m.delete(s.labels)
+		}
+		s.labels = gen.CloneLabels(ls)
+		s.gauges[0] = m.mMin.With(s.labels)
+		s.gauges[1] = m.mMax.With(s.labels)
+		s.gauges[2] = m.mAvg.With(s.labels)
+		s.gauges[3] = m.mSum.With(s.labels)
+		s.gauges[4] = m.mCnt.With(s.labels)
+		s.gauges[5] = m.mStddev.With(s.labels)
+		s.gauges[6] = m.mHdrsize.With(s.labels)
+		s.gauges[7] = m.mP50.With(s.labels)
+		s.gauges[8] = m.mP75.With(s.labels)
+		s.gauges[9] = m.mP90.With(s.labels)
+		s.gauges[10] = m.mP95.With(s.labels)
+		s.gauges[11] = m.mP99.With(s.labels)
+		s.gauges[12] = m.mP99_99.With(s.labels)
+		s.gauges[13] = m.mOutofrange.With(s.labels)
+		s.derived = nil
+	}
+	s.gauges[0].Set(float64(v.Min))
+	s.gauges[1].Set(float64(v.Max))
+	s.gauges[2].Set(float64(v.Avg))
+	s.gauges[3].Set(float64(v.Sum))
+	s.gauges[4].Set(float64(v.Cnt))
+	s.gauges[5].Set(float64(v.Stddev))
+	s.gauges[6].Set(float64(v.Hdrsize))
+	s.gauges[7].Set(float64(v.P50))
+	s.gauges[8].Set(float64(v.P75))
+	s.gauges[9].Set(float64(v.P90))
+	s.gauges[10].Set(float64(v.P95))
+	s.gauges[11].Set(float64(v.P99))
+	s.gauges[12].Set(float64(v.P99_99))
+	s.gauges[13].Set(float64(v.Outofrange))
+	return s.labels
+}
+
+// derive updates the derived metrics with the complete value v. Their
+// series are created on the first call after a change of labels.
+func (s *statsStateBrokersThrottle) derive(m *statsMetricsBrokersThrottle, v *typed.WindowStats) {
+	if s.derived == nil && len(m.derived) != 0 {
+		s.derived = make([]prometheus.Gauge, len(m.derived))
+		for i := range m.derived {
+			s.derived[i] = m.derived[i].Vec.With(s.labels)
+		}
+	}
+	for i, d := range s.derived {
+		d.Set(m.derived[i].Fun(v))
+	}
+}
+
+func (s *statsStateBrokersThrottle) update(m *statsMetricsBrokersThrottle, v *typed.WindowStats, parent prometheus.Labels) {
+	s.values(m, v, parent)
+	s.derive(m, v)
+}
+
+// statsRawBrokersThrottle holds the decoded fields of a JSON value. Maps and nested
+// structs are held by the raw values they contain.
+type statsRawBrokersThrottle struct {
+	v typed.WindowStats
+}
+
+func (r *statsRawBrokersThrottle) decode(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		var err error
+		switch o.Key() {
+		case "min":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Min = int(x)
+		case "max":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Max = int(x)
+		case "avg":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Avg = int(x)
+		case "sum":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Sum = int(x)
+		case "cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Cnt = int(x)
+		case "stddev":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Stddev = int(x)
+		case "hdrsize":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Hdrsize = int(x)
+		case "p50":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P50 = int(x)
+		case "p75":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P75 = int(x)
+		case "p90":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P90 = int(x)
+		case "p95":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P95 = int(x)
+		case "p99":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P99 = int(x)
+		case "p99_99":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P99_99 = int(x)
+		case "outofrange":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Outofrange = int(x)
+		}
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+// filter marks the entries of maps skipped by the filters of m. Fails
+// if an entry to filter cannot be decoded completely.
+func (r *statsRawBrokersThrottle) filter(m *statsMetricsBrokersThrottle) error {
+	return nil
+}
+
+func (s *statsStateBrokersThrottle) stream(m *statsMetricsBrokersThrottle, r *statsRawBrokersThrottle, parent prometheus.Labels) {
+	s.values(m, &r.v, parent)
+}
+
+func (s *statsStateBrokersThrottle) count(m *statsMetricsBrokersThrottle, entries map[string]int) int {
+	if s.labels == nil {
+		return 0
+	}
+	series := 14 + len(s.derived)
+	return series
+}
+
+// delete removes all series of this value and the values it contains
+func (s *statsStateBrokersThrottle) delete(m *statsMetricsBrokersThrottle) {
+	if s.labels != nil {
+		m.delete(s.labels)
+	}
+}
+
+type statsMetricsTopics struct {
+	labelNames                   []string
+	lTopic                       string
+	mAge                         *prometheus.GaugeVec
+	mMetadataAge                 *prometheus.GaugeVec
+	derived                      []gen.GeneratedDerived
+	nBatchsize                   *statsMetricsTopicsBatchsize
+	nBatchcnt                    *statsMetricsTopicsBatchcnt
+	ePartitions                  *statsMetricsTopicsPartitions // nil if aggregated
+	fPartitions                  func(key typed.PartitionId, value typed.PartitionStats) bool
+	aPartitionsMsgqCnt           *prometheus.GaugeVec
+	aPartitionsMsgqBytes         *prometheus.GaugeVec
+	aPartitionsXmitMsgqCnt       *prometheus.GaugeVec
+	aPartitionsXmitMsgqBytes     *prometheus.GaugeVec
+	aPartitionsFetchqCnt         *prometheus.GaugeVec
+	aPartitionsFetchqSize        *prometheus.GaugeVec
+	aPartitionsConsumerLag       *prometheus.GaugeVec
+	aPartitionsConsumerLagStored *prometheus.GaugeVec
+	aPartitionsTxmsgs            *prometheus.CounterVec
+	aPartitionsTxbytes           *prometheus.CounterVec
+	aPartitionsRxmsgs            *prometheus.CounterVec
+	aPartitionsRxbytes           *prometheus.CounterVec
+	aPartitionsMsgs              *prometheus.CounterVec
+	aPartitionsRxVerDrops        *prometheus.CounterVec
+	aPartitionsMsgsInflight      *prometheus.GaugeVec
+}
+
+func newStatsMetricsTopics(o *gen.GeneratedOptions, parentLabelNames []string) *statsMetricsTopics {
+	m := &statsMetricsTopics{}
+	m.labelNames = append(m.labelNames, parentLabelNames...)
+	m.lTopic = o.LabelName("topics", "topic")
+	m.labelNames = append(m.labelNames, m.lTopic)
+	m.mAge = o.NewGaugeVec("topics", "Age", "Age of client's topic object (milliseconds)", m.labelNames)
+	m.mMetadataAge = o.NewGaugeVec("topics", "MetadataAge", "Age of metadata from broker for this topic (milliseconds)", m.labelNames)
+	m.derived = o.NewDerived("topics", "Stats.Topics[]", reflect.TypeOf(typed.TopicStats{}), m.labelNames)
+	if o.NestedInMapEntries() {
+		m.nBatchsize = newStatsMetricsTopicsBatchsize(o, m.labelNames)
+	}
+	if o.NestedInMapEntries() {
+		m.nBatchcnt = newStatsMetricsTopicsBatchcnt(o, m.labelNames)
+	}
+	m.fPartitions = gen.MapEntryFilterFunc[typed.PartitionId, typed.PartitionStats](o, "Stats.Topics[].Partitions")
+	if o.Aggregate("Stats.Topics[].Partitions") {
+		m.aPartitionsMsgqCnt = o.NewGaugeVec("topics", "MsgqCnt", "Number of messages waiting to be produced in first-level queue", m.labelNames)
+		m.aPartitionsMsgqBytes = o.NewGaugeVec("topics", "MsgqBytes", "Number of bytes in msgq_cnt", m.labelNames)
+		m.aPartitionsXmitMsgqCnt = o.NewGaugeVec("topics", "XmitMsgqCnt", "Number of messages ready to be produced in transmit queue", m.labelNames)
+		m.aPartitionsXmitMsgqBytes = o.NewGaugeVec("topics", "XmitMsgqBytes", "Number of bytes in xmit_msgq", m.labelNames)
+		m.aPartitionsFetchqCnt = o.NewGaugeVec("topics", "FetchqCnt", "Number of pre-fetched messages in fetch queue", m.labelNames)
+		m.aPartitionsFetchqSize = o.NewGaugeVec("topics", "FetchqSize", "Bytes in fetchq", m.labelNames)
+		m.aPartitionsConsumerLag = o.NewGaugeVec("topics", "ConsumerLag", "Difference between (hi_offset or ls_offset) and committed_offset). hi_offset is used when isolation.level=read_uncommitted, otherwise ls_offset.", m.labelNames)
+		m.aPartitionsConsumerLagStored = o.NewGaugeVec("topics", "ConsumerLagStored", "Difference between (hi_offset or ls_offset) and stored_offset. See consumer_lag and stored_offset.", m.labelNames)
+		m.aPartitionsTxmsgs = o.NewCounterVec("topics", "Txmsgs", "Total number of messages transmitted (produced)", m.labelNames)
+		m.aPartitionsTxbytes = o.NewCounterVec("topics", "Txbytes", "Total number of bytes transmitted for txmsgs", m.labelNames)
+		m.aPartitionsRxmsgs = o.NewCounterVec("topics", "Rxmsgs", "Total number of messages consumed, not including ignored messages (due to offset, etc).", m.labelNames)
+		m.aPartitionsRxbytes = o.NewCounterVec("topics", "Rxbytes", "Total number of bytes received for rxmsgs", m.labelNames)
+		m.aPartitionsMsgs = o.NewCounterVec("topics", "Msgs", "Total number of messages received (consumer, same as rxmsgs), or total number of messages produced (possibly not yet transmitted) (producer).", m.labelNames)
+		m.aPartitionsRxVerDrops = o.NewCounterVec("topics", "RxVerDrops", "Dropped outdated messages", m.labelNames)
+		m.aPartitionsMsgsInflight = o.NewGaugeVec("topics", "MsgsInflight", "Current number of messages in-flight to/from broker", m.labelNames)
+	} else {
+		m.ePartitions = newStatsMetricsTopicsPartitions(o, m.labelNames)
+	}
+	return m
+}
+
+// share returns a copy of m computing filters and derived metrics with
+// the functions of o
+func (m *statsMetricsTopics) share(o *gen.GeneratedOptions) *statsMetricsTopics {
+	c := *m
+	c.derived = o.ShareDerived(m.derived, "Stats.Topics[]")
+	if m.nBatchsize != nil {
+		c.nBatchsize = m.nBatchsize.share(o)
+	}
+	if m.nBatchcnt != nil {
+		c.nBatchcnt = m.nBatchcnt.share(o)
+	}
+	c.fPartitions = gen.MapEntryFilterFunc[typed.PartitionId, typed.PartitionStats](o, "Stats.Topics[].Partitions")
+	if m.ePartitions != nil {
+		c.ePartitions = m.ePartitions.share(o)
+	}
+	return &c
+}
+
+func (m *statsMetricsTopics) describe(ch chan<- *prometheus.Desc) {
+	m.mAge.Describe(ch)
+	m.mMetadataAge.Describe(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Describe(ch)
+	}
+	if m.nBatchsize != nil {
+		m.nBatchsize.describe(ch)
+	}
+	if m.nBatchcnt != nil {
+		m.nBatchcnt.describe(ch)
+	}
+	if m.ePartitions != nil {
+		m.ePartitions.describe(ch)
+	} else {
+		m.aPartitionsMsgqCnt.Describe(ch)
+		m.aPartitionsMsgqBytes.Describe(ch)
+		m.aPartitionsXmitMsgqCnt.Describe(ch)
+		m.aPartitionsXmitMsgqBytes.Describe(ch)
+		m.aPartitionsFetchqCnt.Describe(ch)
+		m.aPartitionsFetchqSize.Describe(ch)
+		m.aPartitionsConsumerLag.Describe(ch)
+		m.aPartitionsConsumerLagStored.Describe(ch)
+		m.aPartitionsTxmsgs.Describe(ch)
+		m.aPartitionsTxbytes.Describe(ch)
+		m.aPartitionsRxmsgs.Describe(ch)
+		m.aPartitionsRxbytes.Describe(ch)
+		m.aPartitionsMsgs.Describe(ch)
+		m.aPartitionsRxVerDrops.Describe(ch)
+		m.aPartitionsMsgsInflight.Describe(ch)
+	}
+}
+
+func (m *statsMetricsTopics) collect(ch chan<- prometheus.Metric) {
+	m.mAge.Collect(ch)
+	m.mMetadataAge.Collect(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Collect(ch)
+	}
+	if m.nBatchsize != nil {
+		m.nBatchsize.collect(ch)
+	}
+	if m.nBatchcnt != nil {
+		m.nBatchcnt.collect(ch)
+	}
+	if m.ePartitions != nil {
+		m.ePartitions.collect(ch)
+	} else {
+		m.aPartitionsMsgqCnt.Collect(ch)
+		m.aPartitionsMsgqBytes.Collect(ch)
+		m.aPartitionsXmitMsgqCnt.Collect(ch)
+		m.aPartitionsXmitMsgqBytes.Collect(ch)
+		m.aPartitionsFetchqCnt.Collect(ch)
+		m.aPartitionsFetchqSize.Collect(ch)
+		m.aPartitionsConsumerLag.Collect(ch)
+		m.aPartitionsConsumerLagStored.Collect(ch)
+		m.aPartitionsTxmsgs.Collect(ch)
+		m.aPartitionsTxbytes.Collect(ch)
+		m.aPartitionsRxmsgs.Collect(ch)
+		m.aPartitionsRxbytes.Collect(ch)
+		m.aPartitionsMsgs.Collect(ch)
+		m.aPartitionsRxVerDrops.Collect(ch)
+		m.aPartitionsMsgsInflight.Collect(ch)
+	}
+}
+
+// delete removes the series with labels ls of this prefix
+func (m *statsMetricsTopics) delete(ls prometheus.Labels) {
+	m.mAge.Delete(ls)
+	m.mMetadataAge.Delete(ls)
+	for i := range m.derived {
+		m.derived[i].Vec.Delete(ls)
+	}
+	if m.ePartitions == nil {
+		m.aPartitionsMsgqCnt.Delete(ls)
+		m.aPartitionsMsgqBytes.Delete(ls)
+		m.aPartitionsXmitMsgqCnt.Delete(ls)
+		m.aPartitionsXmitMsgqBytes.Delete(ls)
+		m.aPartitionsFetchqCnt.Delete(ls)
+		m.aPartitionsFetchqSize.Delete(ls)
+		m.aPartitionsConsumerLag.Delete(ls)
+		m.aPartitionsConsumerLagStored.Delete(ls)
+		m.aPartitionsTxmsgs.Delete(ls)
+		m.aPartitionsTxbytes.Delete(ls)
+		m.aPartitionsRxmsgs.Delete(ls)
+		m.aPartitionsRxbytes.Delete(ls)
+		m.aPartitionsMsgs.Delete(ls)
+		m.aPartitionsRxVerDrops.Delete(ls)
+		m.aPartitionsMsgsInflight.Delete(ls)
+	}
+}
+
+type statsStateTopics struct {
+	labels prometheus.Labels
+	epoch  uint64 // Of last streaming update
+	// Children of the metrics bound to labels
+	gauges       [2]prometheus.Gauge
+	derived      []prometheus.Gauge
+	nBatchsize   statsStateTopicsBatchsize
+	nBatchcnt    statsStateTopicsBatchcnt
+	ePartitions  map[typed.PartitionId]*statsStateTopicsPartitions
+	gPartitions  uint64                                               // Epoch of streaming updates
+	vPartitions  typed.PartitionStats                                 // Entry being updated, held here to not allocate per entry
+	laPartitions map[typed.PartitionId]statsStateTopicsPartitionsLast // Last values of aggregated counters by entry
+	acPartitions [6]prometheus.Counter
+	agPartitions [9]prometheus.Gauge
+}
+
+type statsStateTopicsPartitionsLast struct {
+	epoch  uint64 // Epoch of the last update of the entry
+	values [6]int64
+}
+
+// equalLabels reports whether the labels of v and parent did not change
+func (s *statsStateTopics) equalLabels(m *statsMetricsTopics, v *typed.TopicStats, parent prometheus.Labels) bool {
+	if s.labels == nil || len(s.labels) != len(parent)+1 || !gen.ContainsLabels(s.labels, parent) {
+		return false
+	}
+	return true &&
+		s.labels[m.lTopic] == v.Topic
+}
+
+// values updates the metrics of this prefix and returns the labels of v
+func (s *statsStateTopics) values(m *statsMetricsTopics, v *typed.TopicStats, parent prometheus.Labels) prometheus.Labels {
+	if !s.equalLabels(m, v, parent) {
+		ls := make(prometheus.Labels, len(parent)+1)
+		for k, lv := range parent {
+			ls[k] = lv
+		}
+		ls[m.lTopic] = v.Topic
+		// Series of outdated labels are dropped
+		if s.labels != nil {
+			m.delete(s.labels)
+		}
+		s.labels = gen.CloneLabels(ls)
+		s.gauges[0] = m.mAge.With(s.labels)
+		s.gauges[1] = m.mMetadataAge.With(s.labels)
+		s.derived = nil
+		s.laPartitions = nil
+		if m.ePartitions == nil {
+			s.agPartitions[0] = m.aPartitionsMsgqCnt.With(s.labels)
+			s.agPartitions[1] = m.aPartitionsMsgqBytes.With(s.labels)
+			s.agPartitions[2] = m.aPartitionsXmitMsgqCnt.With(s.labels)
+			s.agPartitions[3] = m.aPartitionsXmitMsgqBytes.With(s.labels)
+			s.agPartitions[4] = m.aPartitionsFetchqCnt.With(s.labels)
+			s.agPartitions[5] = m.aPartitionsFetchqSize.With(s.labels)
+			s.agPartitions[6] = m.aPartitionsConsumerLag.With(s.labels)
+			s.agPartitions[7] = m.aPartitionsConsumerLagStored.With(s.labels)
+			s.acPartitions[0] = m.aPartitionsTxmsgs.With(s.labels)
+			s.acPartitions[1] = m.aPartitionsTxbytes.With(s.labels)
+			s.acPartitions[2] = m.aPartitionsRxmsgs.With(s.labels)
+			s.acPartitions[3] = m.aPartitionsRxbytes.With(s.labels)
+			s.acPartitions[4] = m.aPartitionsMsgs.With(s.labels)
+			s.acPartitions[5] = m.aPartitionsRxVerDrops.With(s.labels)
+			s.agPartitions[8] = m.aPartitionsMsgsInflight.With(s.labels)
+		}
+	}
+	s.gauges[0].Set(float64(v.Age))
+	s.gauges[1].Set(float64(v.MetadataAge))
+	return s.labels
+}
+
+// derive updates the derived metrics with the complete value v. Their
+// series are created on the first call after a change of labels.
+func (s *statsStateTopics) derive(m *statsMetricsTopics, v *typed.TopicStats) {
+	if s.derived == nil && len(m.derived) != 0 {
+		s.derived = make([]prometheus.Gauge, len(m.derived))
+		for i := range m.derived {
+			s.derived[i] = m.derived[i].Vec.With(s.labels)
+		}
+	}
+	for i, d := range s.derived {
+		d.Set(m.derived[i].Fun(v))
+	}
+}
+
+func (s *statsStateTopics) update(m *statsMetricsTopics, v *typed.TopicStats, parent prometheus.Labels) {
+	ls := s.values(m, v, parent)
+	s.derive(m, v)
+	if m.nBatchsize != nil {
+		s.nBatchsize.update(m.nBatchsize, &v.Batchsize, ls)
+	}
+	if m.nBatchcnt != nil {
+		s.nBatchcnt.update(m.nBatchcnt, &v.Batchcnt, ls)
+	}
+	if m.ePartitions == nil {
+		var agg [15]int64
+		initial := s.laPartitions == nil
+		if initial {
+			s.laPartitions = map[typed.PartitionId]statsStateTopicsPartitionsLast{}
+		}
+		s.gPartitions++
+		for k, ev := range v.Partitions {
+			if k < 0 || m.fPartitions != nil && !m.fPartitions(k, ev) {
+				continue
+			}
+			last, known := s.laPartitions[k]
+			last.epoch = s.gPartitions
+			if x := int64(ev.MsgqCnt); x > 0 {
+				agg[0] += x
+			}
+			if x := int64(ev.MsgqBytes); x > 0 {
+				agg[1] += x
+			}
+			if x := int64(ev.XmitMsgqCnt); x > 0 {
+				agg[2] += x
+			}
+			if x := int64(ev.XmitMsgqBytes); x > 0 {
+				agg[3] += x
+			}
+			if x := int64(ev.FetchqCnt); x > 0 {
+				agg[4] += x
+			}
+			if x := int64(ev.FetchqSize); x > 0 {
+				agg[5] += x
+			}
+			if x := int64(ev.ConsumerLag); x > 0 {
+				agg[6] += x
+			}
+			if x := int64(ev.ConsumerLagStored); x > 0 {
+				agg[7] += x
+			}
+			if x := int64(ev.Txmsgs); x >= 0 {
+				if (known || initial) && x > last.values[0] {
+					agg[8] += x - last.values[0]
+				}
+				last.values[0] = x
+			}
+			if x := int64(ev.Txbytes); x >= 0 {
+				if (known || initial) && x > last.values[1] {
+					agg[9] += x - last.values[1]
+				}
+				last.values[1] = x
+			}
+			if x := int64(ev.Rxmsgs); x >= 0 {
+				if (known || initial) && x > last.values[2] {
+					agg[10] += x - last.values[2]
+				}
+				last.values[2] = x
+			}
+			if x := int64(ev.Rxbytes); x >= 0 {
+				if (known || initial) && x > last.values[3] {
+					agg[11] += x - last.values[3]
+				}
+				last.values[3] = x
+			}
+			if x := int64(ev.Msgs); x >= 0 {
+				if (known || initial) && x > last.values[4] {
+					agg[12] += x - last.values[4]
+				}
+				last.values[4] = x
+			}
+			if x := int64(ev.RxVerDrops); x >= 0 {
+				if (known || initial) && x > last.values[5] {
+					agg[13] += x - last.values[5]
+				}
+				last.values[5] = x
+			}
+			if x := int64(ev.MsgsInflight); x > 0 {
+				agg[14] += x
+			}
+			s.laPartitions[k] = last
+		}
+		for k, last := range s.laPartitions {
+			if last.epoch != s.gPartitions {
+				delete(s.laPartitions, k)
+			}
+		}
+		s.agPartitions[0].Set(float64(agg[0]))
+		s.agPartitions[1].Set(float64(agg[1]))
+		s.agPartitions[2].Set(float64(agg[2]))
+		s.agPartitions[3].Set(float64(agg[3]))
+		s.agPartitions[4].Set(float64(agg[4]))
+		s.agPartitions[5].Set(float64(agg[5]))
+		s.agPartitions[6].Set(float64(agg[6]))
+		s.agPartitions[7].Set(float64(agg[7]))
+		s.acPartitions[0].Add(float64(agg[8]))
+		s.acPartitions[1].Add(float64(agg[9]))
+		s.acPartitions[2].Add(float64(agg[10]))
+		s.acPartitions[3].Add(float64(agg[11]))
+		s.acPartitions[4].Add(float64(agg[12]))
+		s.acPartitions[5].Add(float64(agg[13]))
+		s.agPartitions[8].Set(float64(agg[14]))
+	} else {
+		if s.ePartitions == nil {
+			s.ePartitions = map[typed.PartitionId]*statsStateTopicsPartitions{}
+		}
+		for k, es := range s.ePartitions {
+			ev, ok := v.Partitions[k]
+			if !ok || (m.fPartitions != nil && !m.fPartitions(k, ev)) {
+				es.delete(m.ePartitions)
+				delete(s.ePartitions, k)
+			}
+		}
+		for k, ev := range v.Partitions {
+			if m.fPartitions != nil && !m.fPartitions(k, ev) {
+				continue
+			}
+			es, ok := s.ePartitions[k]
+			if !ok {
+				es = &statsStateTopicsPartitions{}
+				s.ePartitions[k] = es
+			}
+			s.vPartitions = ev
+			es.update(m.ePartitions, &s.vPartitions, ls)
+		}
+	}
+}
+
+// statsRawTopics holds the decoded fields of a JSON value. Maps and nested
+// structs are held by the raw values they contain.
+type statsRawTopics struct {
+	v           typed.TopicStats
+	nBatchsize  statsRawTopicsBatchsize
+	nBatchcnt   statsRawTopicsBatchcnt
+	ePartitions []statsRawTopicsPartitionsEntry
+}
+
+type statsRawTopicsPartitionsEntry struct {
+	k    typed.PartitionId
+	r    statsRawTopicsPartitions
+	data string // Raw JSON of the entry
+	skip bool   // Whether the entry got filtered
+}
+
+func (r *statsRawTopics) decode(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		var err error
+		switch o.Key() {
+		case "topic":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.Topic = string(x)
+		case "age":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Age = int(x)
+		case "metadata_age":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.MetadataAge = int(x)
+		case "batchsize":
+			err = r.nBatchsize.decode(o.Value())
+		case "batchcnt":
+			err = r.nBatchcnt.decode(o.Value())
+		case "partitions":
+			err = r.decodePartitions(o.Value())
+		}
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+func (r *statsRawTopics) decodePartitions(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		k, err := strconv.ParseInt(o.Key(), 10, 64)
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+		r.ePartitions = append(r.ePartitions, statsRawTopicsPartitionsEntry{k: typed.PartitionId(k), data: o.Value()})
+		err = r.ePartitions[len(r.ePartitions)-1].r.decode(o.Value())
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+// filter marks the entries of maps skipped by the filters of m. Fails
+// if an entry to filter cannot be decoded completely.
+func (r *statsRawTopics) filter(m *statsMetricsTopics) error {
+	var err error
+	for i := range r.ePartitions {
+		e := &r.ePartitions[i]
+		if m.fPartitions != nil {
+			var v typed.PartitionStats
+			err = gen.DecodeJSON(e.data, &v)
+			if err != nil {
+				return gen.JSONFieldError("partitions", err)
+			}
+			e.skip = !m.fPartitions(e.k, v)
+		}
+	}
+	return nil
+}
+
+func (s *statsStateTopics) stream(m *statsMetricsTopics, r *statsRawTopics, parent prometheus.Labels) {
+	ls := s.values(m, &r.v, parent)
+	if m.nBatchsize != nil {
+		s.nBatchsize.stream(m.nBatchsize, &r.nBatchsize, ls)
+	}
+	if m.nBatchcnt != nil {
+		s.nBatchcnt.stream(m.nBatchcnt, &r.nBatchcnt, ls)
+	}
+	if m.ePartitions == nil {
+		var agg [15]int64
+		initial := s.laPartitions == nil
+		if initial {
+			s.laPartitions = map[typed.PartitionId]statsStateTopicsPartitionsLast{}
+		}
+		s.gPartitions++
+		for i := range r.ePartitions {
+			if r.ePartitions[i].skip {
+				continue
+			}
+			k, er := r.ePartitions[i].k, &r.ePartitions[i].r
+			if k < 0 {
+				continue
+			}
+			last, known := s.laPartitions[k]
+			last.epoch = s.gPartitions
+			if x := int64(er.v.MsgqCnt); x > 0 {
+				agg[0] += x
+			}
+			if x := int64(er.v.MsgqBytes); x > 0 {
+				agg[1] += x
+			}
+			if x := int64(er.v.XmitMsgqCnt); x > 0 {
+				agg[2] += x
+			}
+			if x := int64(er.v.XmitMsgqBytes); x > 0 {
+				agg[3] += x
+			}
+			if x := int64(er.v.FetchqCnt); x > 0 {
+				agg[4] += x
+			}
+			if x := int64(er.v.FetchqSize); x > 0 {
+				agg[5] += x
+			}
+			if x := int64(er.v.ConsumerLag); x > 0 {
+				agg[6] += x
+			}
+			if x := int64(er.v.ConsumerLagStored); x > 0 {
+				agg[7] += x
+			}
+			if x := int64(er.v.Txmsgs); x >= 0 {
+				if (known || initial) && x > last.values[0] {
+					agg[8] += x - last.values[0]
+				}
+				last.values[0] = x
+			}
+			if x := int64(er.v.Txbytes); x >= 0 {
+				if (known || initial) && x > last.values[1] {
+					agg[9] += x - last.values[1]
+				}
+				last.values[1] = x
+			}
+			if x := int64(er.v.Rxmsgs); x >= 0 {
+				if (known || initial) && x > last.values[2] {
+					agg[10] += x - last.values[2]
+				}
+				last.values[2] = x
+			}
+			if x := int64(er.v.Rxbytes); x >= 0 {
+				if (known || initial) && x > last.values[3] {
+					agg[11] += x - last.values[3]
+				}
+				last.values[3] = x
+			}
+			if x := int64(er.v.Msgs); x >= 0 {
+				if (known || initial) && x > last.values[4] {
+					agg[12] += x - last.values[4]
+				}
+				last.values[4] = x
+			}
+			if x := int64(er.v.RxVerDrops); x >= 0 {
+				if (known || initial) && x > last.values[5] {
+					agg[13] += x - last.values[5]
+				}
+				last.values[5] = x
+			}
+			if x := int64(er.v.MsgsInflight); x > 0 {
+				agg[14] += x
+			}
+			s.laPartitions[k] = last
+		}
+		for k, last := range s.laPartitions {
+			if last.epoch != s.gPartitions {
+				delete(s.laPartitions, k)
+			}
+		}
+		s.agPartitions[0].Set(float64(agg[0]))
+		s.agPartitions[1].Set(float64(agg[1]))
+		s.agPartitions[2].Set(float64(agg[2]))
+		s.agPartitions[3].Set(float64(agg[3]))
+		s.agPartitions[4].Set(float64(agg[4]))
+		s.agPartitions[5].Set(float64(agg[5]))
+		s.agPartitions[6].Set(float64(agg[6]))
+		s.agPartitions[7].Set(float64(agg[7]))
+		s.acPartitions[0].Add(float64(agg[8]))
+		s.acPartitions[1].Add(float64(agg[9]))
+		s.acPartitions[2].Add(float64(agg[10]))
+		s.acPartitions[3].Add(float64(agg[11]))
+		s.acPartitions[4].Add(float64(agg[12]))
+		s.acPartitions[5].Add(float64(agg[13]))
+		s.agPartitions[8].Set(float64(agg[14]))
+	} else {
+		if s.ePartitions == nil {
+			s.ePartitions = map[typed.PartitionId]*statsStateTopicsPartitions{}
+		}
+		// Entries not updated in this epoch are gone
+		s.gPartitions++
+		for i := range r.ePartitions {
+			if r.ePartitions[i].skip {
+				continue
+			}
+			k, er := r.ePartitions[i].k, &r.ePartitions[i].r
+			es, ok := s.ePartitions[k]
+			if !ok {
+				es = &statsStateTopicsPartitions{}
+				s.ePartitions[k] = es
+			}
+			es.epoch = s.gPartitions
+			es.stream(m.ePartitions, er, ls)
+		}
+		for k, es := range s.ePartitions {
+			if es.epoch != s.gPartitions {
+				es.delete(m.ePartitions)
+				delete(s.ePartitions, k)
+			}
+		}
+	}
+}
+
+func (s *statsStateTopics) count(m *statsMetricsTopics, entries map[string]int) int {
+	if s.labels == nil {
+		return 0
+	}
+	series := 2 + len(s.derived)
+	if m.nBatchsize != nil {
+		series += s.nBatchsize.count(m.nBatchsize, entries)
+	}
+	if m.nBatchcnt != nil {
+		series += s.nBatchcnt.count(m.nBatchcnt, entries)
+	}
+	if m.ePartitions == nil {
+		series += 15
+	} else {
+		entries["topics_partitions"] += len(s.ePartitions)
+		for _, es := range s.ePartitions {
+			series += es.count(m.ePartitions, entries)
+		}
+	}
+	return series
+}
+
+// delete removes all series of this value and the values it contains
+func (s *statsStateTopics) delete(m *statsMetricsTopics) {
+	if s.labels != nil {
+		m.delete(s.labels)
+	}
+	if m.nBatchsize != nil {
+		s.nBatchsize.delete(m.nBatchsize)
+	}
+	if m.nBatchcnt != nil {
+		s.nBatchcnt.delete(m.nBatchcnt)
+	}
+	for _, es := range s.ePartitions {
+		es.delete(m.ePartitions)
+	}
+}
+
+type statsMetricsTopicsBatchsize struct {
+	labelNames  []string
+	mMin        *prometheus.GaugeVec
+	mMax        *prometheus.GaugeVec
+	mAvg        *prometheus.GaugeVec
+	mSum        *prometheus.GaugeVec
+	mCnt        *prometheus.GaugeVec
+	mStddev     *prometheus.GaugeVec
+	mHdrsize    *prometheus.GaugeVec
+	mP50        *prometheus.GaugeVec
+	mP75        *prometheus.GaugeVec
+	mP90        *prometheus.GaugeVec
+	mP95        *prometheus.GaugeVec
+	mP99        *prometheus.GaugeVec
+	mP99_99     *prometheus.GaugeVec
+	mOutofrange *prometheus.GaugeVec
+	derived     []gen.GeneratedDerived
+}
+
+func newStatsMetricsTopicsBatchsize(o *gen.GeneratedOptions, parentLabelNames []string) *statsMetricsTopicsBatchsize {
+	m := &statsMetricsTopicsBatchsize{}
+	m.labelNames = append(m.labelNames, parentLabelNames...)
+	m.mMin = o.NewGaugeVec("topics_batchsize", "Min", "Smallest value", m.labelNames)
+	m.mMax = o.NewGaugeVec("topics_batchsize", "Max", "Largest value", m.labelNames)
+	m.mAvg = o.NewGaugeVec("topics_batchsize", "Avg", "Average value", m.labelNames)
+	m.mSum = o.NewGaugeVec("topics_batchsize", "Sum", "Sum of values", m.labelNames)
+	m.mCnt = o.NewGaugeVec("topics_batchsize", "Cnt", "Number of values sampled", m.labelNames)
+	m.mStddev = o.NewGaugeVec("topics_batchsize", "Stddev", "Standard deviation (based on histogram)", m.labelNames)
+	m.mHdrsize = o.NewGaugeVec("topics_batchsize", "Hdrsize", "Memory size of Hdr Histogram", m.labelNames)
+	m.mP50 = o.NewGaugeVec("topics_batchsize", "P50", "50th percentile", m.labelNames)
+	m.mP75 = o.NewGaugeVec("topics_batchsize", "P75", "75th percentile", m.labelNames)
+	m.mP90 = o.NewGaugeVec("topics_batchsize", "P90", "90th percentile", m.labelNames)
+	m.mP95 = o.NewGaugeVec("topics_batchsize", "P95", "95th percentile", m.labelNames)
+	m.mP99 = o.NewGaugeVec("topics_batchsize", "P99", "99th percentile", m.labelNames)
+	m.mP99_99 = o.NewGaugeVec("topics_batchsize", "P99_99", "99.99th percentile", m.labelNames)
+	m.mOutofrange = o.NewGaugeVec("topics_batchsize", "Outofrange", "Values skipped due to out of histogram range", m.labelNames)
+	m.derived = o.NewDerived("topics_batchsize", "Stats.Topics[].Batchsize", reflect.TypeOf(typed.WindowStats{}), m.labelNames)
+	return m
+}
+
+// share returns a copy of m computing filters and derived metrics with
+// the functions of o
+func (m *statsMetricsTopicsBatchsize) share(o *gen.GeneratedOptions) *statsMetricsTopicsBatchsize {
+	c := *m
+	c.derived = o.ShareDerived(m.derived, "Stats.Topics[].Batchsize")
+	return &c
+}
+
+func (m *statsMetricsTopicsBatchsize) describe(ch chan<- *prometheus.Desc) {
+	m.mMin.Describe(ch)
+	m.mMax.Describe(ch)
+	m.mAvg.Describe(ch)
+	m.mSum.Describe(ch)
+	m.mCnt.Describe(ch)
+	m.mStddev.Describe(ch)
+	m.mHdrsize.Describe(ch)
+	m.mP50.Describe(ch)
+	m.mP75.Describe(ch)
+	m.mP90.Describe(ch)
+	m.mP95.Describe(ch)
+	m.mP99.Describe(ch)
+	m.mP99_99.Describe(ch)
+	m.mOutofrange.Describe(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Describe(ch)
+	}
+}
+
+func (m *statsMetricsTopicsBatchsize) collect(ch chan<- prometheus.Metric) {
+	m.mMin.Collect(ch)
+	m.mMax.Collect(ch)
+	m.mAvg.Collect(ch)
+	m.mSum.Collect(ch)
+	m.mCnt.Collect(ch)
+	m.mStddev.Collect(ch)
+	m.mHdrsize.Collect(ch)
+	m.mP50.Collect(ch)
+	m.mP75.Collect(ch)
+	m.mP90.Collect(ch)
+	m.mP95.Collect(ch)
+	m.mP99.Collect(ch)
+	m.mP99_99.Collect(ch)
+	m.mOutofrange.Collect(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Collect(ch)
+	}
+}
+
+// delete removes the series with labels ls of this prefix
+func (m *statsMetricsTopicsBatchsize) delete(ls prometheus.Labels) {
+	m.mMin.Delete(ls)
+	m.mMax.Delete(ls)
+	m.mAvg.Delete(ls)
+	m.mSum.Delete(ls)
+	m.mCnt.Delete(ls)
+	m.mStddev.Delete(ls)
+	m.mHdrsize.Delete(ls)
+	m.mP50.Delete(ls)
+	m.mP75.Delete(ls)
+	m.mP90.Delete(ls)
+	m.mP95.Delete(ls)
+	m.mP99.Delete(ls)
+	m.mP99_99.Delete(ls)
+	m.mOutofrange.Delete(ls)
+	for i := range m.derived {
+		m.derived[i].Vec.Delete(ls)
+	}
+}
+
+type statsStateTopicsBatchsize struct {
+	labels prometheus.Labels
+	// Children of the metrics bound to labels
+	gauges  [14]prometheus.Gauge
+	derived []prometheus.Gauge
+}
+
+// equalLabels reports whether the labels of v and parent did not change
+func (s *statsStateTopicsBatchsize) equalLabels(m *statsMetricsTopicsBatchsize, v *typed.WindowStats, parent prometheus.Labels) bool {
+	if s.labels == nil || len(s.labels) != len(parent)+0 || !gen.ContainsLabels(s.labels, parent) {
+		return false
+	}
+	return true
+}
+
+// values updates the metrics of this prefix and returns the labels of v
+func (s *statsStateTopicsBatchsize) values(m *statsMetricsTopicsBatchsize, v *typed.WindowStats, parent prometheus.Labels) prometheus.Labels {
+	if !s.equalLabels(m, v, parent) {
+		ls := make(prometheus.Labels, len(parent)+0)
+		for k, lv := range parent {
+			ls[k] = lv
+		}
+		// Series of outdated labels are dropped
+		if s.labels != nil {
+			m.delete(s.labels)
+		}
+		s.labels = gen.CloneLabels(ls)
+		s.gauges[0] = m.mMin.With(s.labels)
+		s.gauges[1] = m.mMax.With(s.labels)
+		s.gauges[2] = m.mAvg.With(s.labels)
+		s.gauges[3] = m.mSum.With(s.labels)
+		s.gauges[4] = m.mCnt.With(s.labels)
+		s.gauges[5] = m.mStddev.With(s.labels)
+		s.gauges[6] = m.mHdrsize.With(s.labels)
+		s.gauges[7] = m.mP50.With(s.labels)
+		s.gauges[8] = m.mP75.With(s.labels)
+		s.gauges[9] = m.mP90.With(s.labels)
+		s.gauges[10] = m.mP95.With(s.labels)
+		s.gauges[11] = m.mP99.With(s.labels)
+		s.gauges[12] = m.mP99_99.With(s.labels)
+		s.gauges[13] = m.mOutofrange.With(s.labels)
+		s.derived = nil
+	}
+	s.gauges[0].Set(float64(v.Min))
+	s.gauges[1].Set(float64(v.Max))
+	s.gauges[2].Set(float64(v.Avg))
+	s.gauges[3].Set(float64(v.Sum))
+	s.gauges[4].Set(float64(v.Cnt))
+	s.gauges[5].Set(float64(v.Stddev))
+	s.gauges[6].Set(float64(v.Hdrsize))
+	s.gauges[7].Set(float64(v.P50))
+	s.gauges[8].Set(float64(v.P75))
+	s.gauges[9].Set(float64(v.P90))
+	s.gauges[10].Set(float64(v.P95))
+	s.gauges[11].Set(float64(v.P99))
+	s.gauges[12].Set(float64(v.P99_99))
+	s.gauges[13].Set(float64(v.Outofrange))
+	return s.labels
+}
+
+// derive updates the derived metrics with the complete value v. Their
+// series are created on the first call after a change of labels.
+func (s *statsStateTopicsBatchsize) derive(m *statsMetricsTopicsBatchsize, v *typed.WindowStats) {
+	if s.derived == nil && len(m.derived) != 0 {
+		s.derived = make([]prometheus.Gauge, len(m.derived))
+		for i := range m.derived {
+			s.derived[i] = m.derived[i].Vec.With(s.labels)
+		}
+	}
+	for i, d := range s.derived {
+		d.Set(m.derived[i].Fun(v))
+	}
+}
+
+func (s *statsStateTopicsBatchsize) update(m *statsMetricsTopicsBatchsize, v *typed.WindowStats, parent prometheus.Labels) {
+	s.values(m, v, parent)
+	s.derive(m, v)
+}
+
+// statsRawTopicsBatchsize holds the decoded fields of a JSON value. Maps and nested
+// structs are held by the raw values they contain.
+type statsRawTopicsBatchsize struct {
+	v typed.WindowStats
+}
+
+func (r *statsRawTopicsBatchsize) decode(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		var err error
+		switch o.Key() {
+		case "min":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Min = int(x)
+		case "max":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Max = int(x)
+		case "avg":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Avg = int(x)
+		case "sum":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Sum = int(x)
+		case "cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Cnt = int(x)
+		case "stddev":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Stddev = int(x)
+		case "hdrsize":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Hdrsize = int(x)
+		case "p50":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P50 = int(x)
+		case "p75":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P75 = int(x)
+		case "p90":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P90 = int(x)
+		case "p95":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P95 = int(x)
+		case "p99":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P99 = int(x)
+		case "p99_99":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P99_99 = int(x)
+		case "outofrange":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Outofrange = int(x)
+		}
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+// filter marks the entries of maps skipped by the filters of m. Fails
+// if an entry to filter cannot be decoded completely.
+func (r *statsRawTopicsBatchsize) filter(m *statsMetricsTopicsBatchsize) error {
+	return nil
+}
+
+func (s *statsStateTopicsBatchsize) stream(m *statsMetricsTopicsBatchsize, r *statsRawTopicsBatchsize, parent prometheus.Labels) {
+	s.values(m, &r.v, parent)
+}
+
+func (s *statsStateTopicsBatchsize) count(m *statsMetricsTopicsBatchsize, entries map[string]int) int {
+	if s.labels == nil {
+		return 0
+	}
+	series := 14 + len(s.derived)
+	return series
+}
+
+// delete removes all series of this value and the values it contains
+func (s *statsStateTopicsBatchsize) delete(m *statsMetricsTopicsBatchsize) {
+	if s.labels != nil {
+		m.delete(s.labels)
+	}
+}
+
+type statsMetricsTopicsBatchcnt struct {
+	labelNames  []string
+	mMin        *prometheus.GaugeVec
+	mMax        *prometheus.GaugeVec
+	mAvg        *prometheus.GaugeVec
+	mSum        *prometheus.GaugeVec
+	mCnt        *prometheus.GaugeVec
+	mStddev     *prometheus.GaugeVec
+	mHdrsize    *prometheus.GaugeVec
+	mP50        *prometheus.GaugeVec
+	mP75        *prometheus.GaugeVec
+	mP90        *prometheus.GaugeVec
+	mP95        *prometheus.GaugeVec
+	mP99        *prometheus.GaugeVec
+	mP99_99     *prometheus.GaugeVec
+	mOutofrange *prometheus.GaugeVec
+	derived     []gen.GeneratedDerived
+}
+
+func newStatsMetricsTopicsBatchcnt(o *gen.GeneratedOptions, parentLabelNames []string) *statsMetricsTopicsBatchcnt {
+	m := &statsMetricsTopicsBatchcnt{}
+	m.labelNames = append(m.labelNames, parentLabelNames...)
+	m.mMin = o.NewGaugeVec("topics_batchcnt", "Min", "Smallest value", m.labelNames)
+	m.mMax = o.NewGaugeVec("topics_batchcnt", "Max", "Largest value", m.labelNames)
+	m.mAvg = o.NewGaugeVec("topics_batchcnt", "Avg", "Average value", m.labelNames)
+	m.mSum = o.NewGaugeVec("topics_batchcnt", "Sum", "Sum of values", m.labelNames)
+	m.mCnt = o.NewGaugeVec("topics_batchcnt", "Cnt", "Number of values sampled", m.labelNames)
+	m.mStddev = o.NewGaugeVec("topics_batchcnt", "Stddev", "Standard deviation (based on histogram)", m.labelNames)
+	m.mHdrsize = o.NewGaugeVec("topics_batchcnt", "Hdrsize", "Memory size of Hdr Histogram", m.labelNames)
+	m.mP50 = o.NewGaugeVec("topics_batchcnt", "P50", "50th percentile", m.labelNames)
+	m.mP75 = o.NewGaugeVec("topics_batchcnt", "P75", "75th percentile", m.labelNames)
+	m.mP90 = o.NewGaugeVec("topics_batchcnt", "P90", "90th percentile", m.labelNames)
+	m.mP95 = o.NewGaugeVec("topics_batchcnt", "P95", "95th percentile", m.labelNames)
+	m.mP99 = o.NewGaugeVec("topics_batchcnt", "P99", "99th percentile", m.labelNames)
+	m.mP99_99 = o.NewGaugeVec("topics_batchcnt", "P99_99", "99.99th percentile", m.labelNames)
+	m.mOutofrange = o.NewGaugeVec("topics_batchcnt", "Outofrange", "Values skipped due to out of histogram range", m.labelNames)
+	m.derived = o.NewDerived("topics_batchcnt", "Stats.Topics[].Batchcnt", reflect.TypeOf(typed.WindowStats{}), m.labelNames)
+	return m
+}
+
+// share returns a copy of m computing filters and derived metrics with
+// the functions of o
+func (m *statsMetricsTopicsBatchcnt) share(o *gen.GeneratedOptions) *statsMetricsTopicsBatchcnt {
+	c := *m
+	c.derived = o.ShareDerived(m.derived, "Stats.Topics[].Batchcnt")
+	return &c
+}
+
+func (m *statsMetricsTopicsBatchcnt) describe(ch chan<- *prometheus.Desc) {
+	m.mMin.Describe(ch)
+	m.mMax.Describe(ch)
+	m.mAvg.Describe(ch)
+	m.mSum.Describe(ch)
+	m.mCnt.Describe(ch)
+	m.mStddev.Describe(ch)
+	m.mHdrsize.Describe(ch)
+	m.mP50.Describe(ch)
+	m.mP75.Describe(ch)
+	m.mP90.Describe(ch)
+	m.mP95.Describe(ch)
+	m.mP99.Describe(ch)
+	m.mP99_99.Describe(ch)
+	m.mOutofrange.Describe(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Describe(ch)
+	}
+}
+
+func (m *statsMetricsTopicsBatchcnt) collect(ch chan<- prometheus.Metric) {
+	m.mMin.Collect(ch)
+	m.mMax.Collect(ch)
+	m.mAvg.Collect(ch)
+	m.mSum.Collect(ch)
+	m.mCnt.Collect(ch)
+	m.mStddev.Collect(ch)
+	m.mHdrsize.Collect(ch)
+	m.mP50.Collect(ch)
+	m.mP75.Collect(ch)
+	m.mP90.Collect(ch)
+	m.mP95.Collect(ch)
+	m.mP99.Collect(ch)
+	m.mP99_99.Collect(ch)
+	m.mOutofrange.Collect(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Collect(ch)
+	}
+}
+
+// delete removes the series with labels ls of this prefix
+func (m *statsMetricsTopicsBatchcnt) delete(ls prometheus.Labels) {
+	m.mMin.Delete(ls)
+	m.mMax.Delete(ls)
+	m.mAvg.Delete(ls)
+	m.mSum.Delete(ls)
+	m.mCnt.Delete(ls)
+	m.mStddev.Delete(ls)
+	m.mHdrsize.Delete(ls)
+	m.mP50.Delete(ls)
+	m.mP75.Delete(ls)
+	m.mP90.Delete(ls)
+	m.mP95.Delete(ls)
+	m.mP99.Delete(ls)
+	m.mP99_99.Delete(ls)
+	m.mOutofrange.Delete(ls)
+	for i := range m.derived {
+		m.derived[i].Vec.Delete(ls)
+	}
+}
+
+type statsStateTopicsBatchcnt struct {
+	labels prometheus.Labels
+	// Children of the metrics bound to labels
+	gauges  [14]prometheus.Gauge
+	derived []prometheus.Gauge
+}
+
+// equalLabels reports whether the labels of v and parent did not change
+func (s *statsStateTopicsBatchcnt) equalLabels(m *statsMetricsTopicsBatchcnt, v *typed.WindowStats, parent prometheus.Labels) bool {
+	if s.labels == nil || len(s.labels) != len(parent)+0 || !gen.ContainsLabels(s.labels, parent) {
+		return false
+	}
+	return true
+}
+
+// values updates the metrics of this prefix and returns the labels of v
+func (s *statsStateTopicsBatchcnt) values(m *statsMetricsTopicsBatchcnt, v *typed.WindowStats, parent prometheus.Labels) prometheus.Labels {
+	if !s.equalLabels(m, v, parent) {
+		ls := make(prometheus.Labels, len(parent)+0)
+		for k, lv := range parent {
+			ls[k] = lv
+		}
+		// Series of outdated labels are dropped
+		if s.labels != nil {
+			m.delete(s.labels)
+		}
+		s.labels = gen.CloneLabels(ls)
+		s.gauges[0] = m.mMin.With(s.labels)
+		s.gauges[1] = m.mMax.With(s.labels)
+		s.gauges[2] = m.mAvg.With(s.labels)
+		s.gauges[3] = m.mSum.With(s.labels)
+		s.gauges[4] = m.mCnt.With(s.labels)
+		s.gauges[5] = m.mStddev.With(s.labels)
+		s.gauges[6] = m.mHdrsize.With(s.labels)
+		s.gauges[7] = m.mP50.With(s.labels)
+		s.gauges[8] = m.mP75.With(s.labels)
+		s.gauges[9] = m.mP90.With(s.labels)
+		s.gauges[10] = m.mP95.With(s.labels)
+		s.gauges[11] = m.mP99.With(s.labels)
+		s.gauges[12] = m.mP99_99.With(s.labels)
+		s.gauges[13] = m.mOutofrange.With(s.labels)
+		s.derived = nil
+	}
+	s.gauges[0].Set(float64(v.Min))
+	s.gauges[1].Set(float64(v.Max))
+	s.gauges[2].Set(float64(v.Avg))
+	s.gauges[3].Set(float64(v.Sum))
+	s.gauges[4].Set(float64(v.Cnt))
+	s.gauges[5].Set(float64(v.Stddev))
+	s.gauges[6].Set(float64(v.Hdrsize))
+	s.gauges[7].Set(float64(v.P50))
+	s.gauges[8].Set(float64(v.P75))
+	s.gauges[9].Set(float64(v.P90))
+	s.gauges[10].Set(float64(v.P95))
+	s.gauges[11].Set(float64(v.P99))
+	s.gauges[12].Set(float64(v.P99_99))
+	s.gauges[13].Set(float64(v.Outofrange))
+	return s.labels
+}
+
+// derive updates the derived metrics with the complete value v. Their
+// series are created on the first call after a change of labels.
+func (s *statsStateTopicsBatchcnt) derive(m *statsMetricsTopicsBatchcnt, v *typed.WindowStats) {
+	if s.derived == nil && len(m.derived) != 0 {
+		s.derived = make([]prometheus.Gauge, len(m.derived))
+		for i := range m.derived {
+			s.derived[i] = m.derived[i].Vec.With(s.labels)
+		}
+	}
+	for i, d := range s.derived {
+		d.Set(m.derived[i].Fun(v))
+	}
+}
+
+func (s *statsStateTopicsBatchcnt) update(m *statsMetricsTopicsBatchcnt, v *typed.WindowStats, parent prometheus.Labels) {
+	s.values(m, v, parent)
+	s.derive(m, v)
+}
+
+// statsRawTopicsBatchcnt holds the decoded fields of a JSON value. Maps and nested
+// structs are held by the raw values they contain.
+type statsRawTopicsBatchcnt struct {
+	v typed.WindowStats
+}
+
+func (r *statsRawTopicsBatchcnt) decode(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		var err error
+		switch o.Key() {
+		case "min":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Min = int(x)
+		case "max":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Max = int(x)
+		case "avg":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Avg = int(x)
+		case "sum":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Sum = int(x)
+		case "cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Cnt = int(x)
+		case "stddev":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Stddev = int(x)
+		case "hdrsize":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Hdrsize = int(x)
+		case "p50":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P50 = int(x)
+		case "p75":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P75 = int(x)
+		case "p90":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P90 = int(x)
+		case "p95":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P95 = int(x)
+		case "p99":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P99 = int(x)
+		case "p99_99":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.P99_99 = int(x)
+		case "outofrange":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Outofrange = int(x)
+		}
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+// filter marks the entries of maps skipped by the filters of m. Fails
+// if an entry to filter cannot be decoded completely.
+func (r *statsRawTopicsBatchcnt) filter(m *statsMetricsTopicsBatchcnt) error {
+	return nil
+}
+
+func (s *statsStateTopicsBatchcnt) stream(m *statsMetricsTopicsBatchcnt, r *statsRawTopicsBatchcnt, parent prometheus.Labels) {
+	s.values(m, &r.v, parent)
+}
+
+func (s *statsStateTopicsBatchcnt) count(m *statsMetricsTopicsBatchcnt, entries map[string]int) int {
+	if s.labels == nil {
+		return 0
+	}
+	series := 14 + len(s.derived)
+	return series
+}
+
+// delete removes all series of this value and the values it contains
+func (s *statsStateTopicsBatchcnt) delete(m *statsMetricsTopicsBatchcnt) {
+	if s.labels != nil {
+		m.delete(s.labels)
+	}
+}
+
+type statsMetricsTopicsPartitions struct {
+	labelNames         []string
+	lPartition         string
+	lBroker            string
+	lLeader            string
+	lFetchState        string
+	mMsgqCnt           *prometheus.GaugeVec
+	mMsgqBytes         *prometheus.GaugeVec
+	mXmitMsgqCnt       *prometheus.GaugeVec
+	mXmitMsgqBytes     *prometheus.GaugeVec
+	mFetchqCnt         *prometheus.GaugeVec
+	mFetchqSize        *prometheus.GaugeVec
+	mQueryOffset       *prometheus.GaugeVec
+	mNextOffset        *prometheus.GaugeVec
+	mAppOffset         *prometheus.GaugeVec
+	mStoredOffset      *prometheus.GaugeVec
+	mCommittedOffset   *prometheus.GaugeVec
+	mEofOffset         *prometheus.GaugeVec
+	mLoOffset          *prometheus.GaugeVec
+	mHiOffset          *prometheus.GaugeVec
+	mLsOffset          *prometheus.GaugeVec
+	mConsumerLag       *prometheus.GaugeVec
+	mConsumerLagStored *prometheus.GaugeVec
+	mTxmsgs            *prometheus.CounterVec
+	mTxbytes           *prometheus.CounterVec
+	mRxmsgs            *prometheus.CounterVec
+	mRxbytes           *prometheus.CounterVec
+	mMsgs              *prometheus.CounterVec
+	mRxVerDrops        *prometheus.CounterVec
+	mMsgsInflight      *prometheus.GaugeVec
+	mNextAckSeq        *prometheus.GaugeVec
+	mNextErrSeq        *prometheus.GaugeVec
+	derived            []gen.GeneratedDerived
+}
+
+func newStatsMetricsTopicsPartitions(o *gen.GeneratedOptions, parentLabelNames []string) *statsMetricsTopicsPartitions {
+	m := &statsMetricsTopicsPartitions{}
+	m.labelNames = append(m.labelNames, parentLabelNames...)
+	m.lPartition = o.LabelName("topics_partitions", "partition")
+	m.labelNames = append(m.labelNames, m.lPartition)
+	m.lBroker = o.LabelName("topics_partitions", "broker")
+	m.labelNames = append(m.labelNames, m.lBroker)
+	m.lLeader = o.LabelName("topics_partitions", "leader")
+	m.labelNames = append(m.labelNames, m.lLeader)
+	m.lFetchState = o.LabelName("topics_partitions", "fetch_state")
+	m.labelNames = append(m.labelNames, m.lFetchState)
+	m.mMsgqCnt = o.NewGaugeVec("topics_partitions", "MsgqCnt", "Number of messages waiting to be produced in first-level queue", m.labelNames)
+	m.mMsgqBytes = o.NewGaugeVec("topics_partitions", "MsgqBytes", "Number of bytes in msgq_cnt", m.labelNames)
+	m.mXmitMsgqCnt = o.NewGaugeVec("topics_partitions", "XmitMsgqCnt", "Number of messages ready to be produced in transmit queue", m.labelNames)
+	m.mXmitMsgqBytes = o.NewGaugeVec("topics_partitions", "XmitMsgqBytes", "Number of bytes in xmit_msgq", m.labelNames)
+	m.mFetchqCnt = o.NewGaugeVec("topics_partitions", "FetchqCnt", "Number of pre-fetched messages in fetch queue", m.labelNames)
+	m.mFetchqSize = o.NewGaugeVec("topics_partitions", "FetchqSize", "Bytes in fetchq", m.labelNames)
+	m.mQueryOffset = o.NewGaugeVec("topics_partitions", "QueryOffset", "Current/Last logical offset query", m.labelNames)
+	m.mNextOffset = o.NewGaugeVec("topics_partitions", "NextOffset", "Next offset to fetch", m.labelNames)
+	m.mAppOffset = o.NewGaugeVec("topics_partitions", "AppOffset", "Offset of last message passed to application   1", m.labelNames)
+	m.mStoredOffset = o.NewGaugeVec("topics_partitions", "StoredOffset", "Offset to be committed", m.labelNames)
+	m.mCommittedOffset = o.NewGaugeVec("topics_partitions", "CommittedOffset", "Last committed offset", m.labelNames)
+	m.mEofOffset = o.NewGaugeVec("topics_partitions", "EofOffset", "Last PARTITION_EOF signaled offset", m.labelNames)
+	m.mLoOffset = o.NewGaugeVec("topics_partitions", "LoOffset", "Partition's low watermark offset on broker", m.labelNames)
+	m.mHiOffset = o.NewGaugeVec("topics_partitions", "HiOffset", "Partition's high watermark offset on broker", m.labelNames)
+	m.mLsOffset = o.NewGaugeVec("topics_partitions", "LsOffset", "Partition's last stable offset on broker, or same as hi_offset is broker version is less than 0.11.0.0.", m.labelNames)
+	m.mConsumerLag = o.NewGaugeVec("topics_partitions", "ConsumerLag", "Difference between (hi_offset or ls_offset) and committed_offset). hi_offset is used when isolation.level=read_uncommitted, otherwise ls_offset.", m.labelNames)
+	m.mConsumerLagStored = o.NewGaugeVec("topics_partitions", "ConsumerLagStored", "Difference between (hi_offset or ls_offset) and stored_offset. See consumer_lag and stored_offset.", m.labelNames)
+	m.mTxmsgs = o.NewCounterVec("topics_partitions", "Txmsgs", "Total number of messages transmitted (produced)", m.labelNames)
+	m.mTxbytes = o.NewCounterVec("topics_partitions", "Txbytes", "Total number of bytes transmitted for txmsgs", m.labelNames)
+	m.mRxmsgs = o.NewCounterVec("topics_partitions", "Rxmsgs", "Total number of messages consumed, not including ignored messages (due to offset, etc).", m.labelNames)
+	m.mRxbytes = o.NewCounterVec("topics_partitions", "Rxbytes", "Total number of bytes received for rxmsgs", m.labelNames)
+	m.mMsgs = o.NewCounterVec("topics_partitions", "Msgs", "Total number of messages received (consumer, same as rxmsgs), or total number of messages produced (possibly not yet transmitted) (producer).", m.labelNames)
+	m.mRxVerDrops = o.NewCounterVec("topics_partitions", "RxVerDrops", "Dropped outdated messages", m.labelNames)
+	m.mMsgsInflight = o.NewGaugeVec("topics_partitions", "MsgsInflight", "Current number of messages in-flight to/from broker", m.labelNames)
+	m.mNextAckSeq = o.NewGaugeVec("topics_partitions", "NextAckSeq", "Next expected acked sequence (idempotent producer)", m.labelNames)
+	m.mNextErrSeq = o.NewGaugeVec("topics_partitions", "NextErrSeq", "Next expected errored sequence (idempotent producer)", m.labelNames)
+	m.derived = o.NewDerived("topics_partitions", "Stats.Topics[].Partitions[]", reflect.TypeOf(typed.PartitionStats{}), m.labelNames)
+	return m
+}
+
+// share returns a copy of m computing filters and derived metrics with
+// the functions of o
+func (m *statsMetricsTopicsPartitions) share(o *gen.GeneratedOptions) *statsMetricsTopicsPartitions {
+	c := *m
+	c.derived = o.ShareDerived(m.derived, "Stats.Topics[].Partitions[]")
+	return &c
+}
+
+func (m *statsMetricsTopicsPartitions) describe(ch chan<- *prometheus.Desc) {
+	m.mMsgqCnt.Describe(ch)
+	m.mMsgqBytes.Describe(ch)
+	m.mXmitMsgqCnt.Describe(ch)
+	m.mXmitMsgqBytes.Describe(ch)
+	m.mFetchqCnt.Describe(ch)
+	m.mFetchqSize.Describe(ch)
+	m.mQueryOffset.Describe(ch)
+	m.mNextOffset.Describe(ch)
+	m.mAppOffset.Describe(ch)
+	m.mStoredOffset.Describe(ch)
+	m.mCommittedOffset.Describe(ch)
+	m.mEofOffset.Describe(ch)
+	m.mLoOffset.Describe(ch)
+	m.mHiOffset.Describe(ch)
+	m.mLsOffset.Describe(ch)
+	m.mConsumerLag.Describe(ch)
+	m.mConsumerLagStored.Describe(ch)
+	m.mTxmsgs.Describe(ch)
+	m.mTxbytes.Describe(ch)
+	m.mRxmsgs.Describe(ch)
+	m.mRxbytes.Describe(ch)
+	m.mMsgs.Describe(ch)
+	m.mRxVerDrops.Describe(ch)
+	m.mMsgsInflight.Describe(ch)
+	m.mNextAckSeq.Describe(ch)
+	m.mNextErrSeq.Describe(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Describe(ch)
+	}
+}
+
+func (m *statsMetricsTopicsPartitions) collect(ch chan<- prometheus.Metric) {
+	m.mMsgqCnt.Collect(ch)
+	m.mMsgqBytes.Collect(ch)
+	m.mXmitMsgqCnt.Collect(ch)
+	m.mXmitMsgqBytes.Collect(ch)
+	m.mFetchqCnt.Collect(ch)
+	m.mFetchqSize.Collect(ch)
+	m.mQueryOffset.Collect(ch)
+	m.mNextOffset.Collect(ch)
+	m.mAppOffset.Collect(ch)
+	m.mStoredOffset.Collect(ch)
+	m.mCommittedOffset.Collect(ch)
+	m.mEofOffset.Collect(ch)
+	m.mLoOffset.Collect(ch)
+	m.mHiOffset.Collect(ch)
+	m.mLsOffset.Collect(ch)
+	m.mConsumerLag.Collect(ch)
+	m.mConsumerLagStored.Collect(ch)
+	m.mTxmsgs.Collect(ch)
+	m.mTxbytes.Collect(ch)
+	m.mRxmsgs.Collect(ch)
+	m.mRxbytes.Collect(ch)
+	m.mMsgs.Collect(ch)
+	m.mRxVerDrops.Collect(ch)
+	m.mMsgsInflight.Collect(ch)
+	m.mNextAckSeq.Collect(ch)
+	m.mNextErrSeq.Collect(ch)
+	for i := range m.derived {
+		m.derived[i].Vec.Collect(ch)
+	}
+}
+
+// delete removes the series with labels ls of this prefix
+func (m *statsMetricsTopicsPartitions) delete(ls prometheus.Labels) {
+	m.mMsgqCnt.Delete(ls)
+	m.mMsgqBytes.Delete(ls)
+	m.mXmitMsgqCnt.Delete(ls)
+	m.mXmitMsgqBytes.Delete(ls)
+	m.mFetchqCnt.Delete(ls)
+	m.mFetchqSize.Delete(ls)
+	m.mQueryOffset.Delete(ls)
+	m.mNextOffset.Delete(ls)
+	m.mAppOffset.Delete(ls)
+	m.mStoredOffset.Delete(ls)
+	m.mCommittedOffset.Delete(ls)
+	m.mEofOffset.Delete(ls)
+	m.mLoOffset.Delete(ls)
+	m.mHiOffset.Delete(ls)
+	m.mLsOffset.Delete(ls)
+	m.mConsumerLag.Delete(ls)
+	m.mConsumerLagStored.Delete(ls)
+	m.mTxmsgs.Delete(ls)
+	m.mTxbytes.Delete(ls)
+	m.mRxmsgs.Delete(ls)
+	m.mRxbytes.Delete(ls)
+	m.mMsgs.Delete(ls)
+	m.mRxVerDrops.Delete(ls)
+	m.mMsgsInflight.Delete(ls)
+	m.mNextAckSeq.Delete(ls)
+	m.mNextErrSeq.Delete(ls)
+	for i := range m.derived {
+		m.derived[i].Vec.Delete(ls)
+	}
+}
+
+type statsStateTopicsPartitions struct {
+	labels prometheus.Labels
+	epoch  uint64 // Of last streaming update
+	last   [6]int64
+	// Children of the metrics bound to labels
+	counters [6]prometheus.Counter
+	gauges   [20]prometheus.Gauge
+	derived  []prometheus.Gauge
+}
+
+// equalLabels reports whether the labels of v and parent did not change
+func (s *statsStateTopicsPartitions) equalLabels(m *statsMetricsTopicsPartitions, v *typed.PartitionStats, parent prometheus.Labels) bool {
+	if s.labels == nil || len(s.labels) != len(parent)+4 || !gen.ContainsLabels(s.labels, parent) {
+		return false
+	}
+	return true &&
+		gen.EqualIntLabel(s.labels[m.lPartition], int64(v.Partition)) &&
+		gen.EqualIntLabel(s.labels[m.lBroker], int64(v.Broker)) &&
+		gen.EqualIntLabel(s.labels[m.lLeader], int64(v.Leader)) &&
+		s.labels[m.lFetchState] == v.FetchState
+}
+
+// values updates the metrics of this prefix and returns the labels of v
+func (s *statsStateTopicsPartitions) values(m *statsMetricsTopicsPartitions, v *typed.PartitionStats, parent prometheus.Labels) prometheus.Labels {
+	if !s.equalLabels(m, v, parent) {
+		ls := make(prometheus.Labels, len(parent)+4)
+		for k, lv := range parent {
+			ls[k] = lv
+		}
+		ls[m.lPartition] = strconv.FormatInt(int64(v.Partition), 10)
+		ls[m.lBroker] = strconv.FormatInt(int64(v.Broker), 10)
+		ls[m.lLeader] = strconv.FormatInt(int64(v.Leader), 10)
+		ls[m.lFetchState] = v.FetchState
+		// Series of outdated labels are dropped
+		if s.labels != nil {
+			m.delete(s.labels)
+		}
+		s.labels = gen.CloneLabels(ls)
+		s.last = [6]int64{}
+		s.gauges[0] = m.mMsgqCnt.With(s.labels)
+		s.gauges[1] = m.mMsgqBytes.With(s.labels)
+		s.gauges[2] = m.mXmitMsgqCnt.With(s.labels)
+		s.gauges[3] = m.mXmitMsgqBytes.With(s.labels)
+		s.gauges[4] = m.mFetchqCnt.With(s.labels)
+		s.gauges[5] = m.mFetchqSize.With(s.labels)
+		s.gauges[6] = m.mQueryOffset.With(s.labels)
+		s.gauges[7] = m.mNextOffset.With(s.labels)
+		s.gauges[8] = m.mAppOffset.With(s.labels)
+		s.gauges[9] = m.mStoredOffset.With(s.labels)
+		s.gauges[10] = m.mCommittedOffset.With(s.labels)
+		s.gauges[11] = m.mEofOffset.With(s.labels)
+		s.gauges[12] = m.mLoOffset.With(s.labels)
+		s.gauges[13] = m.mHiOffset.With(s.labels)
+		s.gauges[14] = m.mLsOffset.With(s.labels)
+		s.gauges[15] = m.mConsumerLag.With(s.labels)
+		s.gauges[16] = m.mConsumerLagStored.With(s.labels)
+		s.counters[0] = m.mTxmsgs.With(s.labels)
+		s.counters[1] = m.mTxbytes.With(s.labels)
+		s.counters[2] = m.mRxmsgs.With(s.labels)
+		s.counters[3] = m.mRxbytes.With(s.labels)
+		s.counters[4] = m.mMsgs.With(s.labels)
+		s.counters[5] = m.mRxVerDrops.With(s.labels)
+		s.gauges[17] = m.mMsgsInflight.With(s.labels)
+		s.gauges[18] = m.mNextAckSeq.With(s.labels)
+		s.gauges[19] = m.mNextErrSeq.With(s.labels)
+		s.derived = nil
+	}
+	s.gauges[0].Set(float64(v.MsgqCnt))
+	s.gauges[1].Set(float64(v.MsgqBytes))
+	s.gauges[2].Set(float64(v.XmitMsgqCnt))
+	s.gauges[3].Set(float64(v.XmitMsgqBytes))
+	s.gauges[4].Set(float64(v.FetchqCnt))
+	s.gauges[5].Set(float64(v.FetchqSize))
+	s.gauges[6].Set(float64(v.QueryOffset))
+	s.gauges[7].Set(float64(v.NextOffset))
+	s.gauges[8].Set(float64(v.AppOffset))
+	s.gauges[9].Set(float64(v.StoredOffset))
+	s.gauges[10].Set(float64(v.CommittedOffset))
+	s.gauges[11].Set(float64(v.EofOffset))
+	s.gauges[12].Set(float64(v.LoOffset))
+	s.gauges[13].Set(float64(v.HiOffset))
+	s.gauges[14].Set(float64(v.LsOffset))
+	s.gauges[15].Set(float64(v.ConsumerLag))
+	s.gauges[16].Set(float64(v.ConsumerLagStored))
+	s.last[0] = gen.AddToCounter(s.counters[0], s.last[0], int64(v.Txmsgs))
+	s.last[1] = gen.AddToCounter(s.counters[1], s.last[1], int64(v.Txbytes))
+	s.last[2] = gen.AddToCounter(s.counters[2], s.last[2], int64(v.Rxmsgs))
+	s.last[3] = gen.AddToCounter(s.counters[3], s.last[3], int64(v.Rxbytes))
+	s.last[4] = gen.AddToCounter(s.counters[4], s.last[4], int64(v.Msgs))
+	s.last[5] = gen.AddToCounter(s.counters[5], s.last[5], int64(v.RxVerDrops))
+	s.gauges[17].Set(float64(v.MsgsInflight))
+	s.gauges[18].Set(float64(v.NextAckSeq))
+	s.gauges[19].Set(float64(v.NextErrSeq))
+	return s.labels
+}
+
+// derive updates the derived metrics with the complete value v. Their
+// series are created on the first call after a change of labels.
+func (s *statsStateTopicsPartitions) derive(m *statsMetricsTopicsPartitions, v *typed.PartitionStats) {
+	if s.derived == nil && len(m.derived) != 0 {
+		s.derived = make([]prometheus.Gauge, len(m.derived))
+		for i := range m.derived {
+			s.derived[i] = m.derived[i].Vec.With(s.labels)
+		}
+	}
+	for i, d := range s.derived {
+		d.Set(m.derived[i].Fun(v))
+	}
+}
+
+func (s *statsStateTopicsPartitions) update(m *statsMetricsTopicsPartitions, v *typed.PartitionStats, parent prometheus.Labels) {
+	s.values(m, v, parent)
+	s.derive(m, v)
+}
+
+// statsRawTopicsPartitions holds the decoded fields of a JSON value. Maps and nested
+// structs are held by the raw values they contain.
+type statsRawTopicsPartitions struct {
+	v typed.PartitionStats
+}
+
+func (r *statsRawTopicsPartitions) decode(data string) error {
+	o := gen.NewJSONObject(data)
+	for o.Next() {
+		var err error
+		switch o.Key() {
+		case "partition":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Partition = int(x)
+		case "broker":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Broker = int(x)
+		case "leader":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Leader = int(x)
+		case "desired":
+			var x bool
+			x, err = gen.JSONBool(o.Value())
+			r.v.Desired = bool(x)
+		case "unknown":
+			var x bool
+			x, err = gen.JSONBool(o.Value())
+			r.v.Unknown = bool(x)
+		case "msgq_cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.MsgqCnt = int(x)
+		case "msgq_bytes":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.MsgqBytes = int(x)
+		case "xmit_msgq_cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.XmitMsgqCnt = int(x)
+		case "xmit_msgq_bytes":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.XmitMsgqBytes = int(x)
+		case "fetchq_cnt":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.FetchqCnt = int(x)
+		case "fetchq_size":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.FetchqSize = int(x)
+		case "fetch_state":
+			var x string
+			x, err = gen.JSONString(o.Value())
+			r.v.FetchState = string(x)
+		case "query_offset":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.QueryOffset = int(x)
+		case "next_offset":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.NextOffset = int(x)
+		case "app_offset":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.AppOffset = int(x)
+		case "stored_offset":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.StoredOffset = int(x)
+		case "committed_offset":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.CommittedOffset = int(x)
+		case "eof_offset":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.EofOffset = int(x)
+		case "lo_offset":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.LoOffset = int(x)
+		case "hi_offset":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.HiOffset = int(x)
+		case "ls_offset":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.LsOffset = int(x)
+		case "consumer_lag":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.ConsumerLag = int(x)
+		case "consumer_lag_stored":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.ConsumerLagStored = int(x)
+		case "txmsgs":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Txmsgs = int(x)
+		case "txbytes":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Txbytes = int(x)
+		case "rxmsgs":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Rxmsgs = int(x)
+		case "rxbytes":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Rxbytes = int(x)
+		case "msgs":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.Msgs = int(x)
+		case "rx_ver_drops":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.RxVerDrops = int(x)
+		case "msgs_inflight":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.MsgsInflight = int(x)
+		case "next_ack_seq":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.NextAckSeq = int(x)
+		case "next_err_seq":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.NextErrSeq = int(x)
+		case "acked_msgid":
+			var x int64
+			x, err = gen.JSONInt(o.Value())
+			r.v.AckedMsgid = int(x)
+		}
+		if err != nil {
+			return gen.JSONFieldError(o.Key(), err)
+		}
+	}
+	return o.Err()
+}
+
+// filter marks the entries of maps skipped by the filters of m. Fails
+// if an entry to filter cannot be decoded completely.
+func (r *statsRawTopicsPartitions) filter(m *statsMetricsTopicsPartitions) error {
+	return nil
+}
+
+func (s *statsStateTopicsPartitions) stream(m *statsMetricsTopicsPartitions, r *statsRawTopicsPartitions, parent prometheus.Labels) {
+	s.values(m, &r.v, parent)
+}
+
+func (s *statsStateTopicsPartitions) count(m *statsMetricsTopicsPartitions, entries map[string]int) int {
+	if s.labels == nil {
+		return 0
+	}
+	series := 26 + len(s.derived)
+	return series
+}
+
+// delete removes all series of this value and the values it contains
+func (s *statsStateTopicsPartitions) delete(m *statsMetricsTopicsPartitions) {
+	if s.labels != nil {
+		m.delete(s.labels)
+	}
+}
@@ -0,0 +1,365 @@
+package typedcollector
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/codegen"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/expfmt"
+)
+
+func readFull(t *testing.T) *typed.Stats {
+	b, err := os.ReadFile("../gen/testdata/full.json")
+	if err != nil {
+		t.Fatal("Reading stats failed:", err)
+	}
+	stats := &typed.Stats{}
+	err = json.Unmarshal(b, stats)
+	if err != nil {
+		t.Fatal("Unmarshal failed:", err)
+	}
+	return stats
+}
+
+func gather(t *testing.T, c prometheus.Collector) string {
+	r := prometheus.NewPedanticRegistry()
+	err := r.Register(c)
+	if err != nil {
+		t.Fatal("Register failed:", err)
+	}
+	mfs, err := r.Gather()
+	if err != nil {
+		t.Fatal("Gather failed:", err)
+	}
+	var buf bytes.Buffer
+	for _, mf := range mfs {
+		_, err = expfmt.MetricFamilyToText(&buf, mf)
+		if err != nil {
+			t.Fatal("MetricFamilyToText failed:", err)
+		}
+	}
+	return buf.String()
+}
+
+func TestGeneratedUpToDate(t *testing.T) {
+	var buf bytes.Buffer
+	err := codegen.Generate(&buf, "typedcollector", reflect.TypeOf(typed.Stats{}))
+	if err != nil {
+		t.Fatal("Generate failed:", err)
+	}
+	current, err := os.ReadFile("stats_collector.go")
+	if err != nil {
+		t.Fatal("Reading generated file failed:", err)
+	}
+	if !bytes.Equal(buf.Bytes(), current) {
+		t.Fatal("stats_collector.go is outdated. Run go generate.")
+	}
+}
+
+func optionSets() map[string][]gen.RecursiveMetricsOption {
+	return map[string][]gen.RecursiveMetricsOption{
+		"default":     nil,
+		"aggregation": {gen.WithAggregation("Stats.Topics[].Partitions")},
+		"namespace":   {gen.WithNamespace("kafka"), gen.WithConstLabels(prometheus.Labels{"service": "test"})},
+		"filter": {gen.WithMapEntryFilter("Stats.Topics[].Partitions", gen.NewMapEntryFilter(func(key typed.PartitionId, value typed.PartitionStats) bool {
+			return key != -1
+		}))},
+		"derived": {gen.WithDerivedMetrics("Stats.Brokers[]", gen.NewDerivedMetric("rx_per_tx", "Responses per request", func(bs *typed.BrokerStats) float64 {
+			return float64(bs.Rx) / float64(bs.Tx)
+		}))},
+	}
+}
+
+func TestSameAsReflection(t *testing.T) {
+	stats := readFull(t)
+	for name, opts := range optionSets() {
+		t.Run(name, func(t *testing.T) {
+			reflected, upd := gen.NewRecursiveMetricsFromTags(typed.Stats{}, opts...)
+			upd.Update(stats, prometheus.Labels{})
+			generated, err := NewStatsCollector(opts...)
+			if err != nil {
+				t.Fatal("NewStatsCollector failed:", err)
+			}
+			generated.Update(stats)
+
+			d := cmp.Diff(gather(t, reflected), gather(t, generated))
+			if d != "" {
+				t.Fatal("Diff", d)
+			}
+		})
+	}
+}
+
+func TestUpdateJSONSameAsUpdate(t *testing.T) {
+	full, err := os.ReadFile("../gen/testdata/full.json")
+	if err != nil {
+		t.Fatal("Reading stats failed:", err)
+	}
+	stats := readFull(t)
+	// Second update drops a broker and a partition
+	shrunk := readFull(t)
+	for name := range shrunk.Brokers {
+		delete(shrunk.Brokers, name)
+		break
+	}
+	for _, ts := range shrunk.Topics {
+		delete(ts.Partitions, 0)
+	}
+	shrunkJSON, err := json.Marshal(shrunk)
+	if err != nil {
+		t.Fatal("Marshal failed:", err)
+	}
+
+	for name, opts := range optionSets() {
+		if name == "derived" {
+			// See TestUpdateJSONWithoutDerived
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			decoded, err := NewStatsCollector(opts...)
+			if err != nil {
+				t.Fatal("NewStatsCollector failed:", err)
+			}
+			streamed, err := NewStatsCollector(opts...)
+			if err != nil {
+				t.Fatal("NewStatsCollector failed:", err)
+			}
+
+			decoded.Update(stats)
+			err = streamed.UpdateJSON(string(full))
+			if err != nil {
+				t.Fatal("UpdateJSON failed:", err)
+			}
+			d := cmp.Diff(gather(t, decoded), gather(t, streamed))
+			if d != "" {
+				t.Fatal("Diff", d)
+			}
+
+			decoded.Update(shrunk)
+			err = streamed.UpdateJSON(string(shrunkJSON))
+			if err != nil {
+				t.Fatal("UpdateJSON failed:", err)
+			}
+			d = cmp.Diff(gather(t, decoded), gather(t, streamed))
+			if d != "" {
+				t.Fatal("Diff after removal", d)
+			}
+		})
+	}
+}
+
+func TestUpdateJSONWithoutDerived(t *testing.T) {
+	c, err := NewStatsCollector(optionSets()["derived"]...)
+	if err != nil {
+		t.Fatal("NewStatsCollector failed:", err)
+	}
+	err = c.UpdateJSON(`{"name": "rdkafka#producer-1", "brokers": {"localhost:9092/2": {"tx": 2, "rx": 1}}}`)
+	if err != nil {
+		t.Fatal("UpdateJSON failed:", err)
+	}
+	count := testutil.CollectAndCount(c, "brokers_rx_per_tx")
+	if count != 0 {
+		t.Fatalf("Expected no derived series. Got %d", count)
+	}
+}
+
+func TestUpdateJSONFilterGetsCompleteEntry(t *testing.T) {
+	// Requests are not decoded for streaming, since they are not exported
+	c, err := NewStatsCollector(gen.WithMapEntryFilter("Stats.Brokers", gen.NewMapEntryFilter(func(key typed.BrokerName, value typed.BrokerStats) bool {
+		return value.Req["Produce"] != 0
+	})))
+	if err != nil {
+		t.Fatal("NewStatsCollector failed:", err)
+	}
+	err = c.UpdateJSON(`{"name": "rdkafka#producer-1", "brokers": {
+	"localhost:9092/2": {"name": "localhost:9092/2", "req": {"Produce": 1}},
+	"localhost:9092/3": {"name": "localhost:9092/3", "req": {"Produce": 0}}
+}}`)
+	if err != nil {
+		t.Fatal("UpdateJSON failed:", err)
+	}
+	count := testutil.CollectAndCount(c, "brokers_tx_total")
+	if count != 1 {
+		t.Fatalf("Expected the producing broker only. Got %d series", count)
+	}
+
+	err = c.UpdateJSON(`{"name": "rdkafka#producer-1", "brokers": {"localhost:9092/2": {"req": {"Produce": "many"}}}}`)
+	if !errors.Is(err, gen.ErrJSONSyntax) {
+		t.Fatal("Expected syntax error for entry to filter. Got:", err)
+	}
+}
+
+func TestAggregatedEntryReturnsSameAsReflection(t *testing.T) {
+	reflected, upd := gen.NewRecursiveMetricsFromTags(typed.Stats{}, gen.WithAggregation("Stats.Topics[].Partitions"))
+	generated, err := NewStatsCollector(gen.WithAggregation("Stats.Topics[].Partitions"))
+	if err != nil {
+		t.Fatal("NewStatsCollector failed:", err)
+	}
+	stats := readFull(t)
+	update := func() {
+		upd.Update(stats, prometheus.Labels{})
+		generated.Update(stats)
+	}
+	update()
+	// Partitions leave and return with increased counters
+	returned := map[typed.TopicName]typed.PartitionStats{}
+	for name, ts := range stats.Topics {
+		returned[name] = ts.Partitions[0]
+		delete(ts.Partitions, 0)
+	}
+	update()
+	for name, ps := range returned {
+		ps.Msgs++
+		stats.Topics[name].Partitions[0] = ps
+	}
+	update()
+
+	d := cmp.Diff(gather(t, reflected), gather(t, generated))
+	if d != "" {
+		t.Fatal("Diff", d)
+	}
+}
+
+func TestUpdateJSONMalformed(t *testing.T) {
+	c, err := NewStatsCollector()
+	if err != nil {
+		t.Fatal("NewStatsCollector failed:", err)
+	}
+	for _, data := range []string{
+		`{"name": "rdkafka#producer-1", "brokers": {`,
+		`{"name": "rdkafka#producer-1"} {}`,
+		`{"tx": "many"}`,
+		`{"brokers": {"b": {"nodeid": true}}}`,
+		`[]`,
+	} {
+		err = c.UpdateJSON(data)
+		if !errors.Is(err, gen.ErrJSONSyntax) {
+			t.Errorf("Expected syntax error for %s but got %v", data, err)
+		}
+	}
+}
+
+func TestUpdateJSONWrongTypeUnchanged(t *testing.T) {
+	c, err := NewStatsCollector()
+	if err != nil {
+		t.Fatal("NewStatsCollector failed:", err)
+	}
+	err = c.UpdateJSON(`{"name": "rdkafka#producer-1", "rx": 1, "brokers": {"localhost:9092/2": {"tx": 1}}}`)
+	if err != nil {
+		t.Fatal("UpdateJSON failed:", err)
+	}
+	expected := gather(t, c)
+
+	// The root value precedes the malformed one
+	err = c.UpdateJSON(`{"name": "rdkafka#producer-1", "rx": 7, "brokers": {"localhost:9092/2": {"tx": "many"}}}`)
+	if err == nil || err.Error() != `brokers: localhost:9092/2: tx: invalid JSON: "many" is not an integer` {
+		t.Fatal("Unexpected error:", err)
+	}
+	if d := cmp.Diff(expected, gather(t, c)); d != "" {
+		t.Fatal("Expected metrics to be unchanged (-want +got):\n", d)
+	}
+}
+
+func TestLabelChangeSameAsReflection(t *testing.T) {
+	stats := readFull(t)
+	reflected, upd := gen.NewRecursiveMetricsFromTags(typed.Stats{}, gen.WithAggregation("Stats.Topics[].Partitions"))
+	generated, err := NewStatsCollector(gen.WithAggregation("Stats.Topics[].Partitions"))
+	if err != nil {
+		t.Fatal("NewStatsCollector failed:", err)
+	}
+	upd.Update(stats, prometheus.Labels{})
+	generated.Update(stats)
+
+	// Bound series of the old labels need to be dropped
+	for name, bs := range stats.Brokers {
+		bs.State = "DOWN"
+		bs.Tx--
+		stats.Brokers[name] = bs
+	}
+	stats.Type = "unknown"
+	upd.Update(stats, prometheus.Labels{})
+	generated.Update(stats)
+
+	d := cmp.Diff(gather(t, reflected), gather(t, generated))
+	if d != "" {
+		t.Fatal("Diff", d)
+	}
+}
+
+func TestCount(t *testing.T) {
+	stats := readFull(t)
+	for name, opts := range optionSets() {
+		t.Run(name, func(t *testing.T) {
+			c, err := NewStatsCollector(opts...)
+			if err != nil {
+				t.Fatal("NewStatsCollector failed:", err)
+			}
+			c.Update(stats)
+			entries := map[string]int{}
+			series := c.Count(entries)
+			collected := testutil.CollectAndCount(c)
+			if series != collected {
+				t.Fatalf("Count returned %d series but %d got collected", series, collected)
+			}
+			if name == "aggregation" {
+				if _, ok := entries["topics_partitions"]; ok {
+					t.Fatal("Aggregated map must not track entries")
+				}
+			} else if entries["topics_partitions"] == 0 {
+				t.Fatal("Expected tracked partitions. Got:", entries)
+			}
+		})
+	}
+}
+
+func TestShareUsesOwnFuncs(t *testing.T) {
+	filter := func(partition typed.PartitionId) gen.RecursiveMetricsOption {
+		return gen.WithMapEntryFilter("Stats.Topics[].Partitions", gen.NewMapEntryFilter(func(key typed.PartitionId, value typed.PartitionStats) bool {
+			return key == partition
+		}))
+	}
+	c, err := NewStatsCollector(filter(2))
+	if err != nil {
+		t.Fatal("NewStatsCollector failed:", err)
+	}
+	other, err := NewStatsCollector(filter(1))
+	if err != nil {
+		t.Fatal("NewStatsCollector failed:", err)
+	}
+	shared, err := c.Share(other)
+	if err != nil {
+		t.Fatal("Share failed:", err)
+	}
+	stats := typed.Stats{
+		Name: "rdkafka#producer-2",
+		Topics: map[typed.TopicName]typed.TopicStats{
+			"test": {Topic: "test", Partitions: map[typed.PartitionId]typed.PartitionStats{
+				0: {Partition: 0},
+				1: {Partition: 1},
+			}},
+		},
+	}
+	shared.Update(&stats)
+	count := testutil.CollectAndCount(c, "topics_partitions_msgs_total")
+	if count != 1 {
+		t.Fatalf("Expected only partition 1 from the filter of other. Got %d series", count)
+	}
+
+	unfiltered, err := NewStatsCollector()
+	if err != nil {
+		t.Fatal("NewStatsCollector failed:", err)
+	}
+	_, err = c.Share(unfiltered)
+	if !errors.Is(err, gen.ErrIncompatibleOptions) {
+		t.Fatal("Expected ErrIncompatibleOptions. Got:", err)
+	}
+}
@@ -0,0 +1,14 @@
+package types
+
+import "reflect"
+
+// DerivedMetric describes a Gauge which is computed from a struct value
+// on every update instead of being read from a tagged field.
+type DerivedMetric struct {
+	Name string
+	Help string
+	// T is the struct type the metric is computed from
+	T reflect.Type
+	// Fun gets passed a pointer to a value of type T
+	Fun func(v interface{}) float64
+}
@@ -1,4 +1,20 @@
 package types
 
+import "reflect"
+
 type LabelNameTransformer func(value string) (labelName string)
 type MetricNameTransformer func(value string) (labelName string)
+
+// MapEntryFilter decides whether an entry of a map with keys of type Key
+// and values of type Value gets exported. Create via gen.NewMapEntryFilter.
+type MapEntryFilter struct {
+	Key   reflect.Type
+	Value reflect.Type
+	// Fun is a `func(key Key, value Value) bool` returning false for
+	// entries which should be skipped. Called directly by generated
+	// Collectors.
+	Fun interface{}
+	// Boxed calls Fun with a key and value of any type. Used when walking
+	// values via reflection.
+	Boxed func(key, value interface{}) bool
+}
@@ -0,0 +1,215 @@
+// Package statsd sends Stats as StatsD packets with DogStatsD tags (e.g. to
+// a DogStatsD agent).
+package statsd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/kafka/typed"
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+)
+
+// Option represents an opaque option implementation
+// for creating a Client
+type Option interface {
+}
+
+// WithPrefix creates an Option for prefixing all metric names (e.g. `kafka.`).
+func WithPrefix(prefix string) Option {
+	return &clientPrefix{
+		prefix: prefix,
+	}
+}
+
+// WithMaxPacketSize creates an Option for limiting the size of packets.
+// Defaults to 1432 bytes for UDP and 8192 bytes for Unix sockets.
+func WithMaxPacketSize(size int) Option {
+	return &clientMaxPacketSize{
+		size: size,
+	}
+}
+
+type clientPrefix struct {
+	prefix string
+}
+
+type clientMaxPacketSize struct {
+	size int
+}
+
+// Client sends the metrics of Stats updates. Counters are sent as the
+// increase since the last update, so nothing is sent on the first update
+// of a counter. Gauges are sent as their current value and `kpromlbl`
+// labels as DogStatsD tags.
+// A Client must not be updated concurrently.
+type Client struct {
+	conn          net.Conn
+	rec           *gen.Recorder
+	prefix        string
+	maxPacketSize int
+
+	counters map[string]*counter // By metric name and series
+	epoch    uint64
+	buf      []byte
+	err      error // First error of the current update
+}
+
+// counter is the last value of a counter series
+type counter struct {
+	last  int64
+	epoch uint64
+}
+
+// Dial creates a Client sending to address via network ("udp" or
+// "unixgram") the metrics named by naming (see `v0.MetricNaming`).
+func Dial(network, address string, naming *gen.GeneratedOptions, opts ...Option) (*Client, error) {
+	c := &Client{
+		rec:           naming.NewRecorder(),
+		maxPacketSize: 1432,
+		counters:      map[string]*counter{},
+	}
+	if strings.HasPrefix(network, "unix") {
+		c.maxPacketSize = 8192
+	}
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case *clientPrefix:
+			c.prefix = o.prefix
+		case *clientMaxPacketSize:
+			c.maxPacketSize = o.size
+		default:
+			panic(fmt.Sprintf("Unrecognized option %#v", opt))
+		}
+	}
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	return c, nil
+}
+
+// UpdateWithStatString decodes the JSON statistics of librdkafka and sends
+// their metrics
+func (c *Client) UpdateWithStatString(stats string) error {
+	decoded := &typed.Stats{}
+	err := json.Unmarshal([]byte(stats), decoded)
+	if err != nil {
+		return err
+	}
+	return c.Update(decoded)
+}
+
+// Update sends the metrics of stats. Returns the first error writing a
+// packet, in which case the remaining packets are sent regardless.
+func (c *Client) Update(stats *typed.Stats) error {
+	c.epoch++
+	c.err = nil
+	c.buf = c.buf[:0]
+	c.rec.Record(stats, c)
+	c.flush()
+	// Series gone from stats start over when they come back
+	for k, s := range c.counters {
+		if s.epoch != c.epoch {
+			delete(c.counters, k)
+		}
+	}
+	return c.err
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Record implements gen.MetricSink. Counters are identified by their tags.
+func (c *Client) Record(desc *gen.MetricDesc, labels map[string]string, value float64) {
+	c.RecordSeries(desc, formatTags(labels), labels, value)
+}
+
+// RecordSeries implements gen.SeriesSink. Counters are identified by series,
+// so their increase is sent with the new tags when labels change (e.g. the
+// state of a broker).
+func (c *Client) RecordSeries(desc *gen.MetricDesc, series string, labels map[string]string, value float64) {
+	tags := formatTags(labels)
+	if desc.Type != "counter" {
+		c.write(c.prefix + desc.Name + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|g" + tags)
+		return
+	}
+	name := c.prefix + strings.TrimSuffix(desc.Name, "_total")
+	current := int64(value)
+	key := desc.Name + series
+	s, ok := c.counters[key]
+	if !ok {
+		// The increase before the first sighting is unknown
+		c.counters[key] = &counter{
+			last:  current,
+			epoch: c.epoch,
+		}
+		return
+	}
+	s.epoch = c.epoch
+	diff := current - s.last
+	if diff < 0 {
+		// Wait for the counter to catch up
+		return
+	}
+	s.last = current
+	if diff == 0 {
+		return
+	}
+	c.write(name + ":" + strconv.FormatInt(diff, 10) + "|c" + tags)
+}
+
+// write adds line to the current packet. Sends the packet first if line
+// does not fit anymore.
+func (c *Client) write(line string) {
+	if len(c.buf) != 0 && len(c.buf)+1+len(line) > c.maxPacketSize {
+		c.flush()
+	}
+	if len(c.buf) != 0 {
+		c.buf = append(c.buf, '\n')
+	}
+	c.buf = append(c.buf, line...)
+}
+
+func (c *Client) flush() {
+	if len(c.buf) == 0 {
+		return
+	}
+	_, err := c.conn.Write(c.buf)
+	if err != nil && c.err == nil {
+		c.err = err
+	}
+	c.buf = c.buf[:0]
+}
+
+var tagReplacer = strings.NewReplacer(",", "_", "|", "_", "\n", "_")
+
+// formatTags formats labels as DogStatsD tags sorted by name
+func formatTags(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	b := &strings.Builder{}
+	b.WriteString("|#")
+	for i, k := range names {
+		if i != 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteByte(':')
+		b.WriteString(tagReplacer.Replace(labels[k]))
+	}
+	return b.String()
+}
@@ -0,0 +1,160 @@
+package statsd
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	v0 "github.com/abergmeier/kafka_stats_exporter/v0"
+)
+
+const statsTemplate = `{
+	"name": "rdkafka#consumer-1",
+	"client_id": "rdkafka",
+	"type": "consumer",
+	"rx": %d,
+	"msg_cnt": %d
+}`
+
+// receive returns the lines of all packets received until no more arrive
+func receive(t *testing.T, pc net.PacketConn) (lines []string, packets int) {
+	buf := make([]byte, 65536)
+	for {
+		err := pc.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			return lines, packets
+		}
+		packets++
+		lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+	}
+}
+
+func contains(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+func TestUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("ListenPacket failed:", err)
+	}
+	defer pc.Close()
+
+	naming, err := v0.MetricNaming()
+	if err != nil {
+		t.Fatal("MetricNaming failed:", err)
+	}
+	c, err := Dial("udp", pc.LocalAddr().String(), naming, WithPrefix("kafka."), WithMaxPacketSize(512))
+	if err != nil {
+		t.Fatal("Dial failed:", err)
+	}
+	defer c.Close()
+
+	tags := "|#client_id:rdkafka,name:rdkafka#consumer-1,type:consumer"
+	for _, step := range []struct {
+		rx, msgCnt int
+		expected   []string
+		missing    []string
+	}{
+		// The increase before the first update is unknown
+		{rx: 5, msgCnt: 3, expected: []string{"kafka.msg_cnt:3|g" + tags}, missing: []string{"kafka.rx:5|c" + tags}},
+		// Unchanged counters are not sent
+		{rx: 5, msgCnt: 1, expected: []string{"kafka.msg_cnt:1|g" + tags}, missing: []string{"kafka.rx:5|c" + tags}},
+		{rx: 8, msgCnt: 1, expected: []string{"kafka.rx:3|c" + tags}},
+		// Decreasing counters wait to catch up
+		{rx: 6, msgCnt: 1, missing: []string{"kafka.rx:-2|c" + tags}},
+		{rx: 10, msgCnt: 1, expected: []string{"kafka.rx:2|c" + tags}},
+	} {
+		err = c.UpdateWithStatString(fmt.Sprintf(statsTemplate, step.rx, step.msgCnt))
+		if err != nil {
+			t.Fatal("UpdateWithStatString failed:", err)
+		}
+		lines, packets := receive(t, pc)
+		if packets < 2 {
+			t.Errorf("Expected lines split into multiple packets but got %d", packets)
+		}
+		for _, e := range step.expected {
+			if !contains(lines, e) {
+				t.Errorf("Missing `%s` in %v", e, lines)
+			}
+		}
+		for _, m := range step.missing {
+			if contains(lines, m) {
+				t.Errorf("Unexpected `%s`", m)
+			}
+		}
+		for _, l := range lines {
+			if len(l) == 0 || strings.Count(l, "|") != 2 {
+				t.Errorf("Malformed line `%s`", l)
+			}
+		}
+	}
+}
+
+func TestCounterLabelChange(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("ListenPacket failed:", err)
+	}
+	defer pc.Close()
+
+	naming, err := v0.MetricNaming()
+	if err != nil {
+		t.Fatal("MetricNaming failed:", err)
+	}
+	c, err := Dial("udp", pc.LocalAddr().String(), naming)
+	if err != nil {
+		t.Fatal("Dial failed:", err)
+	}
+	defer c.Close()
+
+	const brokerTemplate = `{
+	"name": "rdkafka#consumer-1",
+	"brokers": {
+		"localhost:9092/2": {"name": "localhost:9092/2", "nodeid": 2, "state": "%s", "tx": %d}
+	}
+}`
+	tags := "|#brokers_name:localhost:9092/2,brokers_nodeid:2,brokers_nodename:,brokers_source:,brokers_state:%s,client_id:,name:rdkafka#consumer-1,type:"
+	for _, step := range []struct {
+		state    string
+		tx       int
+		expected string
+		missing  string
+	}{
+		{state: "UP", tx: 10, missing: "brokers_tx:10|c" + fmt.Sprintf(tags, "UP")},
+		{state: "UP", tx: 12, expected: "brokers_tx:2|c" + fmt.Sprintf(tags, "UP")},
+		// Only the increase is sent with the new state
+		{state: "DOWN", tx: 15, expected: "brokers_tx:3|c" + fmt.Sprintf(tags, "DOWN"), missing: "brokers_tx:15|c" + fmt.Sprintf(tags, "DOWN")},
+	} {
+		err = c.UpdateWithStatString(fmt.Sprintf(brokerTemplate, step.state, step.tx))
+		if err != nil {
+			t.Fatal("UpdateWithStatString failed:", err)
+		}
+		lines, _ := receive(t, pc)
+		if step.expected != "" && !contains(lines, step.expected) {
+			t.Errorf("Missing `%s` in %v", step.expected, lines)
+		}
+		if step.missing != "" && contains(lines, step.missing) {
+			t.Errorf("Unexpected `%s`", step.missing)
+		}
+	}
+}
+
+func TestFormatTags(t *testing.T) {
+	tags := formatTags(map[string]string{"b": "x,y|z", "a": "localhost:9092/2"})
+	expected := "|#a:localhost:9092/2,b:x_y_z"
+	if tags != expected {
+		t.Errorf("Expected `%s` but got `%s`", expected, tags)
+	}
+}
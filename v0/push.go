@@ -0,0 +1,138 @@
+package v0
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Label names of Stats used as grouping key
+const (
+	groupingClientID = "client_id"
+	groupingName     = "name"
+)
+
+// pusher pushes the metrics of one client to a Pushgateway
+type pusher struct {
+	url           string
+	job           string
+	interval      time.Duration
+	deleteOnClose bool
+	failures      prometheus.Counter
+
+	p    *push.Pusher // nil until the grouping key is known
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// start pushes c grouped by clientID and name every interval. Only the
+// first call has an effect.
+func (p *pusher) start(c prometheus.Collector, clientID, name string) {
+	if p.p != nil {
+		return
+	}
+	r := prometheus.NewRegistry()
+	r.MustRegister(c)
+	p.p = push.New(p.url, p.job).
+		Gatherer(&groupGatherer{
+			g: r,
+			grouping: map[string]string{
+				groupingClientID: clientID,
+				groupingName:     name,
+			},
+		}).
+		Grouping(groupingClientID, clientID).
+		Grouping(groupingName, name)
+	p.stop = make(chan struct{})
+	p.done.Add(1)
+	go p.run()
+}
+
+func (p *pusher) run() {
+	defer p.done.Done()
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-t.C:
+			p.push()
+		}
+	}
+}
+
+func (p *pusher) push() error {
+	err := p.p.Push()
+	if err != nil {
+		p.failures.Inc()
+	}
+	return err
+}
+
+// close stops pushing and pushes the final metrics. Deletes the group
+// afterwards if deleteOnClose is set, even if the push failed.
+func (p *pusher) close() error {
+	if p.p == nil {
+		return nil
+	}
+	close(p.stop)
+	p.done.Wait()
+	err := p.push()
+	if p.deleteOnClose {
+		deleteErr := p.p.Delete()
+		if err == nil {
+			err = deleteErr
+		}
+	}
+	return err
+}
+
+// groupGatherer gathers only the series of one group and drops the grouping
+// labels, which are added by the Pushgateway. Collectors of Exporters may be
+// shared by several clients (see NewExporter).
+type groupGatherer struct {
+	g        prometheus.Gatherer
+	grouping map[string]string
+}
+
+func (g *groupGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.g.Gather()
+	filtered := mfs[:0]
+	for _, mf := range mfs {
+		metrics := mf.Metric[:0]
+		for _, m := range mf.Metric {
+			if g.inGroup(m) {
+				metrics = append(metrics, m)
+			}
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+		mf.Metric = metrics
+		filtered = append(filtered, mf)
+	}
+	return filtered, err
+}
+
+// inGroup removes the grouping labels from m and reports whether their
+// values match. Labels of gathered metrics are shared with the Collector
+// and thus not modified in place.
+func (g *groupGatherer) inGroup(m *dto.Metric) bool {
+	labels := make([]*dto.LabelPair, 0, len(m.Label))
+	for _, l := range m.Label {
+		value, ok := g.grouping[l.GetName()]
+		if !ok {
+			labels = append(labels, l)
+			continue
+		}
+		if l.GetValue() != value {
+			return false
+		}
+	}
+	m.Label = labels
+	return true
+}
@@ -0,0 +1,201 @@
+package v0
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const pushStats = `{
+	"name": "rdkafka#consumer-1",
+	"client_id": "rdkafka",
+	"brokers": {
+		"localhost:9092/2": {"name": "localhost:9092/2", "nodeid": 2, "source": "learned", "rx": 2}
+	}
+}`
+
+// gateway records the requests to a Pushgateway
+type gateway struct {
+	mu       sync.Mutex
+	requests []string // Method and sorted grouping key
+	bodies   []string // In text format
+	pushed   chan struct{}
+}
+
+func newGateway(t *testing.T) (*gateway, *httptest.Server) {
+	g := &gateway{
+		pushed: make(chan struct{}, 100),
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := decodeBody(r)
+		if err != nil {
+			t.Error("Decoding body failed:", err)
+		}
+		g.mu.Lock()
+		g.requests = append(g.requests, r.Method+" "+groupingKey(r.URL.EscapedPath()))
+		g.bodies = append(g.bodies, body)
+		g.mu.Unlock()
+		g.pushed <- struct{}{}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(s.Close)
+	return g, s
+}
+
+func (g *gateway) recorded() ([]string, []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string(nil), g.requests...), append([]string(nil), g.bodies...)
+}
+
+// groupingKey sorts the label pairs of the path of a push. Their order
+// is unspecified.
+func groupingKey(path string) string {
+	segments := strings.Split(strings.TrimPrefix(path, "/metrics/"), "/")
+	var pairs []string
+	for i := 0; i+1 < len(segments); i += 2 {
+		pairs = append(pairs, segments[i]+"/"+segments[i+1])
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "/")
+}
+
+// decodeBody returns the pushed metrics in text format
+func decodeBody(r *http.Request) (string, error) {
+	dec := expfmt.NewDecoder(r.Body, expfmt.ResponseFormat(r.Header))
+	var buf bytes.Buffer
+	for {
+		mf := &dto.MetricFamily{}
+		err := dec.Decode(mf)
+		if errors.Is(err, io.EOF) {
+			return buf.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = expfmt.MetricFamilyToText(&buf, mf)
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+const groupPath = "client_id/rdkafka/job/kafka/name/rdkafka%23consumer-1"
+
+func TestWithPushgateway(t *testing.T) {
+	g, s := newGateway(t)
+	e := NewExporter(prometheus.NewRegistry(), WithPushgateway(s.URL, "kafka", 10*time.Millisecond))
+	err := e.UpdateWithStatString(pushStats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	select {
+	case <-g.pushed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected push within interval")
+	}
+	err = e.Close()
+	if err != nil {
+		t.Fatal("Close failed:", err)
+	}
+
+	requests, bodies := g.recorded()
+	if len(requests) < 2 {
+		t.Fatalf("Expected periodic and final push. Got: %v", requests)
+	}
+	for i, r := range requests {
+		if r != "PUT "+groupPath {
+			t.Fatalf("Unexpected request %d: %s", i, r)
+		}
+	}
+	last := bodies[len(bodies)-1]
+	if !strings.Contains(last, `brokers_rx_total{brokers_name="localhost:9092/2",brokers_nodeid="2",brokers_nodename="",brokers_source="learned",brokers_state="",type=""} 2`) {
+		t.Fatalf("Expected metrics without grouping labels. Got:\n%s", last)
+	}
+}
+
+func TestWithPushgatewayDeleteOnClose(t *testing.T) {
+	g, s := newGateway(t)
+	e := NewExporter(prometheus.NewRegistry(), WithPushgateway(s.URL, "kafka", time.Hour), WithPushgatewayDeleteOnClose())
+	err := e.UpdateWithStatString(pushStats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	err = e.Close()
+	if err != nil {
+		t.Fatal("Close failed:", err)
+	}
+
+	requests, bodies := g.recorded()
+	expected := []string{"PUT " + groupPath, "DELETE " + groupPath}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Fatalf("Expected final push before deleting the group. Got: %v", requests)
+	}
+	if !strings.Contains(bodies[0], "\nbrokers_rx_total{") {
+		t.Fatalf("Expected final metrics to be pushed. Got:\n%s", bodies[0])
+	}
+}
+
+func TestWithPushgatewaySharedCollector(t *testing.T) {
+	g, s := newGateway(t)
+	r := prometheus.NewRegistry()
+	other := NewExporter(r)
+	err := other.StreamStatString(strings.Replace(pushStats, "consumer-1", "consumer-2", 1))
+	if err != nil {
+		t.Fatal("StreamStatString failed:", err)
+	}
+	e := NewExporter(r, WithPushgateway(s.URL, "kafka", time.Hour))
+	err = e.StreamStatString(pushStats)
+	if err != nil {
+		t.Fatal("StreamStatString failed:", err)
+	}
+	err = e.Close()
+	if err != nil {
+		t.Fatal("Close failed:", err)
+	}
+
+	requests, bodies := g.recorded()
+	if len(requests) != 1 || requests[0] != "PUT "+groupPath {
+		t.Fatalf("Expected final push. Got: %v", requests)
+	}
+	if n := strings.Count(bodies[0], "\nbrokers_rx_total{"); n != 1 {
+		t.Fatalf("Expected only series of the pushing client. Got %d:\n%s", n, bodies[0])
+	}
+}
+
+func TestWithPushgatewayInterval(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected panic for non-positive interval")
+		}
+	}()
+	WithPushgateway("http://localhost:9091", "kafka", 0)
+}
+
+func TestPushFailures(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+	e := NewExporter(prometheus.NewRegistry(), WithPushgateway(s.URL, "kafka", time.Hour))
+	err := e.UpdateWithStatString(pushStats)
+	if err != nil {
+		t.Fatal("UpdateWithStatString failed:", err)
+	}
+	err = e.Close()
+	if err == nil {
+		t.Fatal("Expected Close to fail on failing push")
+	}
+}
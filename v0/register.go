@@ -0,0 +1,56 @@
+package v0
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// registration is a Collector registered with a Registerer
+type registration struct {
+	r prometheus.Registerer
+	c prometheus.Collector
+}
+
+var (
+	// Number of Exporters using a registration
+	registrationsMu sync.Mutex
+	registrations   = map[registration]int{}
+)
+
+// register registers c with r. If a Collector with identical descriptors
+// and of the same type is already registered (e.g. by an Exporter of a
+// recreated client), the existing Collector is returned instead.
+func register[C prometheus.Collector](r prometheus.Registerer, c C) (C, error) {
+	err := r.Register(c)
+	if err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return c, err
+		}
+		existing, ok := are.ExistingCollector.(C)
+		if !ok {
+			return c, err
+		}
+		c = existing
+	}
+
+	registrationsMu.Lock()
+	defer registrationsMu.Unlock()
+	registrations[registration{r: r, c: c}]++
+	return c, nil
+}
+
+// unregister unregisters c from r once no Exporter uses it anymore
+func unregister(r prometheus.Registerer, c prometheus.Collector) {
+	registrationsMu.Lock()
+	defer registrationsMu.Unlock()
+	key := registration{r: r, c: c}
+	registrations[key]--
+	if registrations[key] > 0 {
+		return
+	}
+	delete(registrations, key)
+	r.Unregister(c)
+}
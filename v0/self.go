@@ -0,0 +1,215 @@
+package v0
+
+import (
+	"strings"
+	"time"
+
+	"github.com/abergmeier/kafka_stats_exporter/v0/pkg/prometheus/gen"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// selfMetrics observe the Exporter itself. Metrics are shared by all
+// Exporters registered with the same Registerer.
+type selfMetrics struct {
+	updates       prometheus.Counter
+	parseFailures prometheus.Counter
+	duration      prometheus.Histogram
+	lastUpdate    *prometheus.GaugeVec
+	mapEntries    *prometheus.GaugeVec
+	series        *prometheus.GaugeVec
+	pushFailures  prometheus.Counter
+
+	client     string         // Of the last successful update
+	entries    map[string]int // Reused for counting
+	registered bool
+}
+
+// selfOptions name the metrics about the Exporter like the exported ones
+type selfOptions struct {
+	namespace   string
+	subsystem   string
+	constLabels prometheus.Labels
+}
+
+// newSelfMetrics creates the metrics without registering them
+func newSelfMetrics(o selfOptions) *selfMetrics {
+	s := &selfMetrics{
+		updates: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			Subsystem:   o.subsystem,
+			ConstLabels: o.constLabels,
+			Name:        "kafka_stats_exporter_updates_total",
+			Help:        "Statistics updates received",
+		}),
+		parseFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			Subsystem:   o.subsystem,
+			ConstLabels: o.constLabels,
+			Name:        "kafka_stats_exporter_parse_failures_total",
+			Help:        "Statistics updates, which could not be parsed",
+		}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   o.namespace,
+			Subsystem:   o.subsystem,
+			ConstLabels: o.constLabels,
+			Name:        "kafka_stats_exporter_update_duration_seconds",
+			Help:        "Duration of successful statistics updates",
+			Buckets:     prometheus.ExponentialBuckets(0.0001, 4, 8),
+		}),
+		lastUpdate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			Subsystem:   o.subsystem,
+			ConstLabels: o.constLabels,
+			Name:        "kafka_stats_exporter_last_update_timestamp_seconds",
+			Help:        "Unix time of the last successful statistics update",
+		}, []string{"client"}),
+		mapEntries: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			Subsystem:   o.subsystem,
+			ConstLabels: o.constLabels,
+			Name:        "kafka_stats_exporter_map_entries",
+			Help:        "Entries tracked per statistics map (e.g. topics_partitions)",
+		}, []string{"client", "map"}),
+		series: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			Subsystem:   o.subsystem,
+			ConstLabels: o.constLabels,
+			Name:        "kafka_stats_exporter_series",
+			Help:        "Series currently exported from statistics",
+		}, []string{"client"}),
+		pushFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			Subsystem:   o.subsystem,
+			ConstLabels: o.constLabels,
+			Name:        "kafka_stats_exporter_push_failures_total",
+			Help:        "Pushes to the Pushgateway, which failed",
+		}),
+		entries: map[string]int{},
+	}
+	return s
+}
+
+// register registers the metrics with r unless already done. Reports
+// whether they got registered by this call. Nothing stays registered on
+// failure.
+func (s *selfMetrics) register(r prometheus.Registerer) (bool, error) {
+	if s.registered {
+		return false, nil
+	}
+	var registered []prometheus.Collector
+	err := registerTo(r, &s.updates, &registered)
+	if err == nil {
+		err = registerTo(r, &s.parseFailures, &registered)
+	}
+	if err == nil {
+		err = registerTo(r, &s.duration, &registered)
+	}
+	if err == nil {
+		err = registerTo(r, &s.lastUpdate, &registered)
+	}
+	if err == nil {
+		err = registerTo(r, &s.mapEntries, &registered)
+	}
+	if err == nil {
+		err = registerTo(r, &s.series, &registered)
+	}
+	if err == nil {
+		err = registerTo(r, &s.pushFailures, &registered)
+	}
+	if err != nil {
+		for _, c := range registered {
+			unregister(r, c)
+		}
+		return false, err
+	}
+	s.registered = true
+	return true, nil
+}
+
+// registerTo registers *c with r, replaces it with the Collector in use
+// and appends that to registered
+func registerTo[C prometheus.Collector](r prometheus.Registerer, c *C, registered *[]prometheus.Collector) error {
+	existing, err := register(r, *c)
+	if err != nil {
+		return err
+	}
+	*c = existing
+	*registered = append(*registered, existing)
+	return nil
+}
+
+// counter is implemented by Collectors counting their series
+type counter interface {
+	Count(entries map[string]int) int
+}
+
+// observe records a successful update of client, which started at start
+func (s *selfMetrics) observe(c counter, client string, start time.Time) {
+	now := time.Now()
+	s.duration.Observe(now.Sub(start).Seconds())
+	if client != s.client {
+		s.deleteClient()
+		s.client = strings.Clone(client)
+	}
+	s.lastUpdate.WithLabelValues(s.client).Set(float64(now.UnixNano()) / 1e9)
+
+	// Maps without entries keep being reported
+	for k := range s.entries {
+		s.entries[k] = 0
+	}
+	series := c.Count(s.entries)
+	s.series.WithLabelValues(s.client).Set(float64(series))
+	for m, n := range s.entries {
+		s.mapEntries.WithLabelValues(s.client, m).Set(float64(n))
+	}
+}
+
+// deleteClient removes the series of the last updated client
+func (s *selfMetrics) deleteClient() {
+	if s.client == "" {
+		return
+	}
+	s.lastUpdate.DeleteLabelValues(s.client)
+	s.series.DeleteLabelValues(s.client)
+	for m := range s.entries {
+		s.mapEntries.DeleteLabelValues(s.client, m)
+	}
+	s.client = ""
+}
+
+func (s *selfMetrics) close(r prometheus.Registerer) {
+	if !s.registered {
+		return
+	}
+	s.registered = false
+	s.deleteClient()
+	unregister(r, s.updates)
+	unregister(r, s.parseFailures)
+	unregister(r, s.duration)
+	unregister(r, s.lastUpdate)
+	unregister(r, s.mapEntries)
+	unregister(r, s.series)
+	unregister(r, s.pushFailures)
+}
+
+// clientName returns the name of the client from JSON stats without
+// decoding them. librdkafka writes the name first.
+func clientName(stats string) string {
+	return topLevelString(stats, "name")
+}
+
+// topLevelString returns the string field key of JSON stats without
+// decoding them
+func topLevelString(stats string, key string) string {
+	o := gen.NewJSONObject(stats)
+	for o.Next() {
+		if o.Key() == key {
+			s, err := gen.JSONString(o.Value())
+			if err != nil {
+				return ""
+			}
+			return s
+		}
+	}
+	return ""
+}